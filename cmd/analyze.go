@@ -6,6 +6,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/ssulei7/gh-dormant-users/internal/analysis"
+	"github.com/ssulei7/gh-dormant-users/internal/report"
 	"github.com/ssulei7/gh-dormant-users/internal/ui"
 )
 
@@ -45,12 +46,14 @@ Available templates:
   - custom:          Custom analysis with user-provided prompt`,
 		RunE: runAnalyze,
 	}
-	cmd.Flags().StringP("file", "f", "", "Path to the CSV file to analyze")
+	cmd.Flags().StringP("file", "f", "", "Path to the report to analyze (CSV, JSON or NDJSON)")
 	cmd.Flags().StringP("template", "t", "summary", "Analysis template to use")
 	cmd.Flags().StringP("prompt", "p", "", "Custom prompt (only used with 'custom' template)")
 	cmd.Flags().Bool("list-templates", false, "List available analysis templates")
 	cmd.Flags().Bool("check-copilot", false, "Check if Copilot CLI is available")
 	cmd.Flags().Bool("prompt-only", false, "Only generate the prompt without sending to Copilot")
+	cmd.Flags().String("html", "", "Also write an HTML report of the file to this path, with the analysis as a section")
+	cmd.MarkFlagsMutuallyExclusive("html", "prompt-only")
 	return cmd
 }
 
@@ -123,5 +126,17 @@ func runAnalyze(cmd *cobra.Command, args []string) error {
 	ui.Header("Analysis Results")
 	ui.Println()
 	ui.Println(response)
+
+	htmlPath, _ := cmd.Flags().GetString("html")
+	if htmlPath == "" {
+		return nil
+	}
+	parsed, err := report.Load(csvFile)
+	if err != nil {
+		return fmt.Errorf("read report for HTML: %w", err)
+	}
+	if err := report.WriteHTML(htmlPath, *parsed, response); err != nil {
+		return fmt.Errorf("write HTML report: %w", err)
+	}
 	return nil
 }
@@ -204,3 +204,25 @@ func TestAnalyzeCommandStopsSpinnerOnFailure(t *testing.T) {
 		t.Fatalf("spinner = %#v", spinner)
 	}
 }
+
+func TestAnalyzeCommandWritesHTMLWithAnalysis(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.csv")
+	if err := os.WriteFile(path, []byte("Username,Email,Active,ActivityTypes\noctocat,,true,commits\nhubot,,false,none\n"), 0o600); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	htmlPath := filepath.Join(t.TempDir(), "report.html")
+	analyzer := &fakeAnalyzer{available: true, response: "Remove <hubot> first"}
+	configureAnalyzeTest(t, analyzer, &fakeSpinner{})
+
+	if err := executeAnalyze("--file", path, "--html", htmlPath); err != nil {
+		t.Fatalf("execute analyze: %v", err)
+	}
+	data, err := os.ReadFile(htmlPath)
+	if err != nil {
+		t.Fatalf("read HTML report: %v", err)
+	}
+	page := string(data)
+	if !strings.Contains(page, "Remove &lt;hubot&gt; first") || !strings.Contains(page, "<td>octocat</td>") {
+		t.Fatalf("HTML report does not contain the analysis and members:\n%s", page)
+	}
+}
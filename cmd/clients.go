@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/cli/go-gh"
+	"github.com/cli/go-gh/pkg/api"
+	"github.com/cli/go-gh/pkg/auth"
+	"github.com/ssulei7/gh-dormant-users/internal/appauth"
+	"github.com/ssulei7/gh-dormant-users/internal/githubapi"
+)
+
+type githubClients struct {
+	coordinator *githubapi.Coordinator
+	rest        api.RESTClient
+	gql         api.GQLClient
+}
+
+// newGitHubClients builds REST and GraphQL clients that send every request
+// through a single Coordinator so they share throttling and rate-limit state.
+// An empty host uses gh's default host, which honours GH_HOST. When app is
+// set, requests authenticate as that GitHub App installation instead of
+// with gh's token, and when config has a token pool, with the pool's tokens.
+func newGitHubClients(host string, app *appauth.Config, config githubapi.Config) (*githubClients, error) {
+	coordinator, err := githubapi.NewCoordinator(config)
+	if err != nil {
+		return nil, fmt.Errorf("configure GitHub API requests: %w", err)
+	}
+	var transport http.RoundTripper = coordinator
+	authToken := ""
+	if app != nil {
+		app.BaseURL = apiBaseURL(host)
+		appTransport, err := appauth.NewTransport(*app, coordinator)
+		if err != nil {
+			return nil, err
+		}
+		transport = appTransport
+		// gh requires a token up front; the app transport replaces it with
+		// the installation token on every request.
+		authToken = "github-app-installation"
+	} else if len(config.Tokens) > 0 {
+		// The Coordinator replaces it with a token from the pool
+		authToken = "token-pool"
+	} else if _, replaying := config.Transport.(*githubapi.Replayer); replaying {
+		// Replayed requests never reach GitHub, so gh's login is not needed
+		authToken = "replay"
+	}
+	clientOptions := func() *api.ClientOptions {
+		return &api.ClientOptions{
+			Host:      host,
+			AuthToken: authToken,
+			Transport: transport,
+			Headers: map[string]string{
+				"Accept":                   "application/vnd.github+json",
+				githubapi.APIVersionHeader: githubapi.APIVersion,
+			},
+		}
+	}
+	restClient, err := gh.RESTClient(clientOptions())
+	if err != nil {
+		return nil, fmt.Errorf("create REST client for %s: %w", displayHost(host), err)
+	}
+	gqlClient, err := gh.GQLClient(clientOptions())
+	if err != nil {
+		return nil, fmt.Errorf("create GraphQL client for %s: %w", displayHost(host), err)
+	}
+	return &githubClients{coordinator: coordinator, rest: restClient, gql: gqlClient}, nil
+}
+
+// defaultHost is the host gh uses when --hostname is not set: GH_HOST, or
+// the only host gh is logged in to, or github.com.
+var defaultHost = func() string {
+	host, _ := auth.DefaultHost()
+	return host
+}
+
+// resolveHostname returns the host a --hostname flag selects: the default
+// host when it is empty, or otherwise the normalized host name.
+func resolveHostname(hostname string) (string, error) {
+	if hostname == "" {
+		return defaultHost(), nil
+	}
+	return normalizeHostname(hostname)
+}
+
+// normalizeHostname accepts a bare host name or a URL copied from a browser
+// and returns the host name gh expects.
+func normalizeHostname(hostname string) (string, error) {
+	host := strings.TrimSpace(hostname)
+	host = strings.TrimPrefix(strings.TrimPrefix(host, "https://"), "http://")
+	host = strings.ToLower(strings.TrimSuffix(host, "/"))
+	if host == "" || strings.ContainsAny(host, "/?# ") {
+		return "", fmt.Errorf("invalid hostname %q; expected a host name such as github.example.com", hostname)
+	}
+	return host, nil
+}
+
+// appCredentials reads the GitHub App flags, returning nil when the run uses
+// gh's token.
+func appCredentials(appID int64, privateKeyPath string, installationID int64) (*appauth.Config, error) {
+	if appID == 0 && privateKeyPath == "" && installationID == 0 {
+		return nil, nil
+	}
+	if appID <= 0 || privateKeyPath == "" || installationID <= 0 {
+		return nil, fmt.Errorf("--app-id, --app-private-key and --installation-id must be used together")
+	}
+	privateKey, err := os.ReadFile(privateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("read app private key: %w", err)
+	}
+	return &appauth.Config{AppID: appID, InstallationID: installationID, PrivateKey: privateKey}, nil
+}
+
+// apiTransport sends requests over the network, saving each exchange to
+// record when it is set, or answers them from the recording in replay.
+func apiTransport(record string, replay string) (http.RoundTripper, error) {
+	switch {
+	case replay != "":
+		replayer, err := githubapi.NewReplayer(replay)
+		if err != nil {
+			return nil, fmt.Errorf("load recording: %w", err)
+		}
+		return replayer, nil
+	case record != "":
+		return githubapi.NewRecorder(record, http.DefaultTransport)
+	default:
+		return http.DefaultTransport, nil
+	}
+}
+
+// readTokenFile reads a token pool file: one token per line, ignoring blank
+// lines and lines starting with #.
+func readTokenFile(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read token file: %w", err)
+	}
+	var tokens []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		tokens = append(tokens, line)
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("token file %s contains no tokens", path)
+	}
+	return tokens, nil
+}
+
+// apiBaseURL is the REST API root of a host
+func apiBaseURL(host string) string {
+	if host == "" || host == "github.com" {
+		return "https://api.github.com/"
+	}
+	return "https://" + host + "/api/v3/"
+}
+
+func displayHost(host string) string {
+	if host == "" {
+		return "the default host"
+	}
+	return host
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/ssulei7/gh-dormant-users/internal/report"
+	"github.com/ssulei7/gh-dormant-users/internal/ui"
+)
+
+var (
+	diffOutput   io.Writer = os.Stdout
+	diffWarnings io.Writer = os.Stderr
+)
+
+var diffCmd = newDiffCommand()
+
+func newDiffCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <earlier-report> <later-report>",
+		Short: "Compare two reports",
+		Long: `Compare two reports from earlier runs, in any format the report command
+writes as data (CSV, JSON or NDJSON), and list the members who became
+dormant, were reactivated, joined or left, and whose activity types changed.`,
+		Args: cobra.ExactArgs(2),
+		RunE: runDiff,
+	}
+	cmd.Flags().String("format", "terminal", "Output format: terminal, csv or json")
+	cmd.Flags().StringP("output", "o", "", "Write the csv or json output to this file instead of standard output")
+	return cmd
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	output, _ := cmd.Flags().GetString("output")
+	format = strings.ToLower(format)
+	switch format {
+	case "terminal", "csv", "json":
+	default:
+		return fmt.Errorf("invalid format %q; expected terminal, csv or json", format)
+	}
+	if format == "terminal" && output != "" {
+		return fmt.Errorf("--output requires --format csv or json")
+	}
+
+	before, err := report.Load(args[0])
+	if err != nil {
+		return fmt.Errorf("read %s: %w", args[0], err)
+	}
+	after, err := report.Load(args[1])
+	if err != nil {
+		return fmt.Errorf("read %s: %w", args[1], err)
+	}
+	diff := report.Compare(*before, *after)
+
+	if format == "terminal" {
+		printDiff(args[0], args[1], diff)
+		return nil
+	}
+	if output == "" {
+		// Standard output holds the data, so caveats go to standard error.
+		for _, caveat := range diff.Caveats {
+			fmt.Fprintf(diffWarnings, "Warning: %s\n", caveat)
+		}
+		return encodeDiff(diffOutput, format, diff)
+	}
+	for _, caveat := range diff.Caveats {
+		ui.Warning("%s", caveat)
+	}
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := bufio.NewWriter(file)
+	if err := encodeDiff(writer, format, diff); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	ui.Success("Diff saved to %s", output)
+	return nil
+}
+
+func encodeDiff(w io.Writer, format string, diff report.Diff) error {
+	if format == "json" {
+		return report.EncodeDiff(w, diff)
+	}
+	return report.EncodeDiffCSV(w, diff)
+}
+
+var diffHeadings = map[string]string{
+	report.ChangeNewlyDormant:    "Newly dormant",
+	report.ChangeReactivated:     "Reactivated",
+	report.ChangeJoined:          "Joined",
+	report.ChangeLeft:            "Left",
+	report.ChangeActivityShifted: "Activity types changed",
+}
+
+func printDiff(beforePath string, afterPath string, diff report.Diff) {
+	counts := diff.Count()
+	lines := []string{fmt.Sprintf("From %s to %s", beforePath, afterPath)}
+	for _, kind := range report.ChangeKinds {
+		lines = append(lines, fmt.Sprintf("%s: %d", diffHeadings[kind], counts[kind]))
+	}
+	ui.BoxWithTitle("Report Diff", strings.Join(lines, "\n"))
+	for _, caveat := range diff.Caveats {
+		ui.Warning("%s", caveat)
+	}
+
+	for _, kind := range report.ChangeKinds {
+		if counts[kind] == 0 {
+			continue
+		}
+		ui.Header(diffHeadings[kind])
+		for _, change := range diff.Changes {
+			if change.Kind == kind {
+				ui.Printf("  - %s%s\n", change.Login, activityShift(change))
+			}
+		}
+		ui.Println()
+	}
+	if len(diff.Changes) == 0 {
+		ui.Info("No members changed between the reports")
+	}
+}
+
+// activityShift describes the activity types a member gained and lost, such
+// as " (+commits, -issues)".
+func activityShift(change report.Change) string {
+	var shifts []string
+	for _, activityType := range change.ActivityAdded {
+		shifts = append(shifts, "+"+activityType)
+	}
+	for _, activityType := range change.ActivityRemoved {
+		shifts = append(shifts, "-"+activityType)
+	}
+	if len(shifts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(shifts, ", ") + ")"
+}
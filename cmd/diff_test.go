@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ssulei7/gh-dormant-users/internal/report"
+)
+
+func writeDiffReports(t *testing.T) (string, string) {
+	t.Helper()
+	directory := t.TempDir()
+	before := filepath.Join(directory, "before.csv")
+	after := filepath.Join(directory, "after.json")
+	if err := os.WriteFile(before, []byte("Username,Email,Active,ActivityTypes,EvidenceComplete\noctocat,,true,commits,true\nhubot,,false,none,true\n"), 0o600); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	content := `{"schema_version": 1, "metadata": {"organizations": ["example"], "evidence_complete": true}, "users": [` +
+		`{"login": "octocat", "active": false, "activity_types": []}, {"login": "hubot", "active": false, "activity_types": []}]}`
+	if err := os.WriteFile(after, []byte(content), 0o600); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	return before, after
+}
+
+func executeDiff(t *testing.T, args ...string) (string, string, error) {
+	t.Helper()
+	var output, warnings bytes.Buffer
+	oldOutput, oldWarnings := diffOutput, diffWarnings
+	diffOutput, diffWarnings = &output, &warnings
+	t.Cleanup(func() { diffOutput, diffWarnings = oldOutput, oldWarnings })
+	command := newDiffCommand()
+	command.SetArgs(args)
+	err := command.Execute()
+	return output.String(), warnings.String(), err
+}
+
+func TestDiffCommandWritesJSON(t *testing.T) {
+	before, after := writeDiffReports(t)
+	output, warnings, err := executeDiff(t, before, after, "--format", "json")
+	if err != nil {
+		t.Fatalf("execute diff: %v", err)
+	}
+	var diff report.Diff
+	if err := json.Unmarshal([]byte(output), &diff); err != nil {
+		t.Fatalf("decode diff: %v\n%s", err, output)
+	}
+	if len(diff.Changes) != 1 || diff.Changes[0].Login != "octocat" || diff.Changes[0].Kind != report.ChangeNewlyDormant {
+		t.Fatalf("changes = %+v", diff.Changes)
+	}
+	// The CSV report records no organizations
+	if len(diff.Caveats) != 1 || !strings.Contains(warnings, "different organizations") {
+		t.Fatalf("caveats = %v, warnings = %q", diff.Caveats, warnings)
+	}
+}
+
+func TestDiffCommandWritesCSVToFile(t *testing.T) {
+	before, after := writeDiffReports(t)
+	path := filepath.Join(t.TempDir(), "diff.csv")
+	if _, _, err := executeDiff(t, before, after, "--format", "csv", "--output", path); err != nil {
+		t.Fatalf("execute diff: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read diff: %v", err)
+	}
+	if !strings.Contains(string(data), "octocat,newly-dormant,true,false,commits,none,,commits") {
+		t.Fatalf("diff = %s", data)
+	}
+}
+
+func TestDiffCommandRejectsInvalidOptions(t *testing.T) {
+	before, after := writeDiffReports(t)
+	if _, _, err := executeDiff(t, before, after, "--format", "xml"); err == nil || !strings.Contains(err.Error(), "invalid format") {
+		t.Fatalf("error = %v", err)
+	}
+	if _, _, err := executeDiff(t, before, after, "--output", "diff.txt"); err == nil || !strings.Contains(err.Error(), "--output requires") {
+		t.Fatalf("error = %v", err)
+	}
+	if _, _, err := executeDiff(t, before); err == nil {
+		t.Fatal("diff accepted a single report")
+	}
+}
@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/ssulei7/gh-dormant-users/internal/history"
+	"github.com/ssulei7/gh-dormant-users/internal/ui"
+)
+
+var defaultHistoryDir = history.DefaultDir
+
+var historyCmd = newHistoryCommand()
+
+func newHistoryCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Inspect the history of report runs",
+		Long: `Inspect the report runs recorded in the local history. Every report run is
+recorded unless --no-history is set, so members can be judged on how long
+they have been dormant rather than on a single run.`,
+	}
+	cmd.PersistentFlags().String("history-dir", "", "Directory of the history (default gh-dormant-users-history in the user cache directory)")
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List recorded runs",
+		Args:  cobra.NoArgs,
+		RunE:  runHistoryList,
+	}
+	show := &cobra.Command{
+		Use:   "show <run-id>",
+		Short: "Show a recorded run with each dormant member's streak",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runHistoryShow,
+	}
+	show.Flags().Int("min-streak", 0, "Only list dormant members who were dormant in at least this many consecutive runs")
+	show.Flags().Bool("all", false, "Also list active members")
+	trend := &cobra.Command{
+		Use:   "trend <user>",
+		Short: "Show a member's verdict in every recorded run",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runHistoryTrend,
+	}
+	prune := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete old recorded runs",
+		Long: `Delete the recorded runs generated more than --older-than-days days ago.
+Runs hold members' logins and emails, so keep only as many as streaks need.`,
+		Args: cobra.NoArgs,
+		RunE: runHistoryPrune,
+	}
+	prune.Flags().Int("older-than-days", 0, "Delete runs generated more than this many days ago (required)")
+	prune.MarkFlagRequired("older-than-days")
+	cmd.AddCommand(list, show, trend, prune)
+	return cmd
+}
+
+func openHistory(cmd *cobra.Command) (*history.Store, error) {
+	dir, _ := cmd.Flags().GetString("history-dir")
+	if dir == "" {
+		var err error
+		if dir, err = defaultHistoryDir(); err != nil {
+			return nil, err
+		}
+	}
+	return history.Open(dir)
+}
+
+func runHistoryList(cmd *cobra.Command, args []string) error {
+	store, err := openHistory(cmd)
+	if err != nil {
+		return err
+	}
+	runs, err := store.Runs()
+	if err != nil {
+		return err
+	}
+	if len(runs) == 0 {
+		ui.Info("No runs recorded in %s", store.Dir())
+		return nil
+	}
+	ui.Header(fmt.Sprintf("%d recorded runs", len(runs)))
+	for _, run := range runs {
+		metadata := run.Report.Metadata
+		note := ""
+		if metadata.Partial {
+			note = " (partial)"
+		}
+		ui.Printf("  %s  %s  since %s  %d members, %d dormant%s\n",
+			run.ID,
+			historyScope(run),
+			metadata.Since.UTC().Format("2006-01-02"),
+			len(run.Report.Users),
+			run.Dormant(),
+			note,
+		)
+	}
+	return nil
+}
+
+func runHistoryShow(cmd *cobra.Command, args []string) error {
+	minStreak, _ := cmd.Flags().GetInt("min-streak")
+	all, _ := cmd.Flags().GetBool("all")
+	if minStreak < 0 {
+		return fmt.Errorf("--min-streak must not be negative")
+	}
+	store, err := openHistory(cmd)
+	if err != nil {
+		return err
+	}
+	run, err := store.Run(args[0])
+	if err != nil {
+		return err
+	}
+	runs, err := store.Runs()
+	if err != nil {
+		return err
+	}
+
+	metadata := run.Report.Metadata
+	lines := []string{
+		"Scope: " + historyScope(*run),
+		"Generated: " + metadata.GeneratedAt.UTC().Format("2006-01-02 15:04 MST"),
+		"Activity since: " + metadata.Since.UTC().Format("2006-01-02"),
+		"Activity types: " + strings.Join(metadata.ActivityTypes, ", "),
+		fmt.Sprintf("Members: %d, dormant: %d", len(run.Report.Users), run.Dormant()),
+	}
+	if metadata.ToolVersion != "" {
+		lines = append(lines, "Tool version: "+metadata.ToolVersion)
+	}
+	ui.BoxWithTitle("Run "+run.ID, strings.Join(lines, "\n"))
+	if metadata.Partial {
+		ui.Warning("This run was interrupted; members without recorded activity may not be dormant, and it does not count towards streaks.")
+	}
+
+	streaks := history.Streaks(runs, *run)
+	listed := 0
+	for _, user := range run.Report.Users {
+		if user.Active {
+			if all && minStreak == 0 {
+				ui.Printf("  %s  active  %s\n", user.Login, strings.Join(user.ActivityTypes, ", "))
+				listed++
+			}
+			continue
+		}
+		streak := streaks[strings.ToLower(user.Login)]
+		if streak < minStreak {
+			continue
+		}
+		ui.Printf("  %s  dormant for %d consecutive %s\n", user.Login, streak, plural(streak, "run", "runs"))
+		listed++
+	}
+	if listed == 0 {
+		ui.Info("No members match")
+	}
+	return nil
+}
+
+func runHistoryTrend(cmd *cobra.Command, args []string) error {
+	store, err := openHistory(cmd)
+	if err != nil {
+		return err
+	}
+	runs, err := store.Runs()
+	if err != nil {
+		return err
+	}
+	points := history.Trend(runs, args[0])
+	if len(points) == 0 {
+		ui.Info("%s is not in any recorded run", args[0])
+		return nil
+	}
+
+	ui.Header(fmt.Sprintf("%s in %d recorded %s", args[0], len(points), plural(len(points), "run", "runs")))
+	for _, point := range points {
+		verdict := "dormant"
+		switch {
+		case point.Active && point.Complete:
+			verdict = "active  " + strings.Join(point.ActivityTypes, ", ")
+		case point.Active:
+			// The run stopped at the first evidence, so its activity types
+			// would show shifts that did not happen.
+			verdict = "active"
+		}
+		if point.Partial {
+			verdict += " (partial run)"
+		}
+		ui.Printf("  %s  %s  %s\n", point.GeneratedAt.UTC().Format("2006-01-02"), point.Scope, verdict)
+	}
+	// Streaks are reported for the latest run of each scope
+	seen := make(map[string]bool)
+	for index := len(runs) - 1; index >= 0; index-- {
+		run := runs[index]
+		if seen[run.Scope] || run.Report.Metadata.Partial {
+			continue
+		}
+		seen[run.Scope] = true
+		if streak := history.Streaks(runs, run)[strings.ToLower(args[0])]; streak > 0 {
+			ui.Warning("Dormant for %d consecutive %s of %s", streak, plural(streak, "run", "runs"), historyScope(run))
+		}
+	}
+	return nil
+}
+
+func runHistoryPrune(cmd *cobra.Command, args []string) error {
+	days, _ := cmd.Flags().GetInt("older-than-days")
+	if days < 1 {
+		return fmt.Errorf("--older-than-days must be at least 1")
+	}
+	store, err := openHistory(cmd)
+	if err != nil {
+		return err
+	}
+	pruned, err := store.Prune(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return err
+	}
+	ui.Success("Deleted %d recorded %s from %s", pruned, plural(pruned, "run", "runs"), store.Dir())
+	return nil
+}
+
+func historyScope(run history.Run) string {
+	metadata := run.Report.Metadata
+	scope := strings.Join(metadata.Organizations, ", ")
+	if metadata.Enterprise != "" {
+		scope = metadata.Enterprise + " enterprise"
+	}
+	if metadata.Hostname != "" && !strings.EqualFold(metadata.Hostname, "github.com") {
+		scope += " on " + metadata.Hostname
+	}
+	return scope
+}
+
+func plural(count int, singular string, many string) string {
+	if count == 1 {
+		return singular
+	}
+	return many
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ssulei7/gh-dormant-users/internal/history"
+	"github.com/ssulei7/gh-dormant-users/internal/report"
+)
+
+func executeHistory(t *testing.T, dir string, args ...string) error {
+	t.Helper()
+	old := defaultHistoryDir
+	defaultHistoryDir = func() (string, error) { return dir, nil }
+	t.Cleanup(func() { defaultHistoryDir = old })
+	command := newHistoryCommand()
+	command.SetArgs(args)
+	return command.Execute()
+}
+
+func TestHistoryCommands(t *testing.T) {
+	dir := t.TempDir()
+	store, err := history.Open(dir)
+	if err != nil {
+		t.Fatalf("open history: %v", err)
+	}
+	id, err := store.Save(report.Report{
+		Metadata: report.Metadata{Organizations: []string{"example"}, GeneratedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		Users:    []report.User{{Login: "octocat", Active: true}, {Login: "hubot"}},
+	})
+	if err != nil {
+		t.Fatalf("save run: %v", err)
+	}
+
+	for _, args := range [][]string{
+		{"list"},
+		{"show", id},
+		{"show", id, "--min-streak", "2", "--all"},
+		{"trend", "hubot"},
+		{"trend", "nobody"},
+		{"prune", "--older-than-days", "30"},
+	} {
+		if err := executeHistory(t, dir, args...); err != nil {
+			t.Fatalf("history %v: %v", args, err)
+		}
+	}
+	if runs, err := store.Runs(); err != nil || len(runs) != 0 {
+		t.Fatalf("runs after prune = %+v, err = %v", runs, err)
+	}
+}
+
+func TestHistoryShowRejectsUnknownRun(t *testing.T) {
+	err := executeHistory(t, t.TempDir(), "show", "20240301T000000Z-example")
+	if err == nil || !strings.Contains(err.Error(), "no run") {
+		t.Fatalf("error = %v", err)
+	}
+	if err := executeHistory(t, t.TempDir(), "show", "x", "--min-streak", "-1"); err == nil || !strings.Contains(err.Error(), "must not be negative") {
+		t.Fatalf("error = %v", err)
+	}
+	if err := executeHistory(t, t.TempDir(), "prune"); err == nil || !strings.Contains(err.Error(), "older-than-days") {
+		t.Fatalf("error = %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/pkg/api"
+	"github.com/ssulei7/gh-dormant-users/internal/activity"
+	"github.com/ssulei7/gh-dormant-users/internal/enterprise"
+	"github.com/ssulei7/gh-dormant-users/internal/githubapi"
+	"github.com/ssulei7/gh-dormant-users/internal/history"
+	"github.com/ssulei7/gh-dormant-users/internal/publish"
+	"github.com/ssulei7/gh-dormant-users/internal/report"
+	"github.com/ssulei7/gh-dormant-users/internal/ui"
+	"github.com/ssulei7/gh-dormant-users/internal/users"
+)
+
+// issueBodyLimit keeps published reports under GitHub's 65,536 character
+// limit on issue bodies, leaving room for the marker.
+const issueBodyLimit = 65000
+
+// reportOutput writes reports in the format chosen with --format, to
+// --output or to a file named after the organizations.
+type reportOutput struct {
+	format      string
+	output      string
+	historyDir  string
+	metadata    report.Metadata
+	coordinator *githubapi.Coordinator
+}
+
+func newReportOutput(options reportOptions, isoDate string, coordinator *githubapi.Coordinator) reportOutput {
+	since, _ := time.Parse(time.RFC3339, isoDate)
+	return reportOutput{
+		format:     options.format,
+		output:     options.output,
+		historyDir: options.historyDir,
+		metadata: report.Metadata{
+			Enterprise:    options.enterprise,
+			Hostname:      options.hostname,
+			Since:         since.UTC(),
+			ActivityTypes: options.activityTypes,
+			ToolVersion:   toolVersion(),
+		},
+		coordinator: coordinator,
+	}
+}
+
+// path is where the report named after name is written. Partial reports get
+// .partial before the extension, so they cannot be mistaken for complete ones.
+func (o reportOutput) path(name string, partial bool) string {
+	if o.output == "" {
+		path := name + "-dormant-users"
+		if partial {
+			path += ".partial"
+		}
+		return path + "." + o.extension()
+	}
+	if !partial {
+		return o.output
+	}
+	extension := filepath.Ext(o.output)
+	return strings.TrimSuffix(o.output, extension) + ".partial" + extension
+}
+
+func (o reportOutput) extension() string {
+	if o.format == "markdown" {
+		return "md"
+	}
+	return o.format
+}
+
+// writeUsers writes the report for one organization and records it in the
+// history
+func (o reportOutput) writeUsers(path string, organization string, userList users.Users, unchecked []string, partial bool) error {
+	run := o.usersReport(organization, userList, unchecked, partial)
+	var err error
+	if o.format == "csv" {
+		err = activity.GenerateUserReportCSV(userList, path, o.metadata.EvidenceComplete, partial)
+	} else {
+		err = report.Write(path, o.format, run)
+	}
+	if err != nil {
+		return err
+	}
+	o.saveHistory(run)
+	return nil
+}
+
+// writeMembers writes the report across several organizations and records
+// it in the history
+func (o reportOutput) writeMembers(path string, organizations []string, members []enterprise.Member, unchecked []string, partial bool) error {
+	run := o.membersReport(organizations, members, unchecked, partial)
+	var err error
+	if o.format == "csv" {
+		err = enterprise.GenerateReportCSV(organizations, members, path, o.metadata.EvidenceComplete, partial)
+	} else {
+		err = report.Write(path, o.format, run)
+	}
+	if err != nil {
+		return err
+	}
+	o.saveHistory(run)
+	return nil
+}
+
+// saveHistory records a run in the history. The report has already been
+// written, so a failure is only reported.
+func (o reportOutput) saveHistory(run report.Report) {
+	if o.historyDir == "" {
+		return
+	}
+	store, err := history.Open(o.historyDir)
+	if err == nil {
+		var id string
+		if id, err = store.Save(run); err == nil {
+			ui.Info("Recorded run %s in the history", id)
+			return
+		}
+	}
+	ui.Warning("Could not record the run in the history: %v", err)
+}
+
+func (o reportOutput) usersReport(organization string, userList users.Users, unchecked []string, partial bool) report.Report {
+	return report.Report{
+		Metadata: o.runMetadata([]string{organization}, unchecked, partial),
+		Users:    report.FromUsers(userList),
+	}
+}
+
+func (o reportOutput) membersReport(organizations []string, members []enterprise.Member, unchecked []string, partial bool) report.Report {
+	return report.Report{
+		Metadata: o.runMetadata(organizations, unchecked, partial),
+		Users:    report.FromMembers(members),
+	}
+}
+
+// publishIssue posts a report as Markdown to the issue for this run's
+// organizations or enterprise in the repository given with --publish-issue.
+func publishIssue(ctx context.Context, client api.RESTClient, repository string, r report.Report) error {
+	if repository == "" {
+		return nil
+	}
+	key := strings.Join(r.Metadata.Organizations, ",")
+	title := "Dormant users: " + strings.Join(r.Metadata.Organizations, ", ")
+	if r.Metadata.Enterprise != "" {
+		key = "enterprise:" + r.Metadata.Enterprise
+		title = "Dormant users: " + r.Metadata.Enterprise + " enterprise"
+	}
+	result, err := publish.Issue(ctx, client, repository, key, title, report.Markdown(r, issueBodyLimit))
+	if err != nil {
+		return fmt.Errorf("publish issue: %w", err)
+	}
+	if result.Created {
+		ui.Success("Opened issue #%d: %s", result.Number, result.URL)
+	} else {
+		ui.Success("Updated issue #%d: %s", result.Number, result.URL)
+	}
+	return nil
+}
+
+func (o reportOutput) runMetadata(organizations []string, unchecked []string, partial bool) report.Metadata {
+	metadata := o.metadata
+	metadata.Organizations = organizations
+	metadata.GeneratedAt = time.Now().UTC()
+	metadata.Partial = partial
+	metadata.NotChecked = unchecked
+	if o.coordinator != nil {
+		metadata.API = report.NewAPIStats(o.coordinator.Stats())
+	}
+	return metadata
+}
+
+// toolVersion is the module version the binary was built from, which Go
+// records from the release tag, or "dev" for local builds.
+func toolVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
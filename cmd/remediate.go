@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/pkg/api"
+	"github.com/spf13/cobra"
+	"github.com/ssulei7/gh-dormant-users/internal/githubapi"
+	"github.com/ssulei7/gh-dormant-users/internal/remediation"
+	"github.com/ssulei7/gh-dormant-users/internal/ui"
+)
+
+var (
+	newRemediationClient = func(hostname string, requestsPerSecond float64) (api.RESTClient, error) {
+		clients, err := newGitHubClients(hostname, nil, githubapi.Config{
+			Transport:          http.DefaultTransport,
+			InitialConcurrency: 1,
+			MaxConcurrency:     1,
+			RequestsPerSecond:  requestsPerSecond,
+			RateLimitReserve:   0.1,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return clients.rest, nil
+	}
+	confirmationInput io.Reader = os.Stdin
+	remediationNow              = time.Now
+)
+
+var remediateCmd = newRemediateCommand()
+
+func newRemediateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remediate",
+		Short: "Take action on dormant users listed in a report",
+		Long: `Take action on the dormant users listed in a report CSV.
+
+Every run first prints the full plan. Changes are only applied when --execute
+is set and the organization name is typed to confirm.
+
+Available actions:
+  - remove:            Remove users from the organization
+  - convert:           Convert users to outside collaborators
+  - remove-from-teams: Remove users from teams (all teams unless --team is set)`,
+		RunE: runRemediate,
+	}
+	cmd.Flags().String("org-name", "", "The name of the organization to remediate")
+	cmd.Flags().String("hostname", "", "GitHub host of the organization, such as a GitHub Enterprise Server instance (default GH_HOST or gh's default host)")
+	cmd.Flags().StringP("file", "f", "", "Path to the report CSV listing dormant users")
+	cmd.Flags().String("action", "", "Action to take: remove, convert or remove-from-teams")
+	cmd.Flags().StringSlice("team", nil, "Team slugs to remove users from (remove-from-teams only; default all teams)")
+	cmd.Flags().Bool("execute", false, "Apply the plan after confirmation instead of only printing it")
+	cmd.Flags().String("log", "", "Path for the per-user result log (default <org-name>-remediation-log.csv)")
+	cmd.Flags().Float64("requests-per-second", 1, "Request rate cap for remediation requests (0-15)")
+	cmd.Flags().Bool("allow-partial", false, "Accept a partial report from an interrupted run, whose inactive users may not have been checked")
+	_ = cmd.MarkFlagRequired("org-name")
+	_ = cmd.MarkFlagRequired("file")
+	_ = cmd.MarkFlagRequired("action")
+	return cmd
+}
+
+func runRemediate(cmd *cobra.Command, args []string) error {
+	orgName, _ := cmd.Flags().GetString("org-name")
+	hostname, _ := cmd.Flags().GetString("hostname")
+	csvFile, _ := cmd.Flags().GetString("file")
+	actionName, _ := cmd.Flags().GetString("action")
+	teams, _ := cmd.Flags().GetStringSlice("team")
+	execute, _ := cmd.Flags().GetBool("execute")
+	logPath, _ := cmd.Flags().GetString("log")
+	requestsPerSecond, _ := cmd.Flags().GetFloat64("requests-per-second")
+	allowPartial, _ := cmd.Flags().GetBool("allow-partial")
+
+	action, err := remediation.ParseAction(actionName)
+	if err != nil {
+		return err
+	}
+	hostname, err = resolveHostname(hostname)
+	if err != nil {
+		return err
+	}
+	if len(teams) > 0 && action != remediation.ActionRemoveFromTeams {
+		return fmt.Errorf("--team can only be used with the remove-from-teams action")
+	}
+	if logPath == "" {
+		logPath = orgName + "-remediation-log.csv"
+	}
+
+	logins, err := remediation.ReadDormantUsers(csvFile, allowPartial)
+	if err != nil {
+		return err
+	}
+	if len(logins) == 0 {
+		ui.Info("No dormant users found in %s", csvFile)
+		return nil
+	}
+
+	client, err := newRemediationClient(hostname, requestsPerSecond)
+	if err != nil {
+		return err
+	}
+	plan, err := remediation.BuildPlan(commandContext(cmd), orgName, action, logins, teams, client)
+	if err != nil {
+		return err
+	}
+	printRemediationPlan(plan, hostname)
+	if len(plan.Steps) == 0 {
+		ui.Info("Nothing to do")
+		return nil
+	}
+	if !execute {
+		ui.Info("Dry run only; re-run with --execute to apply these changes")
+		return nil
+	}
+
+	confirmed, err := confirmRemediation(orgName)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return fmt.Errorf("confirmation did not match organization %q; no changes were made", orgName)
+	}
+
+	resultLog, err := remediation.CreateResultLog(logPath)
+	if err != nil {
+		return fmt.Errorf("create remediation log: %w", err)
+	}
+	defer resultLog.Close()
+
+	// Ctrl-C stops the run after the change in flight; every change made so
+	// far is already in the log.
+	ctx, stop := signal.NotifyContext(commandContext(cmd), os.Interrupt)
+	defer stop()
+	results, err := plan.Execute(ctx, client, remediationNow, func(result remediation.Result) error {
+		switch result.Status {
+		case remediation.StatusSucceeded:
+			ui.Success("%s: %s (%s)", result.Username, result.Action.Describe(), result.Target)
+		case remediation.StatusSkipped:
+			ui.Warning("%s: skipped %s (%s): %s", result.Username, result.Action.Describe(), result.Target, result.Error)
+		default:
+			ui.Error("%s: failed to %s (%s): %s", result.Username, result.Action.Describe(), result.Target, result.Error)
+		}
+		if err := resultLog.Write(result); err != nil {
+			return fmt.Errorf("write remediation log: %w", err)
+		}
+		return nil
+	})
+	ui.Info("Remediation log saved to %s", logPath)
+	if interrupted(ctx, err) {
+		return fmt.Errorf("remediation interrupted after %d of %d steps; see %s", len(results), len(plan.Steps), logPath)
+	}
+	if err != nil {
+		return fmt.Errorf("remediation stopped after %d of %d steps: %w", len(results), len(plan.Steps), err)
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Status == remediation.StatusFailed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d remediation steps failed; see %s", failed, len(results), logPath)
+	}
+	ui.Success("Applied %d remediation steps", len(results))
+	return nil
+}
+
+func printRemediationPlan(plan *remediation.Plan, hostname string) {
+	ui.BoxWithTitle("Remediation Plan", fmt.Sprintf(
+		"Host: %s\nOrganization: %s\nAction: %s\nUsers affected: %d\nChanges: %d",
+		hostname,
+		plan.Organization,
+		plan.Action.Describe(),
+		plan.Users(),
+		len(plan.Steps),
+	))
+	for _, step := range plan.Steps {
+		ui.Printf("  - %s: %s (%s)\n", step.Username, step.Action.Describe(), step.Target)
+	}
+	ui.Println()
+}
+
+func confirmRemediation(orgName string) (bool, error) {
+	ui.Warning("This will modify memberships in %s and cannot be undone automatically.", orgName)
+	ui.Print("Type the organization name to confirm: ")
+	line, err := bufio.NewReader(confirmationInput).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, fmt.Errorf("read confirmation: %w", err)
+	}
+	return strings.TrimSpace(line) == orgName, nil
+}
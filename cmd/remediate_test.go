@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cli/go-gh/pkg/api"
+)
+
+type recordingRESTClient struct {
+	routes   map[string]string
+	errs     map[string]error
+	requests []string
+}
+
+func (c *recordingRESTClient) Request(method string, path string, _ io.Reader) (*http.Response, error) {
+	key := method + " " + path
+	c.requests = append(c.requests, key)
+	if err := c.errs[key]; err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(bytes.NewBufferString(c.routes[key])),
+	}, nil
+}
+
+func (c *recordingRESTClient) RequestWithContext(_ context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	return c.Request(method, path, body)
+}
+
+func (c *recordingRESTClient) Do(method, path string, body io.Reader, result interface{}) error {
+	response, err := c.Request(method, path, body)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(response.Body).Decode(result)
+}
+
+func (c *recordingRESTClient) DoWithContext(_ context.Context, method, path string, body io.Reader, result interface{}) error {
+	return c.Do(method, path, body, result)
+}
+
+func (c *recordingRESTClient) Delete(path string, result interface{}) error {
+	return c.Do(http.MethodDelete, path, nil, result)
+}
+
+func (c *recordingRESTClient) Get(path string, result interface{}) error {
+	return c.Do(http.MethodGet, path, nil, result)
+}
+
+func (c *recordingRESTClient) Patch(path string, body io.Reader, result interface{}) error {
+	return c.Do(http.MethodPatch, path, body, result)
+}
+
+func (c *recordingRESTClient) Post(path string, body io.Reader, result interface{}) error {
+	return c.Do(http.MethodPost, path, body, result)
+}
+
+func (c *recordingRESTClient) Put(path string, body io.Reader, result interface{}) error {
+	return c.Do(http.MethodPut, path, body, result)
+}
+
+func (c *recordingRESTClient) RESTPrefix() string { return "" }
+
+func configureRemediateTest(t *testing.T, client api.RESTClient, input string) *string {
+	t.Helper()
+	oldClient := newRemediationClient
+	oldInput := confirmationInput
+	oldHost := defaultHost
+	var host string
+	newRemediationClient = func(hostname string, _ float64) (api.RESTClient, error) {
+		host = hostname
+		return client, nil
+	}
+	confirmationInput = strings.NewReader(input)
+	defaultHost = func() string { return "github.com" }
+	t.Cleanup(func() {
+		newRemediationClient = oldClient
+		confirmationInput = oldInput
+		defaultHost = oldHost
+	})
+	return &host
+}
+
+func writeRemediationReport(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "example-dormant-users.csv")
+	content := "Username,Email,Active,ActivityTypes\nactive,,true,commits\ndormant,,false,none\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	return path
+}
+
+func executeRemediate(args ...string) error {
+	command := newRemediateCommand()
+	command.SetArgs(args)
+	return command.Execute()
+}
+
+func TestRemediateDryRunMakesNoChanges(t *testing.T) {
+	client := &recordingRESTClient{}
+	configureRemediateTest(t, client, "")
+	report := writeRemediationReport(t)
+
+	if err := executeRemediate("--org-name", "example", "--file", report, "--action", "remove"); err != nil {
+		t.Fatalf("execute remediate: %v", err)
+	}
+	if len(client.requests) != 0 {
+		t.Fatalf("requests = %v, want none", client.requests)
+	}
+}
+
+func TestRemediateUsesHostname(t *testing.T) {
+	client := &recordingRESTClient{}
+	host := configureRemediateTest(t, client, "")
+	report := writeRemediationReport(t)
+
+	if err := executeRemediate("--org-name", "example", "--file", report, "--action", "remove"); err != nil {
+		t.Fatalf("execute remediate: %v", err)
+	}
+	if *host != "github.com" {
+		t.Fatalf("host = %q, want the default host", *host)
+	}
+	if err := executeRemediate("--org-name", "example", "--file", report, "--action", "remove", "--hostname", "https://GitHub.Example.com/"); err != nil {
+		t.Fatalf("execute remediate: %v", err)
+	}
+	if *host != "github.example.com" {
+		t.Fatalf("host = %q, want the normalized --hostname", *host)
+	}
+}
+
+func TestRemediateRequiresMatchingConfirmation(t *testing.T) {
+	client := &recordingRESTClient{}
+	configureRemediateTest(t, client, "other-org\n")
+	report := writeRemediationReport(t)
+
+	err := executeRemediate("--org-name", "example", "--file", report, "--action", "remove", "--execute")
+	if err == nil || !strings.Contains(err.Error(), "no changes were made") {
+		t.Fatalf("error = %v", err)
+	}
+	if len(client.requests) != 0 {
+		t.Fatalf("requests = %v, want none", client.requests)
+	}
+}
+
+func TestRemediateExecutesAndWritesLog(t *testing.T) {
+	client := &recordingRESTClient{}
+	configureRemediateTest(t, client, "example\n")
+	report := writeRemediationReport(t)
+	logPath := filepath.Join(t.TempDir(), "log.csv")
+
+	err := executeRemediate("--org-name", "example", "--file", report, "--action", "convert", "--execute", "--log", logPath)
+	if err != nil {
+		t.Fatalf("execute remediate: %v", err)
+	}
+	if fmt.Sprint(client.requests) != fmt.Sprint([]string{"PUT orgs/example/outside_collaborators/dormant"}) {
+		t.Fatalf("requests = %v", client.requests)
+	}
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("read log: %v", err)
+	}
+	if !strings.Contains(string(data), "dormant,convert,example,succeeded") {
+		t.Fatalf("log = %s", data)
+	}
+}
+
+func TestRemediateReportsFailedSteps(t *testing.T) {
+	client := &recordingRESTClient{errs: map[string]error{
+		"DELETE orgs/example/members/dormant": api.HTTPError{StatusCode: http.StatusForbidden, Message: "owner"},
+	}}
+	configureRemediateTest(t, client, "example\n")
+	report := writeRemediationReport(t)
+	logPath := filepath.Join(t.TempDir(), "log.csv")
+
+	err := executeRemediate("--org-name", "example", "--file", report, "--action", "remove", "--execute", "--log", logPath)
+	if err == nil || !strings.Contains(err.Error(), "1 of 1 remediation steps failed") {
+		t.Fatalf("error = %v", err)
+	}
+	if _, statErr := os.Stat(logPath); statErr != nil {
+		t.Fatalf("expected log to be written: %v", statErr)
+	}
+}
+
+func TestRemediateRejectsTeamsForOrganizationActions(t *testing.T) {
+	configureRemediateTest(t, &recordingRESTClient{}, "")
+	report := writeRemediationReport(t)
+
+	err := executeRemediate("--org-name", "example", "--file", report, "--action", "remove", "--team", "core")
+	if err == nil || !strings.Contains(err.Error(), "--team can only be used") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestRemediateRejectsUnknownAction(t *testing.T) {
+	configureRemediateTest(t, &recordingRESTClient{}, "")
+	report := writeRemediationReport(t)
+
+	err := executeRemediate("--org-name", "example", "--file", report, "--action", "archive")
+	if err == nil || !strings.Contains(err.Error(), "invalid action") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestRemediateStopsWhenInterrupted(t *testing.T) {
+	client := &recordingRESTClient{}
+	configureRemediateTest(t, client, "example\n")
+	report := writeRemediationReport(t)
+	logPath := filepath.Join(t.TempDir(), "log.csv")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	command := newRemediateCommand()
+	command.SetArgs([]string{"--org-name", "example", "--file", report, "--action", "remove", "--execute", "--log", logPath})
+	err := command.ExecuteContext(ctx)
+	if err == nil || !strings.Contains(err.Error(), "interrupted after 0 of 1 steps") {
+		t.Fatalf("error = %v", err)
+	}
+	if len(client.requests) != 0 {
+		t.Fatalf("requests = %v, want none", client.requests)
+	}
+	data, err := os.ReadFile(logPath)
+	if err != nil || !strings.HasPrefix(string(data), "Username,Action") {
+		t.Fatalf("log = %q, %v", data, err)
+	}
+}
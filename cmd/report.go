@@ -1,18 +1,27 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
-	"github.com/cli/go-gh"
-	"github.com/cli/go-gh/pkg/api"
 	"github.com/spf13/cobra"
 	"github.com/ssulei7/gh-dormant-users/internal/activity"
+	"github.com/ssulei7/gh-dormant-users/internal/auditlog"
+	"github.com/ssulei7/gh-dormant-users/internal/checkpoint"
+	"github.com/ssulei7/gh-dormant-users/internal/copilot"
 	dateUtil "github.com/ssulei7/gh-dormant-users/internal/date"
+	"github.com/ssulei7/gh-dormant-users/internal/enterprise"
 	"github.com/ssulei7/gh-dormant-users/internal/githubapi"
+	"github.com/ssulei7/gh-dormant-users/internal/planner"
+	"github.com/ssulei7/gh-dormant-users/internal/publish"
+	"github.com/ssulei7/gh-dormant-users/internal/report"
 	"github.com/ssulei7/gh-dormant-users/internal/repository"
 	"github.com/ssulei7/gh-dormant-users/internal/ui"
 	"github.com/ssulei7/gh-dormant-users/internal/users"
@@ -25,7 +34,20 @@ var reportCmd = &cobra.Command{
 }
 
 type reportOptions struct {
-	orgName            string
+	orgNames           []string
+	enterprise         string
+	hostname           string
+	appID              int64
+	appPrivateKey      string
+	installationID     int64
+	tokenFile          string
+	record             string
+	replay             string
+	format             string
+	output             string
+	publishIssue       string
+	noHistory          bool
+	historyDir         string
 	email              bool
 	date               string
 	requestMode        string
@@ -36,6 +58,12 @@ type reportOptions struct {
 	cacheDir           string
 	noCache            bool
 	clearCache         bool
+	scanStrategy       string
+	planOnly           bool
+	activityTypes      []string
+	auditLogFile       string
+	resume             string
+	activityBreakdown  bool
 }
 
 var (
@@ -44,7 +72,19 @@ var (
 )
 
 func readReportOptions(cmd *cobra.Command) reportOptions {
-	orgName, _ := cmd.Flags().GetString("org-name")
+	orgNames, _ := cmd.Flags().GetStringSlice("org-name")
+	enterpriseSlug, _ := cmd.Flags().GetString("enterprise")
+	hostname, _ := cmd.Flags().GetString("hostname")
+	appID, _ := cmd.Flags().GetInt64("app-id")
+	appPrivateKey, _ := cmd.Flags().GetString("app-private-key")
+	installationID, _ := cmd.Flags().GetInt64("installation-id")
+	tokenFile, _ := cmd.Flags().GetString("token-file")
+	record, _ := cmd.Flags().GetString("record")
+	replay, _ := cmd.Flags().GetString("replay")
+	format, _ := cmd.Flags().GetString("format")
+	output, _ := cmd.Flags().GetString("output")
+	publishIssue, _ := cmd.Flags().GetString("publish-issue")
+	noHistory, _ := cmd.Flags().GetBool("no-history")
 	email, _ := cmd.Flags().GetBool("email")
 	date, _ := cmd.Flags().GetString("date")
 	requestMode, _ := cmd.Flags().GetString("request-mode")
@@ -55,8 +95,26 @@ func readReportOptions(cmd *cobra.Command) reportOptions {
 	cacheDir, _ := cmd.Flags().GetString("cache-dir")
 	noCache, _ := cmd.Flags().GetBool("no-cache")
 	clearCache, _ := cmd.Flags().GetBool("clear-cache")
+	scanStrategy, _ := cmd.Flags().GetString("scan-strategy")
+	planOnly, _ := cmd.Flags().GetBool("plan-only")
+	activityTypes, _ := cmd.Flags().GetStringSlice("activity-types")
+	auditLogFile, _ := cmd.Flags().GetString("audit-log-file")
+	resume, _ := cmd.Flags().GetString("resume")
+	activityBreakdown, _ := cmd.Flags().GetBool("activity-breakdown")
 	return reportOptions{
-		orgName:            orgName,
+		orgNames:           orgNames,
+		enterprise:         enterpriseSlug,
+		hostname:           hostname,
+		appID:              appID,
+		appPrivateKey:      appPrivateKey,
+		installationID:     installationID,
+		tokenFile:          tokenFile,
+		record:             record,
+		replay:             replay,
+		format:             format,
+		output:             output,
+		publishIssue:       publishIssue,
+		noHistory:          noHistory,
 		email:              email,
 		date:               date,
 		requestMode:        requestMode,
@@ -67,10 +125,21 @@ func readReportOptions(cmd *cobra.Command) reportOptions {
 		cacheDir:           cacheDir,
 		noCache:            noCache,
 		clearCache:         clearCache,
+		scanStrategy:       scanStrategy,
+		planOnly:           planOnly,
+		activityTypes:      activityTypes,
+		auditLogFile:       auditLogFile,
+		resume:             resume,
+		activityBreakdown:  activityBreakdown,
 	}
 }
 
 func prepareReportOptions(options reportOptions) (reportOptions, error) {
+	hostname, err := resolveHostname(options.hostname)
+	if err != nil {
+		return reportOptions{}, err
+	}
+	options.hostname = hostname
 	if options.cacheDir == "" {
 		cacheDir, err := defaultCacheDir()
 		if err != nil {
@@ -78,6 +147,22 @@ func prepareReportOptions(options reportOptions) (reportOptions, error) {
 		}
 		options.cacheDir = cacheDir
 	}
+	if options.record != "" && options.replay != "" {
+		return reportOptions{}, fmt.Errorf("--record and --replay cannot be used together")
+	}
+	if options.record != "" || options.replay != "" {
+		// A recording must hold full responses rather than revalidations of
+		// this machine's cache, and a replay must not fill the cache.
+		options.noCache = true
+	}
+	// A replay reproduces an earlier run, which must not be recorded twice.
+	if !options.noHistory && options.replay == "" {
+		historyDir, err := defaultHistoryDir()
+		if err != nil {
+			return reportOptions{}, err
+		}
+		options.historyDir = historyDir
+	}
 	if options.clearCache {
 		if err := clearAPICache(options.cacheDir); err != nil {
 			return reportOptions{}, err
@@ -92,93 +177,515 @@ func prepareReportOptions(options reportOptions) (reportOptions, error) {
 	default:
 		return reportOptions{}, fmt.Errorf("invalid request mode %q; expected safe or bounded", options.requestMode)
 	}
+	options.format = strings.ToLower(options.format)
+	if options.format == "" {
+		options.format = "csv"
+	}
+	if !slices.Contains(report.Formats, options.format) {
+		return reportOptions{}, fmt.Errorf("invalid format %q; expected %s", options.format, strings.Join(report.Formats, ", "))
+	}
+	if options.publishIssue != "" {
+		repository, err := publish.ParseRepository(options.publishIssue)
+		if err != nil {
+			return reportOptions{}, fmt.Errorf("--publish-issue: %w", err)
+		}
+		options.publishIssue = repository
+	}
+	switch strategy := strings.ToLower(options.scanStrategy); strategy {
+	case "":
+		options.scanStrategy = "auto"
+	case "auto", "repos", "users", "search":
+		options.scanStrategy = strategy
+	default:
+		return reportOptions{}, fmt.Errorf("invalid scan strategy %q; expected auto, repos, users or search", options.scanStrategy)
+	}
+	options.orgNames = uniqueOrganizations(options.orgNames)
+	if len(options.orgNames) == 0 && options.enterprise == "" {
+		return reportOptions{}, fmt.Errorf("--org-name must name at least one organization")
+	}
+	if options.resume != "" && options.enterprise != "" {
+		return reportOptions{}, fmt.Errorf("--resume cannot be used with --enterprise")
+	}
+	if options.resume != "" && len(options.orgNames) > 1 {
+		return reportOptions{}, fmt.Errorf("--resume can only be used with a single organization")
+	}
+	if options.resume != "" {
+		// Checkpoints record repository progress, so only repository scans can resume.
+		switch options.scanStrategy {
+		case "auto":
+			options.scanStrategy = "repos"
+		case "repos":
+		default:
+			return reportOptions{}, fmt.Errorf("--resume requires the repos scan strategy, not %q", options.scanStrategy)
+		}
+	}
+	if options.auditLogFile != "" && !slices.Contains(options.activityTypes, auditlog.ActivityType) {
+		options.activityTypes = append(slices.Clone(options.activityTypes), auditlog.ActivityType)
+	}
 	return options, nil
 }
 
+// validateDate checks the date against how far back each selected source
+// reaches. Repository scans and searches are limited to 3 months and the
+// audit log API to its retention period, while audit log exports and Copilot
+// seats are not limited.
+func validateDate(options reportOptions, now time.Time) error {
+	scanTypes, organizationTypes := splitActivityTypes(options.activityTypes)
+	if len(scanTypes) > 0 {
+		if err := dateUtil.ValidateDateAfter(options.date, now.AddDate(0, -3, 0), "3 months"); err != nil {
+			return fmt.Errorf("%w to check %s", err, strings.Join(scanTypes, ", "))
+		}
+	}
+	if organizationTypes[auditlog.ActivityType] && options.auditLogFile == "" {
+		limit := fmt.Sprintf("%d days", auditlog.RetentionDays)
+		if err := dateUtil.ValidateDateAfter(options.date, now.AddDate(0, 0, -auditlog.RetentionDays), limit); err != nil {
+			return fmt.Errorf("%w to check the audit log API; use --audit-log-file with an export to look further back", err)
+		}
+	}
+	return nil
+}
+
+// dateCheckTime is when the date is checked against: now, or for a replay
+// when it was recorded. It is the zero time, and the date is not checked,
+// when the recorded responses carry no date.
+func dateCheckTime(transport http.RoundTripper, now time.Time) time.Time {
+	if replayer, replaying := transport.(*githubapi.Replayer); replaying {
+		return replayer.RecordedAt()
+	}
+	return now
+}
+
+// uniqueOrganizations drops empty and repeated organization names, ignoring
+// case as GitHub does, and keeps the order they were given in.
+func uniqueOrganizations(names []string) []string {
+	unique := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		unique = append(unique, name)
+	}
+	return unique
+}
+
+// consolidated reports whether the run covers several organizations and
+// writes one report for all of them.
+func (options reportOptions) consolidated() bool {
+	return options.enterprise != "" || len(options.orgNames) > 1
+}
+
+// organizationActivityTypes are read from organization-wide sources after
+// the scan rather than by a scan strategy.
+var organizationActivityTypes = []string{auditlog.ActivityType, copilot.ActivityType}
+
+// splitActivityTypes separates the organization-wide activity types from
+// those collected by the scan strategy.
+func splitActivityTypes(activityTypes []string) ([]string, map[string]bool) {
+	scanTypes := make([]string, 0, len(activityTypes))
+	organizationTypes := make(map[string]bool)
+	for _, activityType := range activityTypes {
+		if slices.Contains(organizationActivityTypes, activityType) {
+			organizationTypes[activityType] = true
+			continue
+		}
+		scanTypes = append(scanTypes, activityType)
+	}
+	return scanTypes, organizationTypes
+}
+
+// openCheckpoint resumes the checkpoint named by --resume or starts a new one
+// next to the report.
+func openCheckpoint(options reportOptions, organization string, isoDate string, activityTypes []string) (*checkpoint.File, error) {
+	if options.resume != "" {
+		cp, err := checkpoint.Resume(options.resume, organization, isoDate, activityTypes)
+		if err != nil {
+			return nil, err
+		}
+		ui.Info("Resuming from %s: %d repositories already scanned", cp.Path(), cp.CompletedRepositories())
+		return cp, nil
+	}
+	cp, err := checkpoint.Create(organization+"-dormant-users.checkpoint.ndjson", organization, isoDate, activityTypes)
+	if err != nil {
+		return nil, err
+	}
+	ui.Info("Saving progress to %s; rerun with --resume %s if interrupted", cp.Path(), cp.Path())
+	return cp, nil
+}
+
+// interrupted reports whether err was caused by the run being cancelled
+func interrupted(ctx context.Context, err error) bool {
+	return err != nil && ctx.Err() != nil
+}
+
+// writePartialReport saves the activity collected before an interrupt. The
+// report is named so it cannot be mistaken for a complete one, and the
+// repositories and sources that were not covered are listed alongside it.
+func writePartialReport(out reportOutput, organization string, userList users.Users, uncovered []activity.UncoveredRepository, unchecked []string, cp *checkpoint.File) error {
+	reportPath := out.path(organization, true)
+	if err := out.writeUsers(reportPath, organization, userList, unchecked, true); err != nil {
+		return fmt.Errorf("generate partial report: %w", err)
+	}
+
+	lines := []string{"The run was interrupted; users without recorded activity may not be dormant."}
+	if len(uncovered) > 0 {
+		uncoveredPath := organization + "-dormant-users.uncovered.csv"
+		if err := activity.GenerateUncoveredRepositoriesCSV(uncovered, uncoveredPath); err != nil {
+			return fmt.Errorf("write uncovered repositories: %w", err)
+		}
+		lines = append(lines, fmt.Sprintf("%d repositories were not fully scanned; see %s", len(uncovered), uncoveredPath))
+	}
+	if len(unchecked) > 0 {
+		lines = append(lines, "Not checked: "+strings.Join(unchecked, "; "))
+	}
+	if cp != nil {
+		lines = append(lines, fmt.Sprintf("Rerun with --resume %s to finish the scan", cp.Path()))
+	}
+	ui.BoxWithTitle("Partial Report", strings.Join(lines, "\n"))
+	return fmt.Errorf("report interrupted; partial results written to %s", reportPath)
+}
+
 func generateDormantUserReport(cmd *cobra.Command, args []string) error {
 	options, err := prepareReportOptions(readReportOptions(cmd))
 	if err != nil {
 		return err
 	}
 
-	coordinator, err := githubapi.NewCoordinator(githubapi.Config{
-		Transport:          http.DefaultTransport,
+	app, err := appCredentials(options.appID, options.appPrivateKey, options.installationID)
+	if err != nil {
+		return err
+	}
+	tokens, err := readTokenFile(options.tokenFile)
+	if err != nil {
+		return err
+	}
+	transport, err := apiTransport(options.record, options.replay)
+	if err != nil {
+		return err
+	}
+	clients, err := newGitHubClients(options.hostname, app, githubapi.Config{
+		Transport:          transport,
 		CacheDir:           options.cacheDir,
 		CacheEnabled:       !options.noCache,
 		InitialConcurrency: options.initialConcurrency,
 		MaxConcurrency:     options.maxConcurrency,
 		RequestsPerSecond:  options.requestsPerSecond,
 		RateLimitReserve:   float64(options.rateLimitReserve) / 100,
+		Tokens:             tokens,
 	})
 	if err != nil {
-		return fmt.Errorf("configure GitHub API requests: %w", err)
+		return err
 	}
-	clientOptions := func() *api.ClientOptions {
-		return &api.ClientOptions{
-			Transport: coordinator,
-			Headers: map[string]string{
-				"Accept":               "application/vnd.github+json",
-				"X-GitHub-Api-Version": "2026-03-10",
-			},
+
+	// Convert date to iso 8601 format
+	isoDate, err := dateUtil.GetISODate(options.date)
+	if err != nil {
+		return err
+	}
+	if now := dateCheckTime(transport, time.Now()); !now.IsZero() {
+		if err := validateDate(options, now); err != nil {
+			return err
 		}
 	}
-	restClient, err := gh.RESTClient(clientOptions())
-	if err != nil {
-		return fmt.Errorf("create REST client: %w", err)
+
+	// Ctrl-C cancels the requests in flight; activity found so far is still
+	// written as a partial report.
+	ctx, stop := signal.NotifyContext(commandContext(cmd), os.Interrupt)
+	defer stop()
+
+	if len(tokens) > 0 && options.replay == "" {
+		dropped, err := clients.coordinator.CheckTokens(ctx, apiBaseURL(options.hostname)+"rate_limit")
+		if err != nil {
+			return err
+		}
+		if dropped > 0 {
+			ui.Warning("%d %s in %s rejected as invalid and will not be used", dropped, plural(dropped, "token was", "tokens were"), options.tokenFile)
+		}
 	}
-	gqlClient, err := gh.GQLClient(clientOptions())
-	if err != nil {
-		return fmt.Errorf("create GraphQL client: %w", err)
+
+	if options.enterprise != "" {
+		organizations, err := enterprise.GetOrganizations(ctx, options.enterprise, clients.gql)
+		if err != nil {
+			return err
+		}
+		ui.BoxWithTitle("Enterprise Info", fmt.Sprintf("Number of organizations: %v", len(organizations)))
+		return generateConsolidatedReport(ctx, stop, options, options.enterprise+"-enterprise", organizations, isoDate, clients)
+	}
+	if len(options.orgNames) > 1 {
+		return generateConsolidatedReport(ctx, stop, options, strings.Join(options.orgNames, "-"), options.orgNames, isoDate, clients)
 	}
 
-	// Validate date is no longer than 3 months
-	if err := dateUtil.ValidateDate(options.date); err != nil {
+	organization := options.orgNames[0]
+	scan, err := scanOrganization(ctx, options, organization, isoDate, clients)
+	if err != nil || scan == nil {
 		return err
 	}
+	scan.checker.GenerateBarChart()
 
-	// Convert date to iso 8601 format
-	isoDate, err := dateUtil.GetISODate(options.date)
-	if err != nil {
-		return err
+	out := newReportOutput(options, isoDate, clients.coordinator)
+	out.metadata.EvidenceComplete = scan.checker.EvidenceComplete()
+	if ctx.Err() != nil {
+		// Let a second Ctrl-C end the process while the partial report is written.
+		stop()
+		return writePartialReport(out, organization, scan.users, scan.checker.Uncovered(), scan.unchecked, scan.checkpoint)
 	}
 
-	users, err := users.GetOrganizationUsers(options.orgName, options.email, restClient, gqlClient)
-	if err != nil {
+	if err := out.writeUsers(out.path(organization, false), organization, scan.users, scan.unchecked, false); err != nil {
+		return fmt.Errorf("generate report: %w", err)
+	}
+	if scan.checkpoint != nil {
+		if err := scan.checkpoint.Remove(); err != nil {
+			return err
+		}
+	}
+	if err := publishIssue(ctx, clients.rest, options.publishIssue, out.usersReport(organization, scan.users, scan.unchecked, false)); err != nil {
 		return err
 	}
 
-	repositories, err := repository.GetOrgRepositories(options.orgName, restClient)
+	printAPISummary(clients.coordinator.Stats(), options.hostname)
+	return nil
+}
+
+// organizationScan is the activity collected for one organization's members
+type organizationScan struct {
+	users      users.Users
+	checker    *activity.ActivityChecker
+	checkpoint *checkpoint.File
+	unchecked  []string
+}
+
+// scanOrganization fetches an organization's members and collects their
+// activity. It returns a nil scan when only the plan was requested. When ctx
+// is cancelled during collection, the activity found so far is returned
+// without an error and the sources that were not checked are listed.
+func scanOrganization(ctx context.Context, options reportOptions, organization string, isoDate string, clients *githubClients) (*organizationScan, error) {
+	coordinator, restClient, gqlClient := clients.coordinator, clients.rest, clients.gql
+	activityTypes, organizationTypes := splitActivityTypes(options.activityTypes)
+
+	users, err := users.GetOrganizationUsers(ctx, organization, options.email, restClient, gqlClient)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	activityTypes, _ := cmd.Flags().GetStringSlice("activity-types")
+	// User and search scans do not need the repository list unless a plan is printed.
+	scan := len(activityTypes) > 0
+	var repositories repository.Repositories
+	if scan && (options.scanStrategy == "auto" || options.scanStrategy == "repos" || options.planOnly) {
+		repositories, err = repository.GetOrgRepositories(ctx, organization, restClient)
+		if err != nil {
+			return nil, err
+		}
+		ui.BoxWithTitle("Organization Info", fmt.Sprintf("Number of users: %v\nNumber of repositories: %v", len(users), len(repositories)))
+	} else {
+		ui.BoxWithTitle("Organization Info", fmt.Sprintf("Number of users: %v", len(users)))
+	}
+
+	strategy := options.scanStrategy
+	if scan && (strategy == "auto" || options.planOnly) {
+		since, err := time.Parse(time.RFC3339, isoDate)
+		if err != nil {
+			return nil, err
+		}
+		plan := planner.Build(planner.Input{
+			Members:       len(users),
+			Repositories:  repositories,
+			Since:         since,
+			ActivityTypes: activityTypes,
+			RateLimits:    coordinator.RateLimits(),
+		})
+		if strategy != "auto" {
+			plan.Override(strategy)
+		}
+		ui.BoxWithTitle("Scan Plan", plan.Summary())
+		strategy = plan.Strategy
+	}
+
+	if options.planOnly {
+		return nil, nil
+	}
 
-	// Now, check for activity in the organization's repositories
-	ui.BoxWithTitle("Organization Info", fmt.Sprintf("Number of users: %v\nNumber of repositories: %v", len(users), len(repositories)))
 	ui.Info("Checking for activity...")
+	result := &organizationScan{users: users, checker: activity.NewActivityChecker(options.maxConcurrency)}
+	checker := result.checker
+	checker.UseHost(options.hostname)
+	if options.activityBreakdown {
+		checker.UseActivityBreakdown()
+	}
+	if scan {
+		switch strategy {
+		case "users":
+			err = checker.CheckUserContributions(ctx, users, organization, isoDate, gqlClient, activityTypes)
+		case "search":
+			err = checker.CheckSearchActivity(ctx, users, organization, isoDate, restClient, activityTypes)
+		default:
+			// Runs over several organizations cannot be resumed, so they keep
+			// no checkpoints.
+			if !options.consolidated() {
+				cp, cpErr := openCheckpoint(options, organization, isoDate, activityTypes)
+				if cpErr != nil {
+					return nil, cpErr
+				}
+				checker.UseCheckpoint(cp)
+				result.checkpoint = cp
+			}
+			err = checker.CheckActivity(ctx, users, organization, repositories, isoDate, restClient, gqlClient, activityTypes)
+		}
+		if interrupted(ctx, err) {
+			if strategy == "users" || strategy == "search" {
+				result.unchecked = append(result.unchecked, fmt.Sprintf("%s scan (%s)", strategy, strings.Join(activityTypes, ", ")))
+			}
+		} else if err != nil {
+			return nil, fmt.Errorf("collect activity: %w", err)
+		}
+	}
+	if organizationTypes[auditlog.ActivityType] {
+		if ctx.Err() != nil {
+			result.unchecked = append(result.unchecked, auditlog.ActivityType)
+		} else if err := checker.CheckAuditLogActivity(ctx, users, organization, isoDate, restClient, options.auditLogFile); interrupted(ctx, err) {
+			result.unchecked = append(result.unchecked, auditlog.ActivityType)
+		} else if err != nil {
+			return nil, fmt.Errorf("collect audit log activity: %w", err)
+		}
+	}
+	if organizationTypes[copilot.ActivityType] {
+		if ctx.Err() != nil {
+			result.unchecked = append(result.unchecked, copilot.ActivityType)
+		} else if err := checker.CheckCopilotActivity(ctx, users, organization, isoDate, restClient); interrupted(ctx, err) {
+			result.unchecked = append(result.unchecked, copilot.ActivityType)
+		} else if err != nil {
+			return nil, fmt.Errorf("collect Copilot activity: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// generateConsolidatedReport scans several organizations with the same
+// clients, so they share one Coordinator and cache, and writes one report
+// named after name with a verdict across all of them and a column for each
+// organization. Each organization is scanned in full, even for members already
+// found active elsewhere, so the report shows every organization a user was
+// active in.
+func generateConsolidatedReport(ctx context.Context, stop context.CancelFunc, options reportOptions, name string, organizations []string, isoDate string, clients *githubClients) error {
+	var scanned []enterprise.OrganizationUsers
+	var uncovered []activity.UncoveredRepository
+	var unchecked []string
+	evidenceComplete := true
+	for _, organization := range organizations {
+		if ctx.Err() != nil {
+			break
+		}
+		ui.Info("Scanning organization %s", organization)
+		scan, err := scanOrganization(ctx, options, organization, isoDate, clients)
+		if interrupted(ctx, err) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("scan organization %s: %w", organization, err)
+		}
+		if scan == nil {
+			continue
+		}
+		scanned = append(scanned, enterprise.OrganizationUsers{Organization: organization, Users: scan.users})
+		evidenceComplete = evidenceComplete && scan.checker.EvidenceComplete()
+		for _, repo := range scan.checker.Uncovered() {
+			repo.Name = organization + "/" + repo.Name
+			uncovered = append(uncovered, repo)
+		}
+		for _, source := range scan.unchecked {
+			unchecked = append(unchecked, organization+": "+source)
+		}
+	}
+	if options.planOnly {
+		return nil
+	}
+
+	names := make([]string, 0, len(scanned))
+	for _, organization := range scanned {
+		names = append(names, organization.Organization)
+	}
+	members := enterprise.Consolidate(scanned)
+	activeCount := 0
+	for _, member := range members {
+		if member.Active() {
+			activeCount++
+		}
+	}
+	ui.BarChart([]ui.Bar{
+		{Label: "Active", Value: activeCount},
+		{Label: "Inactive", Value: len(members) - activeCount},
+	})
 
-	checker := activity.NewActivityChecker(options.maxConcurrency)
-	if err := checker.CheckActivity(users, options.orgName, repositories, isoDate, restClient, activityTypes); err != nil {
-		return fmt.Errorf("collect activity: %w", err)
+	out := newReportOutput(options, isoDate, clients.coordinator)
+	out.metadata.EvidenceComplete = evidenceComplete
+	if ctx.Err() != nil {
+		// Let a second Ctrl-C end the process while the partial report is written.
+		stop()
+		return writePartialConsolidatedReport(out, name, organizations, names, members, uncovered, unchecked)
 	}
-	checker.GenerateBarChart()
 
-	if err := activity.GenerateUserReportCSV(users, options.orgName+"-dormant-users.csv"); err != nil {
+	if err := out.writeMembers(out.path(name, false), names, members, unchecked, false); err != nil {
 		return fmt.Errorf("generate report: %w", err)
 	}
+	if err := publishIssue(ctx, clients.rest, options.publishIssue, out.membersReport(names, members, unchecked, false)); err != nil {
+		return err
+	}
+	printAPISummary(clients.coordinator.Stats(), options.hostname)
+	return nil
+}
+
+// writePartialConsolidatedReport saves the organizations scanned before an
+// interrupt and lists those that were not scanned.
+func writePartialConsolidatedReport(out reportOutput, name string, organizations []string, scanned []string, members []enterprise.Member, uncovered []activity.UncoveredRepository, unchecked []string) error {
+	reportPath := out.path(name, true)
+	if err := out.writeMembers(reportPath, scanned, members, unchecked, true); err != nil {
+		return fmt.Errorf("generate partial report: %w", err)
+	}
 
-	stats := coordinator.Stats()
+	lines := []string{"The run was interrupted; users without recorded activity may not be dormant."}
+	var skipped []string
+	for _, organization := range organizations {
+		if !slices.Contains(scanned, organization) {
+			skipped = append(skipped, organization)
+		}
+	}
+	if len(skipped) > 0 {
+		lines = append(lines, "Organizations not scanned: "+strings.Join(skipped, ", "))
+	}
+	if len(uncovered) > 0 {
+		uncoveredPath := name + "-dormant-users.uncovered.csv"
+		if err := activity.GenerateUncoveredRepositoriesCSV(uncovered, uncoveredPath); err != nil {
+			return fmt.Errorf("write uncovered repositories: %w", err)
+		}
+		lines = append(lines, fmt.Sprintf("%d repositories were not fully scanned; see %s", len(uncovered), uncoveredPath))
+	}
+	if len(unchecked) > 0 {
+		lines = append(lines, "Not checked: "+strings.Join(unchecked, "; "))
+	}
+	ui.BoxWithTitle("Partial Report", strings.Join(lines, "\n"))
+	return fmt.Errorf("report interrupted; partial results written to %s", reportPath)
+}
+
+func printAPISummary(stats githubapi.Stats, hostname string) {
+	// GitHub Enterprise Server instances often run with rate limiting
+	// disabled and send no rate-limit headers.
+	remaining := "no primary rate limit reported"
+	if stats.RateLimit > 0 {
+		remaining = fmt.Sprintf("%d/%d primary requests remaining", stats.RateRemaining, stats.RateLimit)
+	}
 	ui.Info(
-		"API summary: %d requests, %d revalidated cache hits, %d cache errors, %d retries, %v waiting, %d/%d primary requests remaining",
+		"API summary: %d requests, %d revalidated cache hits, %d cache errors, %d retries, %v waiting, %s",
 		stats.Requests,
 		stats.CacheHits,
 		stats.CacheErrors,
 		stats.Retries,
 		stats.WaitDuration.Round(time.Second),
-		stats.RateRemaining,
-		stats.RateLimit,
+		remaining,
 	)
+	if stats.APIVersionDropped {
+		ui.Info("%s does not support API version %s; requests were sent without %s", hostname, githubapi.APIVersion, githubapi.APIVersionHeader)
+	}
 	endpoints := make([]string, 0, len(stats.EndpointCounts))
 	for endpoint := range stats.EndpointCounts {
 		endpoints = append(endpoints, endpoint)
@@ -199,5 +706,4 @@ func generateDormantUserReport(cmd *cobra.Command, args []string) error {
 		stats.P95Latency.Round(time.Millisecond),
 		stats.AchievedRequestsPerSecond,
 	)
-	return nil
 }
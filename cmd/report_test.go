@@ -2,16 +2,31 @@ package cmd
 
 import (
 	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/ssulei7/gh-dormant-users/internal/activity"
+	"github.com/ssulei7/gh-dormant-users/internal/enterprise"
+	"github.com/ssulei7/gh-dormant-users/internal/githubapi"
+	"github.com/ssulei7/gh-dormant-users/internal/history"
+	"github.com/ssulei7/gh-dormant-users/internal/report"
+	"github.com/ssulei7/gh-dormant-users/internal/users"
 )
 
 func newReportTestCommand() *cobra.Command {
 	command := &cobra.Command{}
 	flags := command.Flags()
-	flags.String("org-name", "", "")
+	flags.StringSlice("org-name", nil, "")
+	flags.String("enterprise", "", "")
+	flags.String("hostname", "", "")
+	flags.Int64("app-id", 0, "")
+	flags.String("app-private-key", "", "")
+	flags.Int64("installation-id", 0, "")
 	flags.Bool("email", false, "")
 	flags.String("date", "", "")
 	flags.String("request-mode", "bounded", "")
@@ -22,7 +37,12 @@ func newReportTestCommand() *cobra.Command {
 	flags.String("cache-dir", "", "")
 	flags.Bool("no-cache", false, "")
 	flags.Bool("clear-cache", false, "")
+	flags.String("scan-strategy", "auto", "")
+	flags.Bool("plan-only", false, "")
 	flags.StringSlice("activity-types", nil, "")
+	flags.String("audit-log-file", "", "")
+	flags.String("resume", "", "")
+	flags.Bool("activity-breakdown", false, "")
 	return command
 }
 
@@ -39,11 +59,17 @@ func configureReportDependencies(t *testing.T, cacheDir func() (string, error),
 	t.Helper()
 	oldDefault := defaultCacheDir
 	oldClear := clearAPICache
+	oldHost := defaultHost
+	oldHistory := defaultHistoryDir
 	defaultCacheDir = cacheDir
+	defaultHistoryDir = func() (string, error) { return "/history", nil }
 	clearAPICache = clear
+	defaultHost = func() string { return "github.com" }
 	t.Cleanup(func() {
 		defaultCacheDir = oldDefault
 		clearAPICache = oldClear
+		defaultHost = oldHost
+		defaultHistoryDir = oldHistory
 	})
 }
 
@@ -51,6 +77,11 @@ func TestReadReportOptions(t *testing.T) {
 	command := newReportTestCommand()
 	setReportTestFlags(t, command, map[string]string{
 		"org-name":            "example",
+		"enterprise":          "acme",
+		"hostname":            "github.example.com",
+		"app-id":              "7",
+		"app-private-key":     "app.pem",
+		"installation-id":     "42",
 		"email":               "true",
 		"date":                "Jul 1 2026",
 		"request-mode":        "safe",
@@ -61,18 +92,33 @@ func TestReadReportOptions(t *testing.T) {
 		"cache-dir":           "/cache",
 		"no-cache":            "true",
 		"clear-cache":         "true",
+		"scan-strategy":       "users",
+		"plan-only":           "true",
+		"activity-types":      "commits,issues",
+		"audit-log-file":      "audit.json",
+		"resume":              "example.checkpoint.json",
+		"activity-breakdown":  "true",
 	})
 
 	got := readReportOptions(command)
-	if got.orgName != "example" || !got.email || got.date != "Jul 1 2026" || got.requestMode != "safe" {
+	if strings.Join(got.orgNames, ",") != "example" || got.enterprise != "acme" || got.hostname != "github.example.com" || !got.email || got.date != "Jul 1 2026" || got.requestMode != "safe" {
 		t.Fatalf("basic options = %#v", got)
 	}
 	if got.initialConcurrency != 4 || got.maxConcurrency != 8 || got.requestsPerSecond != 7.5 {
 		t.Fatalf("request options = %#v", got)
 	}
+	if got.appID != 7 || got.appPrivateKey != "app.pem" || got.installationID != 42 {
+		t.Fatalf("app options = %#v", got)
+	}
 	if got.rateLimitReserve != 20 || got.cacheDir != "/cache" || !got.noCache || !got.clearCache {
 		t.Fatalf("cache options = %#v", got)
 	}
+	if got.scanStrategy != "users" || !got.planOnly || got.resume != "example.checkpoint.json" {
+		t.Fatalf("scan options = %#v", got)
+	}
+	if strings.Join(got.activityTypes, ",") != "commits,issues" || got.auditLogFile != "audit.json" || !got.activityBreakdown {
+		t.Fatalf("activity options = %#v", got)
+	}
 }
 
 func TestPrepareReportOptionsSafeMode(t *testing.T) {
@@ -87,6 +133,7 @@ func TestPrepareReportOptionsSafeMode(t *testing.T) {
 	)
 
 	got, err := prepareReportOptions(reportOptions{
+		orgNames:           []string{"example"},
 		requestMode:        "SAFE",
 		initialConcurrency: 5,
 		maxConcurrency:     15,
@@ -103,6 +150,32 @@ func TestPrepareReportOptionsSafeMode(t *testing.T) {
 	}
 }
 
+func TestPrepareReportOptionsHostname(t *testing.T) {
+	configureReportDependencies(t, func() (string, error) { return "/cache", nil }, func(string) error { return nil })
+
+	tests := []struct {
+		hostname string
+		want     string
+	}{
+		{hostname: "", want: "github.com"},
+		{hostname: "github.example.com", want: "github.example.com"},
+		{hostname: "https://GitHub.Example.com/", want: "github.example.com"},
+	}
+	for _, tt := range tests {
+		got, err := prepareReportOptions(reportOptions{orgNames: []string{"example"}, hostname: tt.hostname, requestMode: "bounded"})
+		if err != nil {
+			t.Fatalf("prepareReportOptions(%q) returned error: %v", tt.hostname, err)
+		}
+		if got.hostname != tt.want {
+			t.Fatalf("hostname for %q = %q, want %q", tt.hostname, got.hostname, tt.want)
+		}
+	}
+
+	if _, err := prepareReportOptions(reportOptions{orgNames: []string{"example"}, hostname: "https://github.example.com/orgs/example", requestMode: "bounded"}); err == nil || !strings.Contains(err.Error(), "invalid hostname") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
 func TestPrepareReportOptionsBoundedMode(t *testing.T) {
 	configureReportDependencies(t, func() (string, error) {
 		t.Fatal("default cache lookup should not run")
@@ -113,6 +186,7 @@ func TestPrepareReportOptionsBoundedMode(t *testing.T) {
 	})
 
 	got, err := prepareReportOptions(reportOptions{
+		orgNames:           []string{"example"},
 		requestMode:        "bounded",
 		initialConcurrency: 4,
 		maxConcurrency:     8,
@@ -124,6 +198,9 @@ func TestPrepareReportOptionsBoundedMode(t *testing.T) {
 	if got.initialConcurrency != 4 || got.maxConcurrency != 8 {
 		t.Fatalf("bounded concurrency = %d/%d", got.initialConcurrency, got.maxConcurrency)
 	}
+	if got.scanStrategy != "auto" {
+		t.Fatalf("default scan strategy = %q", got.scanStrategy)
+	}
 }
 
 func TestPrepareReportOptionsErrors(t *testing.T) {
@@ -131,7 +208,7 @@ func TestPrepareReportOptionsErrors(t *testing.T) {
 		configureReportDependencies(t, func() (string, error) {
 			return "", errors.New("cache lookup failed")
 		}, func(string) error { return nil })
-		_, err := prepareReportOptions(reportOptions{requestMode: "bounded"})
+		_, err := prepareReportOptions(reportOptions{orgNames: []string{"example"}, requestMode: "bounded"})
 		if err == nil || !strings.Contains(err.Error(), "cache lookup failed") {
 			t.Fatalf("error = %v", err)
 		}
@@ -143,7 +220,7 @@ func TestPrepareReportOptionsErrors(t *testing.T) {
 		}, func(string) error {
 			return errors.New("clear failed")
 		})
-		_, err := prepareReportOptions(reportOptions{requestMode: "bounded", clearCache: true})
+		_, err := prepareReportOptions(reportOptions{orgNames: []string{"example"}, requestMode: "bounded", clearCache: true})
 		if err == nil || !strings.Contains(err.Error(), "clear failed") {
 			t.Fatalf("error = %v", err)
 		}
@@ -153,11 +230,31 @@ func TestPrepareReportOptionsErrors(t *testing.T) {
 		configureReportDependencies(t, func() (string, error) {
 			return "/cache", nil
 		}, func(string) error { return nil })
-		_, err := prepareReportOptions(reportOptions{requestMode: "turbo"})
+		_, err := prepareReportOptions(reportOptions{orgNames: []string{"example"}, requestMode: "turbo"})
 		if err == nil || !strings.Contains(err.Error(), "invalid request mode") {
 			t.Fatalf("error = %v", err)
 		}
 	})
+
+	t.Run("scan strategy", func(t *testing.T) {
+		configureReportDependencies(t, func() (string, error) {
+			return "/cache", nil
+		}, func(string) error { return nil })
+		_, err := prepareReportOptions(reportOptions{orgNames: []string{"example"}, requestMode: "bounded", scanStrategy: "orgs"})
+		if err == nil || !strings.Contains(err.Error(), "invalid scan strategy") {
+			t.Fatalf("error = %v", err)
+		}
+	})
+
+	t.Run("resume with user scan", func(t *testing.T) {
+		configureReportDependencies(t, func() (string, error) {
+			return "/cache", nil
+		}, func(string) error { return nil })
+		_, err := prepareReportOptions(reportOptions{orgNames: []string{"example"}, requestMode: "bounded", scanStrategy: "users", resume: "example.checkpoint.json"})
+		if err == nil || !strings.Contains(err.Error(), "--resume requires the repos scan strategy") {
+			t.Fatalf("error = %v", err)
+		}
+	})
 }
 
 func TestGenerateDormantUserReportRejectsInvalidMode(t *testing.T) {
@@ -172,3 +269,403 @@ func TestGenerateDormantUserReportRejectsInvalidMode(t *testing.T) {
 		t.Fatalf("error = %v", err)
 	}
 }
+
+func TestPrepareReportOptionsAddsAuditLogForExport(t *testing.T) {
+	configureReportDependencies(t, func() (string, error) {
+		return "/cache", nil
+	}, func(string) error { return nil })
+
+	got, err := prepareReportOptions(reportOptions{
+		orgNames:      []string{"example"},
+		requestMode:   "bounded",
+		activityTypes: []string{"commits"},
+		auditLogFile:  "audit.ndjson",
+	})
+	if err != nil {
+		t.Fatalf("prepareReportOptions returned error: %v", err)
+	}
+	if strings.Join(got.activityTypes, ",") != "commits,audit-log" {
+		t.Fatalf("activity types = %v", got.activityTypes)
+	}
+}
+
+func TestSplitActivityTypes(t *testing.T) {
+	scanTypes, organizationTypes := splitActivityTypes([]string{"audit-log", "commits", "copilot"})
+	if strings.Join(scanTypes, ",") != "commits" || !organizationTypes["audit-log"] || !organizationTypes["copilot"] {
+		t.Fatalf("scan types = %v, organization types = %v", scanTypes, organizationTypes)
+	}
+	scanTypes, organizationTypes = splitActivityTypes([]string{"issues"})
+	if len(organizationTypes) != 0 || strings.Join(scanTypes, ",") != "issues" {
+		t.Fatalf("scan types = %v, organization types = %v", scanTypes, organizationTypes)
+	}
+}
+
+func TestValidateDateLimitsEachSource(t *testing.T) {
+	now := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		options reportOptions
+		wantErr string
+	}{
+		{name: "recent scan", options: reportOptions{date: "Aug 1 2026", activityTypes: []string{"commits"}}},
+		{
+			name:    "old scan",
+			options: reportOptions{date: "Jun 1 2026", activityTypes: []string{"commits", "audit-log"}},
+			wantErr: "date must be within the last 3 months to check commits",
+		},
+		{name: "audit log API within retention", options: reportOptions{date: "Jun 1 2026", activityTypes: []string{"audit-log", "copilot"}}},
+		{
+			name:    "audit log API beyond retention",
+			options: reportOptions{date: "Jan 1 2026", activityTypes: []string{"audit-log"}},
+			wantErr: "date must be within the last 180 days to check the audit log API",
+		},
+		{name: "audit log export", options: reportOptions{date: "Jan 1 2025", activityTypes: []string{"audit-log"}, auditLogFile: "export.ndjson"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDate(tt.options, now)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateDate returned error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReportChecksOnlyRepositoryActivityByDefault(t *testing.T) {
+	flag := reportCmd.Flags().Lookup("activity-types")
+	if flag == nil {
+		t.Fatal("report has no --activity-types flag")
+	}
+	if want := "[commits,issues,issue-comments,pr-comments]"; flag.DefValue != want {
+		t.Fatalf("--activity-types default = %s, want %s with the other types opt-in", flag.DefValue, want)
+	}
+}
+
+func TestDateCheckTimeUsesRecordingTimeForReplays(t *testing.T) {
+	now := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	recorded := `{"version":1,"method":"GET","url":"https://api.github.com/orgs/example/members","status":200,"response_header":{"Date":["Mon, 02 Mar 2026 09:00:00 GMT"]},"body":"W10="}`
+	if err := os.WriteFile(filepath.Join(dir, "exchange.json"), []byte(recorded), 0o600); err != nil {
+		t.Fatalf("write recording: %v", err)
+	}
+	replayer, err := githubapi.NewReplayer(dir)
+	if err != nil {
+		t.Fatalf("NewReplayer returned error: %v", err)
+	}
+
+	if got := dateCheckTime(http.DefaultTransport, now); !got.Equal(now) {
+		t.Fatalf("dateCheckTime = %v, want now", got)
+	}
+	want := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	if got := dateCheckTime(replayer, now); !got.Equal(want) {
+		t.Fatalf("dateCheckTime = %v, want the recording time %v", got, want)
+	}
+	if err := validateDate(reportOptions{date: "Feb 1 2026", activityTypes: []string{"commits"}}, want); err != nil {
+		t.Fatalf("validateDate returned error for a date within 3 months of the recording: %v", err)
+	}
+}
+
+func TestPrepareReportOptionsResumeUsesRepositoryScan(t *testing.T) {
+	configureReportDependencies(t, func() (string, error) {
+		return "/cache", nil
+	}, func(string) error { return nil })
+
+	got, err := prepareReportOptions(reportOptions{orgNames: []string{"example"}, requestMode: "bounded", resume: "example.checkpoint.json"})
+	if err != nil {
+		t.Fatalf("prepareReportOptions returned error: %v", err)
+	}
+	if got.scanStrategy != "repos" {
+		t.Fatalf("scan strategy = %q", got.scanStrategy)
+	}
+}
+
+func TestAppCredentials(t *testing.T) {
+	if app, err := appCredentials(0, "", 0); app != nil || err != nil {
+		t.Fatalf("appCredentials without flags = %v, %v", app, err)
+	}
+	if _, err := appCredentials(7, "", 42); err == nil || !strings.Contains(err.Error(), "must be used together") {
+		t.Fatalf("error = %v", err)
+	}
+
+	keyPath := filepath.Join(t.TempDir(), "app.pem")
+	if err := os.WriteFile(keyPath, []byte("key"), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	app, err := appCredentials(7, keyPath, 42)
+	if err != nil {
+		t.Fatalf("appCredentials returned error: %v", err)
+	}
+	if app.AppID != 7 || app.InstallationID != 42 || string(app.PrivateKey) != "key" {
+		t.Fatalf("app = %+v", app)
+	}
+
+	for host, want := range map[string]string{
+		"":                   "https://api.github.com/",
+		"github.com":         "https://api.github.com/",
+		"github.example.com": "https://github.example.com/api/v3/",
+	} {
+		if got := apiBaseURL(host); got != want {
+			t.Fatalf("apiBaseURL(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
+
+func TestPrepareReportOptionsRecording(t *testing.T) {
+	configureReportDependencies(t, func() (string, error) { return "/cache", nil }, func(string) error { return nil })
+
+	for _, options := range []reportOptions{{record: "run"}, {replay: "run"}} {
+		options.orgNames, options.requestMode = []string{"example"}, "bounded"
+		got, err := prepareReportOptions(options)
+		if err != nil {
+			t.Fatalf("prepareReportOptions returned error: %v", err)
+		}
+		if !got.noCache {
+			t.Fatalf("cache enabled with record %q and replay %q", got.record, got.replay)
+		}
+	}
+	if _, err := prepareReportOptions(reportOptions{orgNames: []string{"example"}, record: "a", replay: "b", requestMode: "bounded"}); err == nil || !strings.Contains(err.Error(), "cannot be used together") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestPrepareReportOptionsFormat(t *testing.T) {
+	configureReportDependencies(t, func() (string, error) { return "/cache", nil }, func(string) error { return nil })
+
+	got, err := prepareReportOptions(reportOptions{orgNames: []string{"example"}, format: "NDJSON", requestMode: "bounded"})
+	if err != nil {
+		t.Fatalf("prepareReportOptions returned error: %v", err)
+	}
+	if got.format != "ndjson" {
+		t.Fatalf("format = %q", got.format)
+	}
+	if _, err := prepareReportOptions(reportOptions{orgNames: []string{"example"}, format: "xml", requestMode: "bounded"}); err == nil || !strings.Contains(err.Error(), "invalid format") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestPrepareReportOptionsHistory(t *testing.T) {
+	configureReportDependencies(t, func() (string, error) { return "/cache", nil }, func(string) error { return nil })
+
+	tests := []struct {
+		options reportOptions
+		want    string
+	}{
+		{options: reportOptions{requestMode: "bounded"}, want: "/history"},
+		{options: reportOptions{requestMode: "bounded", noHistory: true}, want: ""},
+		{options: reportOptions{requestMode: "bounded", replay: "recording"}, want: ""},
+	}
+	for _, tt := range tests {
+		tt.options.orgNames = []string{"example"}
+		got, err := prepareReportOptions(tt.options)
+		if err != nil {
+			t.Fatalf("prepareReportOptions returned error: %v", err)
+		}
+		if got.historyDir != tt.want {
+			t.Fatalf("historyDir for %+v = %q, want %q", tt.options, got.historyDir, tt.want)
+		}
+	}
+}
+
+func TestWriteUsersRecordsRunInHistory(t *testing.T) {
+	t.Chdir(t.TempDir())
+	historyDir := filepath.Join(t.TempDir(), "history")
+	out := newReportOutput(reportOptions{format: "csv", historyDir: historyDir, activityTypes: []string{"commits"}}, "2024-03-01T00:00:00Z", nil)
+	if err := out.writeUsers(out.path("example", false), "example", users.Users{{Login: "octocat"}}, nil, false); err != nil {
+		t.Fatalf("writeUsers returned error: %v", err)
+	}
+	store, err := history.Open(historyDir)
+	if err != nil {
+		t.Fatalf("open history: %v", err)
+	}
+	runs, err := store.Runs()
+	if err != nil {
+		t.Fatalf("read history: %v", err)
+	}
+	if len(runs) != 1 || runs[0].Scope != "example" || len(runs[0].Report.Users) != 1 || runs[0].Report.Users[0].Active {
+		t.Fatalf("runs = %+v", runs)
+	}
+}
+
+func TestPrepareReportOptionsPublishIssue(t *testing.T) {
+	configureReportDependencies(t, func() (string, error) { return "/cache", nil }, func(string) error { return nil })
+
+	got, err := prepareReportOptions(reportOptions{orgNames: []string{"example"}, publishIssue: " example/audits ", requestMode: "bounded"})
+	if err != nil {
+		t.Fatalf("prepareReportOptions returned error: %v", err)
+	}
+	if got.publishIssue != "example/audits" {
+		t.Fatalf("publishIssue = %q", got.publishIssue)
+	}
+	for _, repository := range []string{"audits", "example/", "example/audits/issues"} {
+		if _, err := prepareReportOptions(reportOptions{orgNames: []string{"example"}, publishIssue: repository, requestMode: "bounded"}); err == nil || !strings.Contains(err.Error(), "owner/repo") {
+			t.Fatalf("publishIssue %q: error = %v", repository, err)
+		}
+	}
+}
+
+func TestReportOutputPath(t *testing.T) {
+	tests := []struct {
+		output  reportOutput
+		partial bool
+		want    string
+	}{
+		{output: reportOutput{format: "csv"}, want: "example-dormant-users.csv"},
+		{output: reportOutput{format: "ndjson"}, partial: true, want: "example-dormant-users.partial.ndjson"},
+		{output: reportOutput{format: "markdown"}, want: "example-dormant-users.md"},
+		{output: reportOutput{format: "json", output: "out/report.json"}, want: "out/report.json"},
+		{output: reportOutput{format: "json", output: "out/report.json"}, partial: true, want: "out/report.partial.json"},
+	}
+	for _, tt := range tests {
+		if got := tt.output.path("example", tt.partial); got != tt.want {
+			t.Fatalf("path(%+v, %v) = %q, want %q", tt.output, tt.partial, got, tt.want)
+		}
+	}
+}
+
+func TestWritePartialReportAsJSON(t *testing.T) {
+	t.Chdir(t.TempDir())
+	out := newReportOutput(reportOptions{format: "json", activityTypes: []string{"commits"}}, "2024-03-01T00:00:00Z", nil)
+	err := writePartialReport(out, "example", users.Users{{Login: "octocat"}}, nil, []string{"copilot"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "example-dormant-users.partial.json") {
+		t.Fatalf("error = %v", err)
+	}
+	parsed, err := report.Read("example-dormant-users.partial.json")
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	metadata := parsed.Metadata
+	if !metadata.Partial || strings.Join(metadata.NotChecked, ",") != "copilot" || strings.Join(metadata.Organizations, ",") != "example" || metadata.Since.Format("2006-01-02") != "2024-03-01" {
+		t.Fatalf("metadata = %+v", metadata)
+	}
+	if len(parsed.Users) != 1 || parsed.Users[0].Login != "octocat" {
+		t.Fatalf("users = %+v", parsed.Users)
+	}
+}
+
+func TestReadTokenFile(t *testing.T) {
+	if tokens, err := readTokenFile(""); tokens != nil || err != nil {
+		t.Fatalf("readTokenFile without a path = %v, %v", tokens, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "tokens.txt")
+	if err := os.WriteFile(path, []byte("# primary\nghp_one\n\n  ghp_two  \r\n"), 0o600); err != nil {
+		t.Fatalf("write token file: %v", err)
+	}
+	tokens, err := readTokenFile(path)
+	if err != nil {
+		t.Fatalf("readTokenFile returned error: %v", err)
+	}
+	if strings.Join(tokens, ",") != "ghp_one,ghp_two" {
+		t.Fatalf("tokens = %v", tokens)
+	}
+
+	if err := os.WriteFile(path, []byte("# none yet\n"), 0o600); err != nil {
+		t.Fatalf("write token file: %v", err)
+	}
+	if _, err := readTokenFile(path); err == nil || !strings.Contains(err.Error(), "contains no tokens") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestPrepareReportOptionsOrganizations(t *testing.T) {
+	configureReportDependencies(t, func() (string, error) { return "/cache", nil }, func(string) error { return nil })
+
+	got, err := prepareReportOptions(reportOptions{orgNames: []string{"one", " Two", "", "ONE", "two"}, requestMode: "bounded"})
+	if err != nil {
+		t.Fatalf("prepareReportOptions returned error: %v", err)
+	}
+	if strings.Join(got.orgNames, ",") != "one,Two" || !got.consolidated() {
+		t.Fatalf("organizations = %v, consolidated = %v", got.orgNames, got.consolidated())
+	}
+
+	_, err = prepareReportOptions(reportOptions{orgNames: []string{"one", "two"}, resume: "one.checkpoint.json", requestMode: "bounded"})
+	if err == nil || !strings.Contains(err.Error(), "--resume can only be used with a single organization") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestPrepareReportOptionsRequiresAnOrganization(t *testing.T) {
+	configureReportDependencies(t, func() (string, error) { return "/cache", nil }, func(string) error { return nil })
+
+	for _, names := range [][]string{{"", ""}, {" "}} {
+		_, err := prepareReportOptions(reportOptions{orgNames: names, requestMode: "bounded"})
+		if err == nil || !strings.Contains(err.Error(), "at least one organization") {
+			t.Fatalf("error for %q = %v, want at least one organization", names, err)
+		}
+	}
+	if _, err := prepareReportOptions(reportOptions{enterprise: "acme", requestMode: "bounded"}); err != nil {
+		t.Fatalf("prepareReportOptions returned error for an enterprise: %v", err)
+	}
+}
+
+func TestGenerateDormantUserReportRejectsEmptyOrganizationList(t *testing.T) {
+	configureReportDependencies(t, func() (string, error) { return "/cache", nil }, func(string) error { return nil })
+	for _, value := range []string{",", " "} {
+		command := newReportTestCommand()
+		setReportTestFlags(t, command, map[string]string{"org-name": value, "request-mode": "bounded", "date": "Sep 1 2026"})
+
+		err := generateDormantUserReport(command, nil)
+		if err == nil || !strings.Contains(err.Error(), "at least one organization") {
+			t.Fatalf("error for --org-name %q = %v", value, err)
+		}
+	}
+}
+
+func TestPrepareReportOptionsRejectsResumeForEnterprise(t *testing.T) {
+	configureReportDependencies(t, func() (string, error) { return "/cache", nil }, func(string) error { return nil })
+	_, err := prepareReportOptions(reportOptions{enterprise: "acme", resume: "acme.checkpoint.json", requestMode: "bounded"})
+	if err == nil || !strings.Contains(err.Error(), "--resume cannot be used with --enterprise") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestWritePartialConsolidatedReportListsUnscannedOrganizations(t *testing.T) {
+	t.Chdir(t.TempDir())
+	members := enterprise.Consolidate([]enterprise.OrganizationUsers{{Organization: "one", Users: users.Users{{Login: "octocat"}}}})
+	uncovered := []activity.UncoveredRepository{{Name: "one/widgets", ActivityTypes: []string{"commits"}}}
+
+	err := writePartialConsolidatedReport(reportOutput{format: "csv"}, "acme-enterprise", []string{"one", "two"}, []string{"one"}, members, uncovered, nil)
+	if err == nil || !strings.Contains(err.Error(), "acme-enterprise-dormant-users.partial.csv") {
+		t.Fatalf("error = %v", err)
+	}
+	data, err := os.ReadFile("acme-enterprise-dormant-users.partial.csv")
+	if err != nil {
+		t.Fatalf("partial report was not written: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); !strings.HasSuffix(lines[0], "EvidenceComplete,Partial,Org:one") || !strings.HasSuffix(lines[1], ",true,none") {
+		t.Fatalf("partial report = %q", data)
+	}
+	if _, err := os.Stat("acme-enterprise-dormant-users.uncovered.csv"); err != nil {
+		t.Fatalf("uncovered repositories were not written: %v", err)
+	}
+}
+
+func TestWritePartialReportMarksUncoveredRepositories(t *testing.T) {
+	t.Chdir(t.TempDir())
+	userList := users.Users{{Login: "octocat"}}
+	uncovered := []activity.UncoveredRepository{{Name: "widgets", ActivityTypes: []string{"commits"}}}
+
+	err := writePartialReport(reportOutput{format: "csv"}, "example", userList, uncovered, []string{"copilot"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "example-dormant-users.partial.csv") {
+		t.Fatalf("error = %v", err)
+	}
+	if _, err := os.Stat("example-dormant-users.csv"); !os.IsNotExist(err) {
+		t.Fatalf("complete report should not be written: %v", err)
+	}
+	if _, err := os.Stat("example-dormant-users.partial.csv"); err != nil {
+		t.Fatalf("partial report was not written: %v", err)
+	}
+	data, err := os.ReadFile("example-dormant-users.uncovered.csv")
+	if err != nil {
+		t.Fatalf("uncovered repositories were not written: %v", err)
+	}
+	if !strings.Contains(string(data), "widgets,commits") {
+		t.Fatalf("uncovered repositories = %q", data)
+	}
+}
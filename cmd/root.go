@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -22,10 +23,24 @@ var rootCmd = &cobra.Command{
 }
 
 func init() {
-	reportCmd.Flags().String("org-name", "", "The name of the organization to report upon")
+	reportCmd.Flags().StringSlice("org-name", nil, "Comma-separated names of the organizations to report upon; several organizations get one consolidated report")
+	reportCmd.Flags().String("enterprise", "", "Report on every organization of this enterprise (slug) in one consolidated report, instead of --org-name")
+	reportCmd.Flags().Int64("app-id", 0, "Authenticate as this GitHub App instead of with gh's token (requires --app-private-key and --installation-id)")
+	reportCmd.Flags().String("app-private-key", "", "Path to the GitHub App's PEM private key")
+	reportCmd.Flags().Int64("installation-id", 0, "ID of the GitHub App installation to authenticate as")
+	reportCmd.Flags().String("token-file", "", "Spread requests across the tokens in this file, one per line, sending each to the token with the most rate limit left")
+	reportCmd.Flags().String("format", "csv", "Report format: csv, json (one document with run metadata), ndjson (metadata, then one user per line), html (a self-contained page with charts) or markdown")
+	reportCmd.Flags().StringP("output", "o", "", "Path of the report (default <organization>-dormant-users.<format>)")
+	reportCmd.Flags().String("publish-issue", "", "Post the report as Markdown to an issue in this repository (owner/repo), updating the issue a previous run opened")
+	reportCmd.Flags().Bool("no-history", false, "Do not record this run in the local history read by the history command. Recorded runs include member logins and emails until deleted with history prune")
+	reportCmd.Flags().String("record", "", "Save every API request and response, without credentials, to this directory")
+	reportCmd.Flags().String("replay", "", "Answer API requests from a directory saved with --record instead of GitHub")
+	reportCmd.Flags().String("hostname", "", "GitHub host to query, such as a GitHub Enterprise Server instance (default GH_HOST or gh's default host)")
 	reportCmd.Flags().BoolP("email", "e", false, "Check if user has an email")
-	reportCmd.Flags().String("date", "", "The date from which to start looking for activity. Max 3 months in the past.")
-	reportCmd.Flags().StringSlice("activity-types", []string{"commits", "issues", "issue-comments", "pr-comments"}, "Comma-separated list of activity types to check (commits, issues, issue-comments, pr-comments)")
+	reportCmd.Flags().String("date", "", "The date from which to start looking for activity. Max 3 months in the past for repository activity, 180 days for the audit log API.")
+	reportCmd.Flags().StringSlice("activity-types", []string{"commits", "issues", "issue-comments", "pr-comments"}, "Comma-separated list of activity types to check (commits, issues, issue-comments, pr-comments, pull-requests, pr-reviews, discussions, audit-log, copilot)")
+	reportCmd.Flags().Bool("activity-breakdown", false, "Keep scanning each activity type until every member has been seen with it, instead of stopping once every member is active")
+	reportCmd.Flags().String("audit-log-file", "", "Read audit-log activity from an exported audit log (JSON or NDJSON) instead of the API")
 	reportCmd.Flags().String("request-mode", "bounded", "API request mode: bounded (default) or safe (serial)")
 	reportCmd.Flags().Int("initial-concurrency", 5, "Initial concurrent API requests in bounded mode")
 	reportCmd.Flags().Int("max-concurrency", 15, "Adaptive concurrency ceiling in bounded mode (1-15)")
@@ -34,19 +49,37 @@ func init() {
 	reportCmd.Flags().String("cache-dir", "", "Directory for the strict ETag response cache")
 	reportCmd.Flags().Bool("no-cache", false, "Disable the persistent response cache")
 	reportCmd.Flags().Bool("clear-cache", false, "Clear the response cache before collecting data")
-	if err := reportCmd.MarkFlagRequired("org-name"); err != nil {
-		ui.Error("%v", err)
-		os.Exit(1)
-	}
+	reportCmd.Flags().String("scan-strategy", "auto", "Scan strategy: auto (cheapest estimate), repos (walk every repository), users (query each user's contributions) or search (search API per user)")
+	reportCmd.Flags().String("resume", "", "Resume an interrupted repository scan from its checkpoint file")
+	reportCmd.Flags().Bool("plan-only", false, "Print the estimated API cost of each scan strategy and exit")
+	reportCmd.MarkFlagsOneRequired("org-name", "enterprise")
+	reportCmd.MarkFlagsMutuallyExclusive("org-name", "enterprise")
+	reportCmd.MarkFlagsRequiredTogether("app-id", "app-private-key", "installation-id")
+	reportCmd.MarkFlagsMutuallyExclusive("token-file", "app-id")
+	reportCmd.MarkFlagsMutuallyExclusive("record", "replay")
+	reportCmd.MarkFlagsMutuallyExclusive("replay", "app-id")
+	reportCmd.MarkFlagsMutuallyExclusive("replay", "publish-issue")
 	if err := reportCmd.MarkFlagRequired("date"); err != nil {
 		ui.Error("%v", err)
 		os.Exit(1)
 	}
 	rootCmd.AddCommand(reportCmd)
 	rootCmd.AddCommand(analyzeCmd)
+	rootCmd.AddCommand(remediateCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(historyCmd)
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 }
 
+// commandContext returns the command's context, which is unset when a command
+// function is called directly instead of through Execute.
+func commandContext(cmd *cobra.Command) context.Context {
+	if ctx := cmd.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		ui.Error("%v", err)
@@ -1,19 +1,31 @@
 package activity
 
 import (
+	"context"
 	"encoding/csv"
+	"fmt"
+	"iter"
 	"os"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cli/go-gh/pkg/api"
+	"github.com/ssulei7/gh-dormant-users/internal/auditlog"
+	"github.com/ssulei7/gh-dormant-users/internal/checkpoint"
 	"github.com/ssulei7/gh-dormant-users/internal/commits"
+	"github.com/ssulei7/gh-dormant-users/internal/contributions"
+	"github.com/ssulei7/gh-dormant-users/internal/copilot"
+	"github.com/ssulei7/gh-dormant-users/internal/discussions"
 	"github.com/ssulei7/gh-dormant-users/internal/githubapi"
 	"github.com/ssulei7/gh-dormant-users/internal/issues"
 	"github.com/ssulei7/gh-dormant-users/internal/pullrequests"
 	"github.com/ssulei7/gh-dormant-users/internal/repository"
+	"github.com/ssulei7/gh-dormant-users/internal/search"
 	"github.com/ssulei7/gh-dormant-users/internal/ui"
 	"github.com/ssulei7/gh-dormant-users/internal/users"
 )
@@ -23,9 +35,27 @@ type ActivityChecker struct {
 	activeUsers map[string]bool
 	userIndex   map[string]*users.User
 	workers     int
+	checkpoint  *checkpoint.File
+	changed     map[string]bool
+	uncovered   []UncoveredRepository
+	seen        map[string]map[string]bool
+	unresolved  int
+	breakdown   bool
+	host        string
+	scanTypes   []string
+	stopScan    context.CancelFunc
+	skipped     atomic.Int64
+	stopped     atomic.Bool
 	mu          sync.RWMutex
 }
 
+// UncoveredRepository is a repository whose activity was not fully collected
+// because the scan was cancelled.
+type UncoveredRepository struct {
+	Name          string
+	ActivityTypes []string
+}
+
 // NewActivityChecker creates a new ActivityChecker
 func NewActivityChecker(workerCount ...int) *ActivityChecker {
 	workers := 5
@@ -35,10 +65,59 @@ func NewActivityChecker(workerCount ...int) *ActivityChecker {
 	return &ActivityChecker{
 		activeUsers: make(map[string]bool),
 		userIndex:   make(map[string]*users.User),
+		seen:        make(map[string]map[string]bool),
+		changed:     make(map[string]bool),
 		workers:     workers,
 	}
 }
 
+// UseCheckpoint makes CheckActivity restore progress from the checkpoint,
+// skip repositories it lists as done and record each completed repository.
+func (ac *ActivityChecker) UseCheckpoint(file *checkpoint.File) {
+	ac.checkpoint = file
+}
+
+// UseHost makes audit log and Copilot evidence link to host instead of
+// github.com
+func (ac *ActivityChecker) UseHost(host string) {
+	ac.host = host
+}
+
+// UseActivityBreakdown makes CheckActivity keep reading each activity type
+// until every user has been seen with it, instead of stopping as soon as
+// every user is active.
+func (ac *ActivityChecker) UseActivityBreakdown() {
+	ac.breakdown = true
+}
+
+// SkippedRequests returns the number of API requests CheckActivity avoided
+// because they could no longer change the result. It is a lower bound, as
+// listings that were not read may have had more than one page.
+func (ac *ActivityChecker) SkippedRequests() int64 {
+	return ac.skipped.Load()
+}
+
+// EvidenceComplete reports whether every source was read in full. It is
+// false once any activity was left unread because it could no longer change
+// the verdicts: users' activity types are then only those found first, and
+// their last activity may not be their newest.
+func (ac *ActivityChecker) EvidenceComplete() bool {
+	return !ac.stopped.Load()
+}
+
+// skip records requests left out because they could no longer change the
+// verdicts.
+func (ac *ActivityChecker) skip(requests int64) {
+	ac.skipped.Add(requests)
+	ac.stopped.Store(true)
+}
+
+// Uncovered returns the repositories the last CheckActivity call did not
+// finish scanning because its context was cancelled.
+func (ac *ActivityChecker) Uncovered() []UncoveredRepository {
+	return ac.uncovered
+}
+
 // activityTypeSet for quick lookup
 type activityTypeSet map[string]bool
 
@@ -51,23 +130,48 @@ func newActivityTypeSet(types []string) activityTypeSet {
 }
 
 // CheckActivity checks all activity types in a single pass through repositories.
-func (ac *ActivityChecker) CheckActivity(usersList users.Users, organization string, repositories repository.Repositories, date string, client api.RESTClient, activityTypes []string) error {
-	// Build user index for O(1) lookups
-	for i := range usersList {
-		user := &usersList[i]
-		ac.userIndex[user.Login] = user
-		ac.activeUsers[user.Login] = false
-	}
+// REST activity is collected per repository by the worker pool; discussions are
+// collected afterwards with batched GraphQL queries. Once every user is
+// resolved (active, or seen with every activity type when a breakdown is
+// requested) no further repositories are queued and requests in flight are
+// cancelled. When ctx is cancelled the scan stops, the repositories it did not
+// finish are available from Uncovered and the context's error is returned.
+func (ac *ActivityChecker) CheckActivity(ctx context.Context, usersList users.Users, organization string, repositories repository.Repositories, date string, client api.RESTClient, gqlClient api.GQLClient, activityTypes []string) error {
+	ac.indexUsers(usersList)
 
 	typeSet := newActivityTypeSet(activityTypes)
 	since, err := time.Parse(time.RFC3339, date)
 	if err != nil {
 		return err
 	}
+	ac.uncovered = nil
+
+	// scanCtx is cancelled early once every user is resolved for the REST types.
+	scanCtx, cancelScan := context.WithCancel(ctx)
+	defer cancelScan()
+	restTypes := slices.DeleteFunc(slices.Clone(activityTypes), func(t string) bool { return t == "discussions" })
+	ac.mu.Lock()
+	ac.scanTypes = restTypes
+	ac.stopScan = cancelScan
+	ac.mu.Unlock()
+	defer func() {
+		ac.mu.Lock()
+		ac.stopScan = nil
+		ac.mu.Unlock()
+	}()
+	ac.restoreCheckpoint()
+	if ac.scanResolved() {
+		ac.stopped.Store(true)
+		cancelScan()
+	}
 
 	// Calculate total work: repos * number of activity types enabled
 	totalWork := len(repositories) * len(activityTypes)
 	progressBar := ui.NewProgressBar(totalWork, "Checking for activity...")
+	repositoryWork := len(typeSet)
+	if typeSet["discussions"] {
+		repositoryWork--
+	}
 
 	var wg sync.WaitGroup
 	var progressMux sync.Mutex
@@ -76,6 +180,17 @@ func (ac *ActivityChecker) CheckActivity(usersList users.Users, organization str
 	var stopOnce sync.Once
 	var firstErr error
 	var errorMux sync.Mutex
+	covered := make(map[string]bool, len(repositories))
+	var coveredMux sync.Mutex
+	// Checkpointed repositories are marked before the workers start, as they
+	// write to covered too.
+	if ac.checkpoint != nil {
+		for _, repo := range repositories {
+			if ac.checkpoint.RepositoryDone(repo.Name) {
+				covered[repo.Name] = true
+			}
+		}
+	}
 
 	for i := 0; i < ac.workers; i++ {
 		wg.Add(1)
@@ -85,11 +200,21 @@ func (ac *ActivityChecker) CheckActivity(usersList users.Users, organization str
 				select {
 				case <-done:
 					return
+				case <-scanCtx.Done():
+					return
 				case repo, ok := <-repoChan:
 					if !ok {
 						return
 					}
-					if err := ac.checkRepoActivity(organization, repo, date, since, client, typeSet, progressBar, &progressMux); err != nil {
+					err := ac.checkRepoActivity(scanCtx, organization, repo, date, since, client, typeSet, progressBar, &progressMux)
+					if scanCtx.Err() != nil && ctx.Err() == nil {
+						// Stopped early: the rest of this repository is not needed.
+						return
+					}
+					if err == nil && ac.checkpoint != nil {
+						err = ac.checkpoint.CompleteRepository(repo.Name, ac.changedActivity)
+					}
+					if err != nil {
 						errorMux.Lock()
 						if firstErr == nil {
 							firstErr = err
@@ -98,87 +223,612 @@ func (ac *ActivityChecker) CheckActivity(usersList users.Users, organization str
 						stopOnce.Do(func() { close(done) })
 						return
 					}
+					coveredMux.Lock()
+					covered[repo.Name] = true
+					coveredMux.Unlock()
 				}
 			}
 		}()
 	}
 
 enqueue:
-	for _, repo := range repositories {
+	for index, repo := range repositories {
+		if ac.checkpoint != nil && ac.checkpoint.RepositoryDone(repo.Name) {
+			for range repositoryWork {
+				incrementProgress(progressBar, &progressMux)
+			}
+			continue
+		}
 		select {
 		case <-done:
 			break enqueue
+		case <-scanCtx.Done():
+			if ctx.Err() == nil {
+				for _, skipped := range repositories[index:] {
+					ac.skip(int64(minimumRepositoryRequests(skipped, since, typeSet)))
+				}
+			}
+			break enqueue
 		case repoChan <- repo:
 		}
 	}
 	close(repoChan)
 	wg.Wait()
+	discussionsDone := !typeSet["discussions"]
+	if firstErr == nil && ctx.Err() == nil && typeSet["discussions"] {
+		if ac.resolved("discussions") {
+			ac.skip(int64(discussionRequests(repositories)))
+			discussionsDone = true
+		} else if ac.checkpoint != nil && ac.checkpoint.DiscussionsDone() {
+			for range repositories {
+				incrementProgress(progressBar, &progressMux)
+			}
+			discussionsDone = true
+		} else {
+			firstErr = ac.checkDiscussionActivity(ctx, organization, repositories, since, gqlClient, progressBar, &progressMux)
+			if firstErr == nil && ac.checkpoint != nil {
+				firstErr = ac.checkpoint.CompleteDiscussions(ac.changedActivity)
+			}
+			discussionsDone = firstErr == nil
+		}
+	}
 	progressBar.Complete()
+	if skipped := ac.skipped.Load(); skipped > 0 {
+		ui.Info("Skipped at least %d API requests that could no longer change the report", skipped)
+	}
+
+	// A cancelled scan is not a failure of any one repository, so report the
+	// cancellation and what was left unscanned instead of the request error.
+	if err := ctx.Err(); err != nil {
+		ac.uncovered = uncoveredRepositories(repositories, activityTypes, covered, discussionsDone)
+		return err
+	}
 	return firstErr
 }
 
+// minimumRepositoryRequests counts the listings a repository scan would have
+// requested, assuming each fits on one page.
+func minimumRepositoryRequests(repo repository.Repository, since time.Time, typeSet activityTypeSet) int {
+	requests := 0
+	if typeSet["commits"] && repo.Size > 0 && (repo.PushedAt == nil || !repo.PushedAt.Before(since)) {
+		requests++
+	}
+	for _, activityType := range []string{"issues", "issue-comments", "pr-comments"} {
+		if typeSet[activityType] {
+			requests++
+		}
+	}
+	if typeSet["pull-requests"] || typeSet["pr-reviews"] {
+		requests++
+	}
+	return requests
+}
+
+// discussionRequests counts the batched queries a discussion scan would send
+func discussionRequests(repositories repository.Repositories) int {
+	count := 0
+	for _, repo := range repositories {
+		if repo.HasDiscussions {
+			count++
+		}
+	}
+	return (count + discussions.RepositoryBatchSize - 1) / discussions.RepositoryBatchSize
+}
+
+// uncoveredRepositories lists the activity types still missing for each
+// repository after a cancelled scan.
+func uncoveredRepositories(repositories repository.Repositories, activityTypes []string, covered map[string]bool, discussionsDone bool) []UncoveredRepository {
+	var uncovered []UncoveredRepository
+	for _, repo := range repositories {
+		var missing []string
+		for _, activityType := range activityTypes {
+			if activityType == "discussions" {
+				if !discussionsDone && repo.HasDiscussions {
+					missing = append(missing, activityType)
+				}
+			} else if !covered[repo.Name] {
+				missing = append(missing, activityType)
+			}
+		}
+		if len(missing) > 0 {
+			uncovered = append(uncovered, UncoveredRepository{Name: repo.Name, ActivityTypes: missing})
+		}
+	}
+	return uncovered
+}
+
+// checkDiscussionActivity marks the authors of discussions, comments and
+// replies created since the date. Only repositories with discussions enabled
+// are queried, but every repository counts towards progress.
+func (ac *ActivityChecker) checkDiscussionActivity(ctx context.Context, organization string, repositories repository.Repositories, since time.Time, gqlClient api.GQLClient, progressBar *ui.ProgressBar, progressMux *sync.Mutex) error {
+	var names []string
+	for _, repo := range repositories {
+		if repo.HasDiscussions {
+			names = append(names, repo.Name)
+		} else {
+			incrementProgress(progressBar, progressMux)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	if gqlClient == nil {
+		return fmt.Errorf("GraphQL client is required to check discussions")
+	}
+
+	activityList, err := discussions.GetDiscussionActivitySinceDate(ctx, organization, names, since, gqlClient)
+	if err != nil {
+		return err
+	}
+	for _, item := range activityList {
+		ac.markUserActive(item.Login, "discussions", users.Evidence{At: item.At, Repository: item.Repository, URL: item.URL})
+	}
+	for range names {
+		incrementProgress(progressBar, progressMux)
+	}
+	return nil
+}
+
+// CheckUserContributions marks users active from their contributionsCollection
+// in the organization. It asks about each user in batched GraphQL queries
+// instead of walking every repository, and only covers the activity types in
+// contributions.SupportedActivityTypes.
+func (ac *ActivityChecker) CheckUserContributions(ctx context.Context, usersList users.Users, organization string, date string, gqlClient api.GQLClient, activityTypes []string) error {
+	ac.indexUsers(usersList)
+	if unsupported := contributions.UnsupportedActivityTypes(activityTypes); len(unsupported) > 0 {
+		ui.Warning("The users scan strategy does not check: %s", strings.Join(unsupported, ", "))
+	}
+
+	since, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return err
+	}
+	organizationID, err := contributions.GetOrganizationID(ctx, organization, gqlClient)
+	if err != nil {
+		return err
+	}
+
+	logins := make([]string, 0, len(usersList))
+	for i := range usersList {
+		logins = append(logins, usersList[i].Login)
+	}
+	progressBar := ui.NewProgressBar(len(logins), "Checking user contributions...")
+	found, err := contributions.GetUserContributions(ctx, logins, organizationID, since, gqlClient, activityTypes, func(count int) {
+		for range count {
+			progressBar.Increment()
+		}
+	})
+	progressBar.Complete()
+	if err != nil {
+		return err
+	}
+
+	for login, contributionList := range found {
+		for _, contribution := range contributionList {
+			ac.markUserActive(login, contribution.ActivityType, users.Evidence{
+				At:         contribution.At,
+				Repository: contribution.Repository,
+				URL:        contribution.URL,
+			})
+		}
+	}
+	return nil
+}
+
+// CheckSearchActivity marks users active using the search API, with at most
+// one commit search and one issue search per user. The issue search is skipped
+// once a user is known to be active, to conserve the search rate limit.
+func (ac *ActivityChecker) CheckSearchActivity(ctx context.Context, usersList users.Users, organization string, date string, client api.RESTClient, activityTypes []string) error {
+	ac.indexUsers(usersList)
+	if unsupported := search.UnsupportedActivityTypes(activityTypes); len(unsupported) > 0 {
+		ui.Warning("The search scan strategy does not check: %s", strings.Join(unsupported, ", "))
+	}
+
+	since, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return err
+	}
+	checkCommits := slices.ContainsFunc(activityTypes, func(t string) bool { return slices.Contains(search.CommitActivityTypes, t) })
+	checkIssues := slices.ContainsFunc(activityTypes, func(t string) bool { return slices.Contains(search.IssueActivityTypes, t) })
+
+	progressBar := ui.NewProgressBar(len(usersList), "Searching for user activity...")
+	defer progressBar.Complete()
+	for i := range usersList {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		login := usersList[i].Login
+		if checkCommits {
+			match, err := search.FindCommitActivity(ctx, organization, login, since, client)
+			if err != nil {
+				return err
+			}
+			ac.markSearchMatch(login, match)
+		}
+		if checkIssues && usersList[i].IsActive() {
+			ac.stopped.Store(true)
+		} else if checkIssues {
+			match, err := search.FindIssueActivity(ctx, organization, login, since, activityTypes, client)
+			if err != nil {
+				return err
+			}
+			ac.markSearchMatch(login, match)
+		}
+		progressBar.Increment()
+	}
+	return nil
+}
+
+func (ac *ActivityChecker) markSearchMatch(login string, match *search.Match) {
+	if match == nil {
+		return
+	}
+	ac.markUserActive(login, match.ActivityType, users.Evidence{At: match.At, Repository: match.Repository, URL: match.URL})
+}
+
+// CheckAuditLogActivity marks users who acted in the organization audit log
+// since the date. Events are read from an exported file when exportPath is
+// set, otherwise the audit log API is queried once per user, by the checker's
+// workers. Actors are matched to users ignoring case.
+func (ac *ActivityChecker) CheckAuditLogActivity(ctx context.Context, usersList users.Users, organization string, date string, client api.RESTClient, exportPath string) error {
+	ac.indexUsers(usersList)
+
+	since, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return err
+	}
+
+	var latest map[string]auditlog.Event
+	if exportPath != "" {
+		events, err := auditlog.ReadFile(exportPath)
+		if err != nil {
+			return err
+		}
+		latest = auditlog.LatestByActor(events, since)
+	} else {
+		progressBar := ui.NewProgressBar(len(usersList), "Checking audit log...")
+		latest, err = ac.latestAuditLogEvents(ctx, organization, usersList, since, client, progressBar)
+		progressBar.Complete()
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := range usersList {
+		login := usersList[i].Login
+		event, ok := latest[strings.ToLower(login)]
+		if !ok {
+			continue
+		}
+		ac.markUserActive(login, auditlog.ActivityType, users.Evidence{
+			At:         event.At(),
+			Repository: event.Repository(),
+			URL:        auditlog.EvidenceURL(ac.host, organization, login),
+		})
+	}
+	return nil
+}
+
+// latestAuditLogEvents asks the audit log API for each user's newest event
+// since the date, keyed by lowercase login. The first error stops the
+// remaining requests.
+func (ac *ActivityChecker) latestAuditLogEvents(ctx context.Context, organization string, usersList users.Users, since time.Time, client api.RESTClient, progressBar *ui.ProgressBar) (map[string]auditlog.Event, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	latest := make(map[string]auditlog.Event)
+	var firstErr error
+	var mu sync.Mutex
+	var progressMux sync.Mutex
+	var wg sync.WaitGroup
+	logins := make(chan string)
+	for range ac.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for login := range logins {
+				if ctx.Err() != nil {
+					continue
+				}
+				event, err := auditlog.GetLatestActorEvent(ctx, organization, login, since, client)
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				if event != nil {
+					latest[strings.ToLower(login)] = *event
+				}
+				mu.Unlock()
+				incrementProgress(progressBar, &progressMux)
+			}
+		}()
+	}
+
+send:
+	for i := range usersList {
+		select {
+		case <-ctx.Done():
+			break send
+		case logins <- usersList[i].Login:
+		}
+	}
+	close(logins)
+	wg.Wait()
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	return latest, firstErr
+}
+
+// CheckCopilotActivity records each user's Copilot seat and marks users whose
+// seat was used after the date as active.
+func (ac *ActivityChecker) CheckCopilotActivity(ctx context.Context, usersList users.Users, organization string, date string, client api.RESTClient) error {
+	ac.indexUsers(usersList)
+
+	since, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return err
+	}
+	seats, err := copilot.GetSeats(ctx, organization, client)
+	if err != nil {
+		return err
+	}
+
+	for i := range usersList {
+		usersList[i].SetCopilotSeat(users.CopilotSeat{Checked: true})
+	}
+	for _, seat := range seats {
+		user, exists := ac.userIndex[seat.Assignee.Login]
+		if !exists {
+			continue
+		}
+		assigned := users.CopilotSeat{Checked: true, Assigned: true, LastActivityEditor: seat.LastActivityEditor}
+		if seat.LastActivityAt != nil {
+			assigned.LastActivityAt = seat.LastActivityAt.UTC()
+		}
+		user.SetCopilotSeat(assigned)
+		if seat.LastActivityAt != nil && seat.LastActivityAt.After(since) {
+			ac.markUserActive(user.Login, copilot.ActivityType, users.Evidence{
+				At:  assigned.LastActivityAt,
+				URL: copilot.SeatManagementURL(ac.host, organization),
+			})
+		}
+	}
+	return nil
+}
+
+// restoreCheckpoint marks users active with the activity recorded by an
+// earlier, interrupted run.
+func (ac *ActivityChecker) restoreCheckpoint() {
+	if ac.checkpoint == nil {
+		return
+	}
+	for login, recorded := range ac.checkpoint.Users() {
+		evidence := users.Evidence{At: recorded.LastActiveAt, Repository: recorded.Repository, URL: recorded.URL}
+		for _, activityType := range recorded.ActivityTypes {
+			ac.markUserActive(login, activityType, evidence)
+		}
+	}
+	// Restored activity is already in the checkpoint
+	ac.mu.Lock()
+	clear(ac.changed)
+	ac.mu.Unlock()
+}
+
+// changedActivity returns the activity of the users marked active since it
+// was last called, for the checkpoint to record.
+func (ac *ActivityChecker) changedActivity() map[string]checkpoint.UserActivity {
+	ac.mu.Lock()
+	changed := ac.changed
+	ac.changed = make(map[string]bool)
+	ac.mu.Unlock()
+
+	found := make(map[string]checkpoint.UserActivity, len(changed))
+	for login := range changed {
+		user := ac.userIndex[login]
+		activityTypes := user.GetActivityTypes()
+		if len(activityTypes) == 0 {
+			continue
+		}
+		sort.Strings(activityTypes)
+		evidence := user.GetLastActivity()
+		found[login] = checkpoint.UserActivity{
+			ActivityTypes: activityTypes,
+			LastActiveAt:  evidence.At,
+			Repository:    evidence.Repository,
+			URL:           evidence.URL,
+		}
+	}
+	return found
+}
+
+// indexUsers builds the user index used for O(1) lookups. Users already
+// marked active by an earlier check stay active.
+func (ac *ActivityChecker) indexUsers(usersList users.Users) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	for i := range usersList {
+		user := &usersList[i]
+		ac.userIndex[user.Login] = user
+		if _, seen := ac.activeUsers[user.Login]; !seen {
+			ac.activeUsers[user.Login] = false
+		}
+	}
+	ac.unresolved = 0
+	for _, active := range ac.activeUsers {
+		if !active {
+			ac.unresolved++
+		}
+	}
+}
+
 // checkRepoActivity checks all enabled activity types for a single repository.
-func (ac *ActivityChecker) checkRepoActivity(organization string, repo repository.Repository, date string, since time.Time, client api.RESTClient, typeSet activityTypeSet, progressBar *ui.ProgressBar, progressMux *sync.Mutex) error {
+// Listings are prefetched, as most are read to the end; the few that stop
+// once every user is resolved waste at most a handful of requests.
+func (ac *ActivityChecker) checkRepoActivity(ctx context.Context, organization string, repo repository.Repository, date string, since time.Time, client api.RESTClient, typeSet activityTypeSet, progressBar *ui.ProgressBar, progressMux *sync.Mutex) error {
 	// Check commits
 	if typeSet["commits"] {
 		if repo.Size > 0 && (repo.PushedAt == nil || !repo.PushedAt.Before(since)) {
-			commitList, err := commits.GetCommitsSinceDate(organization, repo.Name, date, client)
+			err := walkPages(ac, "commits", commits.CommitPagesSinceDate(ctx, organization, repo.Name, date, client, true), func(commit commits.Commit) {
+				ac.markUserActive(commit.Author.Login, "commits", activityEvidence(repo.Name, commit.HTMLURL, commit.Commit.Committer.Date))
+			})
 			if err != nil {
 				if !skipUnavailableRepositoryEndpoint(progressBar, repo.Name, "commits", err) {
 					return err
 				}
 			}
-			for _, commit := range commitList {
-				ac.markUserActive(commit.Author.Login, "commits")
-			}
 		}
 		incrementProgress(progressBar, progressMux)
 	}
 
 	// Check issues
 	if typeSet["issues"] {
-		issueList, err := issues.GetIssuesSinceDate(organization, repo.Name, date, client)
+		err := walkPages(ac, "issues", issues.IssuePagesSinceDate(ctx, organization, repo.Name, date, client, true), func(issue issues.Issue) {
+			// The listing holds issues anyone updated since the date, so only
+			// those opened since then count for their author.
+			if created, err := time.Parse(time.RFC3339, issue.CreatedAt); err == nil && created.Before(since) {
+				return
+			}
+			ac.markUserActive(issue.User.Login, "issues", activityEvidence(repo.Name, issue.HTMLURL, issue.CreatedAt))
+		})
 		if err != nil {
 			if !skipUnavailableRepositoryEndpoint(progressBar, repo.Name, "issues", err) {
 				return err
 			}
 		}
-		for _, issue := range issueList {
-			ac.markUserActive(issue.User.Login, "issues")
-		}
 		incrementProgress(progressBar, progressMux)
 	}
 
 	// Check issue comments
 	if typeSet["issue-comments"] {
-		issueCommentList, err := issues.GetIssueCommentsSinceDate(organization, repo.Name, date, client)
+		err := walkPages(ac, "issue-comments", issues.IssueCommentPagesSinceDate(ctx, organization, repo.Name, date, client, true), func(comment issues.IssueComment) {
+			ac.markUserActive(comment.User.Login, "issue-comments", activityEvidence(repo.Name, comment.HTMLURL, comment.CreatedAt, comment.UpdatedAt))
+		})
 		if err != nil {
 			if !skipUnavailableRepositoryEndpoint(progressBar, repo.Name, "issue comments", err) {
 				return err
 			}
 		}
-		for _, comment := range issueCommentList {
-			ac.markUserActive(comment.User.Login, "issue-comments")
-		}
 		incrementProgress(progressBar, progressMux)
 	}
 
 	// Check PR comments
 	if typeSet["pr-comments"] {
-		prCommentList, err := pullrequests.GetPullRequestCommentsSinceDate(organization, repo.Name, date, client)
+		err := walkPages(ac, "pr-comments", pullrequests.PullRequestCommentPagesSinceDate(ctx, organization, repo.Name, date, client, true), func(comment pullrequests.PullRequestComment) {
+			ac.markUserActive(comment.User.Login, "pr-comments", activityEvidence(repo.Name, comment.HTMLURL, comment.CreatedAt, comment.UpdatedAt))
+		})
 		if err != nil {
 			if !skipUnavailableRepositoryEndpoint(progressBar, repo.Name, "pull request comments", err) {
 				return err
 			}
 		}
-		for _, comment := range prCommentList {
-			ac.markUserActive(comment.User.Login, "pr-comments")
-		}
 		incrementProgress(progressBar, progressMux)
 	}
+
+	// Check PR authors and reviewers; both walk the pull requests updated since the date
+	if typeSet["pull-requests"] || typeSet["pr-reviews"] {
+		pullRequestList, err := pullrequests.GetPullRequestsUpdatedSinceDate(ctx, organization, repo.Name, date, client)
+		if err != nil {
+			if !skipUnavailableRepositoryEndpoint(progressBar, repo.Name, "pull requests", err) {
+				return err
+			}
+		}
+		if typeSet["pull-requests"] {
+			for _, pullRequest := range pullRequestList {
+				if occurredSince(pullRequest.CreatedAt, since) {
+					ac.markUserActive(pullRequest.User.Login, "pull-requests", activityEvidence(repo.Name, pullRequest.HTMLURL, pullRequest.CreatedAt))
+				}
+			}
+			incrementProgress(progressBar, progressMux)
+		}
+		if typeSet["pr-reviews"] {
+			for index, pullRequest := range pullRequestList {
+				if ac.resolved("pr-reviews") {
+					ac.skip(int64(len(pullRequestList) - index))
+					break
+				}
+				reviews, err := pullrequests.GetPullRequestReviews(ctx, organization, repo.Name, pullRequest.Number, client)
+				if err != nil {
+					if !skipUnavailableRepositoryEndpoint(progressBar, repo.Name, "pull request reviews", err) {
+						return err
+					}
+					continue
+				}
+				for _, review := range reviews {
+					if occurredSince(review.SubmittedAt, since) {
+						ac.markUserActive(review.User.Login, "pr-reviews", activityEvidence(repo.Name, review.HTMLURL, review.SubmittedAt))
+					}
+				}
+			}
+			incrementProgress(progressBar, progressMux)
+		}
+	}
 	return nil
 }
 
+// walkPages marks activity from each item of a paginated listing. Once every
+// user is resolved for the activity type the remaining pages are not
+// requested, since they can no longer change the report.
+func walkPages[T any](ac *ActivityChecker, activityType string, pages iter.Seq2[[]T, error], mark func(T)) error {
+	if ac.resolved(activityType) {
+		ac.skip(1)
+		return nil
+	}
+	for page, err := range pages {
+		if err != nil {
+			return err
+		}
+		for _, item := range page {
+			mark(item)
+		}
+		if ac.resolved(activityType) {
+			ac.stopped.Store(true)
+			return nil
+		}
+	}
+	return nil
+}
+
+// resolved reports whether reading more activity of the given type could
+// change the report: without a breakdown only unresolved (inactive) users
+// matter, with one every user must have been seen with the type.
+func (ac *ActivityChecker) resolved(activityType string) bool {
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+	return ac.resolvedLocked(activityType)
+}
+
+func (ac *ActivityChecker) resolvedLocked(activityType string) bool {
+	if ac.breakdown {
+		return len(ac.seen[activityType]) == len(ac.userIndex)
+	}
+	return ac.unresolved == 0
+}
+
+// scanResolved reports whether every REST activity type of the running
+// repository scan is resolved.
+func (ac *ActivityChecker) scanResolved() bool {
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+	if len(ac.scanTypes) == 0 {
+		return false
+	}
+	for _, activityType := range ac.scanTypes {
+		if !ac.resolvedLocked(activityType) {
+			return false
+		}
+	}
+	return true
+}
+
+// occurredSince reports whether an RFC 3339 timestamp is on or after since.
+// Missing or unparseable timestamps (such as pending reviews) never count.
+func occurredSince(value string, since time.Time) bool {
+	at, err := time.Parse(time.RFC3339, value)
+	return err == nil && !at.Before(since)
+}
+
 func skipUnavailableRepositoryEndpoint(progressBar *ui.ProgressBar, repoName string, activityType string, err error) bool {
 	if !githubapi.IsRepositoryUnavailable(err) {
 		return false
@@ -193,20 +843,47 @@ func incrementProgress(progressBar *ui.ProgressBar, progressMux *sync.Mutex) {
 	progressMux.Unlock()
 }
 
+// activityEvidence describes the API object that proved activity, using the
+// latest of its parseable timestamps.
+func activityEvidence(repoName string, url string, timestamps ...string) users.Evidence {
+	evidence := users.Evidence{Repository: repoName, URL: url}
+	for _, value := range timestamps {
+		at, err := time.Parse(time.RFC3339, value)
+		if err == nil && at.After(evidence.At) {
+			evidence.At = at.UTC()
+		}
+	}
+	return evidence
+}
+
 // markUserActive marks a user as active with the given activity type using O(1) lookup.
-func (ac *ActivityChecker) markUserActive(login string, activityType string) {
+func (ac *ActivityChecker) markUserActive(login string, activityType string, evidence users.Evidence) {
 	user, exists := ac.userIndex[login]
 	if !exists {
 		return
 	}
 
 	// Use atomic method on user (handles its own locking)
-	user.MarkActiveWithType(activityType)
+	user.RecordActivity(activityType, evidence)
 
 	// Update activeUsers map
 	ac.mu.Lock()
-	ac.activeUsers[login] = true
+	if !ac.activeUsers[login] {
+		ac.activeUsers[login] = true
+		ac.unresolved--
+	}
+	if ac.seen[activityType] == nil {
+		ac.seen[activityType] = make(map[string]bool)
+	}
+	ac.seen[activityType][login] = true
+	ac.changed[login] = true
+	stopScan := ac.stopScan
 	ac.mu.Unlock()
+
+	if stopScan != nil && ac.scanResolved() {
+		ac.stopped.Store(true)
+		stopScan()
+	}
 }
 
 // GenerateBarChart generates a bar chart of active/inactive users
@@ -232,7 +909,13 @@ func (ac *ActivityChecker) GenerateBarChart() {
 	ui.BarChart(bars)
 }
 
-func GenerateUserReportCSV(users users.Users, filePath string) error {
+// GenerateUserReportCSV writes one row per user. EvidenceComplete and Partial
+// are the same on every row: when EvidenceComplete is false, the scan stopped
+// reading activity once it could no longer change the verdicts, so
+// ActivityTypes and LastActiveAt are the first evidence found. Partial is
+// true when the run was interrupted, so inactive users may not have been
+// checked.
+func GenerateUserReportCSV(users users.Users, filePath string, evidenceComplete bool, partial bool) error {
 	ui.Info("Generating CSV report: %s", filePath)
 	file, err := os.Create(filePath)
 	if err != nil {
@@ -243,7 +926,10 @@ func GenerateUserReportCSV(users users.Users, filePath string) error {
 	writer := csv.NewWriter(file)
 	defer writer.Flush()
 
-	header := []string{"Username", "Email", "Active", "ActivityTypes"}
+	header := []string{
+		"Username", "Email", "Active", "ActivityTypes", "LastActiveAt", "LastActiveRepo", "EvidenceURL",
+		"CopilotSeat", "CopilotLastActivityAt", "CopilotLastActivityEditor", "EvidenceComplete", "Partial",
+	}
 	if err := writer.Write(header); err != nil {
 		return err
 	}
@@ -256,7 +942,22 @@ func GenerateUserReportCSV(users users.Users, filePath string) error {
 		} else {
 			atSlice = user.GetActivityTypes()
 		}
-		record := []string{user.Login, user.Email, strconv.FormatBool(user.IsActive()), strings.Join(atSlice, ",")}
+		evidence := user.GetLastActivity()
+		lastActiveAt := ""
+		if !evidence.At.IsZero() {
+			lastActiveAt = evidence.At.UTC().Format(time.RFC3339)
+		}
+		record := []string{
+			user.Login,
+			user.Email,
+			strconv.FormatBool(user.IsActive()),
+			strings.Join(atSlice, ","),
+			lastActiveAt,
+			evidence.Repository,
+			evidence.URL,
+		}
+		record = append(record, user.GetCopilotSeat().Columns()...)
+		record = append(record, strconv.FormatBool(evidenceComplete), strconv.FormatBool(partial))
 		if err := writer.Write(record); err != nil {
 			return err
 		}
@@ -265,3 +966,26 @@ func GenerateUserReportCSV(users users.Users, filePath string) error {
 	ui.Success("Report saved to %s", filePath)
 	return nil
 }
+
+// GenerateUncoveredRepositoriesCSV lists the repositories an interrupted scan
+// did not finish, with the activity types that were not collected for each.
+func GenerateUncoveredRepositoriesCSV(uncovered []UncoveredRepository, filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"Repository", "MissingActivityTypes"}); err != nil {
+		return err
+	}
+	for _, repo := range uncovered {
+		if err := writer.Write([]string{repo.Name, strings.Join(repo.ActivityTypes, ",")}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
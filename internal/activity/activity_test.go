@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
@@ -14,6 +17,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ssulei7/gh-dormant-users/internal/checkpoint"
 	"github.com/ssulei7/gh-dormant-users/internal/repository"
 	"github.com/ssulei7/gh-dormant-users/internal/users"
 )
@@ -26,6 +30,8 @@ type countingRESTClient struct {
 type routeRESTClient struct {
 	mu       sync.Mutex
 	routes   map[string]string
+	links    map[string]string
+	lasts    map[string]string
 	errs     map[string]error
 	requests []string
 }
@@ -41,9 +47,17 @@ func (c *routeRESTClient) Request(_ string, path string, _ io.Reader) (*http.Res
 	if !ok {
 		return nil, fmt.Errorf("unexpected request: %s", path)
 	}
+	header := make(http.Header)
+	if next, ok := c.links[path]; ok {
+		link := fmt.Sprintf("<%s>; rel=\"next\"", next)
+		if last, ok := c.lasts[path]; ok {
+			link += fmt.Sprintf(", <%s>; rel=\"last\"", last)
+		}
+		header.Set("Link", link)
+	}
 	return &http.Response{
 		StatusCode: http.StatusOK,
-		Header:     make(http.Header),
+		Header:     header,
 		Body:       io.NopCloser(bytes.NewBufferString(body)),
 	}, nil
 }
@@ -147,11 +161,13 @@ func TestCheckActivitySkipsRepositoriesWithoutRecentPushes(t *testing.T) {
 	checker := NewActivityChecker()
 
 	err := checker.CheckActivity(
+		context.Background(),
 		users.Users{{Login: "octocat"}},
 		"example",
 		repositories,
 		time.Now().AddDate(0, -1, 0).UTC().Format(time.RFC3339),
 		client,
+		nil,
 		[]string{"commits"},
 	)
 	if err != nil {
@@ -192,11 +208,13 @@ func TestCheckActivityMarksUsersForAllSelectedActivity(t *testing.T) {
 
 	checker := NewActivityChecker(1)
 	err := checker.CheckActivity(
+		context.Background(),
 		userList,
 		"example",
 		repository.Repositories{{Name: "widgets", Size: 1}},
 		date,
 		client,
+		nil,
 		[]string{"commits", "issues", "issue-comments", "pr-comments"},
 	)
 	if err != nil {
@@ -231,11 +249,13 @@ func TestCheckActivityOnlyRequestsSelectedTypes(t *testing.T) {
 	client := &routeRESTClient{routes: map[string]string{path: `[]`}}
 
 	err := NewActivityChecker(1).CheckActivity(
+		context.Background(),
 		users.Users{{Login: "octocat"}},
 		"example",
 		repository.Repositories{{Name: "widgets", Size: 1}},
 		date,
 		client,
+		nil,
 		[]string{"issues"},
 	)
 	if err != nil {
@@ -249,11 +269,13 @@ func TestCheckActivityOnlyRequestsSelectedTypes(t *testing.T) {
 func TestCheckActivityRejectsInvalidDate(t *testing.T) {
 	client := &countingRESTClient{}
 	err := NewActivityChecker().CheckActivity(
+		context.Background(),
 		users.Users{{Login: "octocat"}},
 		"example",
 		repository.Repositories{{Name: "widgets", Size: 1}},
 		"not-a-date",
 		client,
+		nil,
 		[]string{"issues"},
 	)
 	if err == nil {
@@ -273,11 +295,13 @@ func TestCheckActivityReturnsEndpointError(t *testing.T) {
 	}
 
 	err := NewActivityChecker(1).CheckActivity(
+		context.Background(),
 		users.Users{{Login: "octocat"}},
 		"example",
 		repository.Repositories{{Name: "widgets", Size: 1}},
 		date,
 		client,
+		nil,
 		[]string{"issues"},
 	)
 	if err == nil || !strings.Contains(err.Error(), "fetch issues for example/widgets") {
@@ -290,8 +314,677 @@ func TestMarkUserActiveIgnoresUnknownLogin(t *testing.T) {
 	checker.userIndex["octocat"] = &users.User{Login: "octocat"}
 	checker.activeUsers["octocat"] = false
 
-	checker.markUserActive("outsider", "issues")
+	checker.markUserActive("outsider", "issues", users.Evidence{})
 	if checker.activeUsers["octocat"] {
 		t.Fatal("known user changed when marking unknown login")
 	}
 }
+
+func TestCheckActivityRecordsNewestEvidence(t *testing.T) {
+	date := "2026-07-01T00:00:00Z"
+	client := &routeRESTClient{routes: map[string]string{
+		"repos/example/widgets/commits?per_page=100&since=" + date: `[
+			{"html_url":"https://github.com/example/widgets/commit/old","commit":{"author":{"date":"2026-07-02T00:00:00Z"},"committer":{"date":"2026-07-02T00:00:00Z"}},"author":{"login":"octocat"}},
+			{"html_url":"https://github.com/example/widgets/commit/new","commit":{"author":{"date":"2026-06-20T00:00:00Z"},"committer":{"date":"2026-07-05T10:00:00Z"}},"author":{"login":"octocat"}}
+		]`,
+		"repos/example/widgets/issues/comments?per_page=100&since=" + date: `[
+			{"html_url":"https://github.com/example/widgets/issues/1#issuecomment-1","created_at":"2026-07-03T00:00:00Z","updated_at":"2026-07-04T00:00:00Z","user":{"login":"octocat"}}
+		]`,
+	}}
+	userList := users.Users{{Login: "octocat"}}
+
+	err := NewActivityChecker(1).CheckActivity(
+		context.Background(),
+		userList,
+		"example",
+		repository.Repositories{{Name: "widgets", Size: 1}},
+		date,
+		client,
+		nil,
+		[]string{"commits", "issue-comments"},
+	)
+	if err != nil {
+		t.Fatalf("CheckActivity returned error: %v", err)
+	}
+
+	evidence := userList[0].GetLastActivity()
+	if !evidence.At.Equal(time.Date(2026, 7, 5, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("last active at = %v", evidence.At)
+	}
+	if evidence.Repository != "widgets" || evidence.URL != "https://github.com/example/widgets/commit/new" {
+		t.Fatalf("evidence = %#v", evidence)
+	}
+}
+
+func TestCheckActivityCountsOnlyIssuesOpenedSinceDate(t *testing.T) {
+	date := "2026-07-01T00:00:00Z"
+	client := &routeRESTClient{routes: map[string]string{
+		"repos/example/widgets/issues?per_page=100&since=" + date: `[
+			{"html_url":"https://github.com/example/widgets/issues/2","created_at":"2026-07-03T00:00:00Z","updated_at":"2026-07-04T00:00:00Z","user":{"login":"octocat"}},
+			{"html_url":"https://github.com/example/widgets/issues/1","created_at":"2026-05-01T00:00:00Z","updated_at":"2026-07-02T00:00:00Z","user":{"login":"hubot"}}
+		]`,
+	}}
+	userList := users.Users{{Login: "octocat"}, {Login: "hubot"}}
+
+	err := NewActivityChecker(1).CheckActivity(
+		context.Background(),
+		userList,
+		"example",
+		repository.Repositories{{Name: "widgets", Size: 1}},
+		date,
+		client,
+		nil,
+		[]string{"issues"},
+	)
+	if err != nil {
+		t.Fatalf("CheckActivity returned error: %v", err)
+	}
+
+	if !userList[0].IsActive() {
+		t.Fatal("octocat opened an issue since the date and should be active")
+	}
+	if evidence := userList[0].GetLastActivity(); !evidence.At.Equal(time.Date(2026, 7, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("last active at = %v, want the issue's creation time", evidence.At)
+	}
+	if userList[1].IsActive() {
+		t.Fatal("hubot only opened an issue before the date and should stay dormant")
+	}
+}
+
+func TestCheckActivityResumesFromCheckpoint(t *testing.T) {
+	date := "2026-07-01T00:00:00Z"
+	path := filepath.Join(t.TempDir(), "example.checkpoint.ndjson")
+	cp, err := checkpoint.Create(path, "example", date, []string{"commits"})
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	at := time.Date(2026, 7, 3, 0, 0, 0, 0, time.UTC)
+	if err := cp.CompleteRepository("done", func() map[string]checkpoint.UserActivity {
+		return map[string]checkpoint.UserActivity{
+			"earlier": {ActivityTypes: []string{"commits"}, LastActiveAt: at, Repository: "done", URL: "https://github.com/example/done/commit/1"},
+		}
+	}); err != nil {
+		t.Fatalf("CompleteRepository returned error: %v", err)
+	}
+
+	resumed, err := checkpoint.Resume(path, "example", date, []string{"commits"})
+	if err != nil {
+		t.Fatalf("Resume returned error: %v", err)
+	}
+	client := &routeRESTClient{routes: map[string]string{
+		"repos/example/widgets/commits?per_page=100&since=" + date: `[{"author":{"login":"later"}}]`,
+	}}
+	userList := users.Users{{Login: "earlier"}, {Login: "later"}, {Login: "inactive"}}
+
+	checker := NewActivityChecker(1)
+	checker.UseCheckpoint(resumed)
+	err = checker.CheckActivity(
+		context.Background(),
+		userList,
+		"example",
+		repository.Repositories{{Name: "done", Size: 1}, {Name: "widgets", Size: 1}},
+		date,
+		client,
+		nil,
+		[]string{"commits"},
+	)
+	if err != nil {
+		t.Fatalf("CheckActivity returned error: %v", err)
+	}
+
+	if len(client.requests) != 1 {
+		t.Fatalf("requests = %v, want only widgets", client.requests)
+	}
+	if !userList[0].IsActive() || userList[0].GetLastActivity().URL != "https://github.com/example/done/commit/1" {
+		t.Fatalf("checkpointed evidence was not restored: %#v", userList[0].GetLastActivity())
+	}
+	if !userList[1].IsActive() || userList[2].IsActive() {
+		t.Fatal("activity from the remaining repository was not recorded")
+	}
+
+	final, err := checkpoint.Resume(path, "example", date, []string{"commits"})
+	if err != nil {
+		t.Fatalf("Resume returned error: %v", err)
+	}
+	if !final.RepositoryDone("widgets") || len(final.Users()) != 2 {
+		t.Fatalf("checkpoint was not updated: %d repositories, users %v", final.CompletedRepositories(), final.Users())
+	}
+	// Only the user found in widgets is written again
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read checkpoint: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if last := lines[len(lines)-1]; !strings.Contains(last, `"later"`) || strings.Contains(last, `"earlier"`) {
+		t.Fatalf("widgets record = %s", last)
+	}
+}
+
+// cancellingRESTClient cancels the run when a path is requested, as Ctrl-C
+// would while that request is in flight.
+type cancellingRESTClient struct {
+	*routeRESTClient
+	cancelOn string
+	cancel   context.CancelFunc
+}
+
+func (c *cancellingRESTClient) RequestWithContext(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	if path == c.cancelOn {
+		c.cancel()
+		return nil, ctx.Err()
+	}
+	return c.routeRESTClient.Request(method, path, body)
+}
+
+func TestCheckActivityResumesMixedRepositoriesWithSeveralWorkers(t *testing.T) {
+	date := "2026-07-01T00:00:00Z"
+	path := filepath.Join(t.TempDir(), "example.checkpoint.ndjson")
+	cp, err := checkpoint.Create(path, "example", date, []string{"commits"})
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	client := &routeRESTClient{routes: map[string]string{}}
+	var repositories repository.Repositories
+	for index := range 40 {
+		name := fmt.Sprintf("repo%d", index)
+		repositories = append(repositories, repository.Repository{Name: name, Size: 1})
+		if index%2 == 0 {
+			if err := cp.CompleteRepository(name, func() map[string]checkpoint.UserActivity { return nil }); err != nil {
+				t.Fatalf("CompleteRepository returned error: %v", err)
+			}
+			continue
+		}
+		client.routes["repos/example/"+name+"/commits?per_page=100&since="+date] = `[]`
+	}
+	resumed, err := checkpoint.Resume(path, "example", date, []string{"commits"})
+	if err != nil {
+		t.Fatalf("Resume returned error: %v", err)
+	}
+
+	checker := NewActivityChecker(4)
+	checker.UseCheckpoint(resumed)
+	err = checker.CheckActivity(context.Background(), users.Users{{Login: "inactive"}}, "example", repositories, date, client, nil, []string{"commits"})
+	if err != nil {
+		t.Fatalf("CheckActivity returned error: %v", err)
+	}
+	if len(client.requests) != 20 {
+		t.Fatalf("requests = %d, want only the 20 pending repositories", len(client.requests))
+	}
+	if uncovered := checker.Uncovered(); len(uncovered) != 0 {
+		t.Fatalf("uncovered = %v, want every repository covered", uncovered)
+	}
+}
+
+func TestCheckActivityReportsUncoveredRepositoriesWhenCancelled(t *testing.T) {
+	date := "2026-07-01T00:00:00Z"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := &cancellingRESTClient{
+		routeRESTClient: &routeRESTClient{routes: map[string]string{
+			"repos/example/first/commits?per_page=100&since=" + date: `[{"author":{"login":"octocat"}}]`,
+		}},
+		cancelOn: "repos/example/second/commits?per_page=100&since=" + date,
+		cancel:   cancel,
+	}
+	userList := users.Users{{Login: "octocat"}, {Login: "dormant"}}
+
+	checker := NewActivityChecker(1)
+	err := checker.CheckActivity(
+		ctx,
+		userList,
+		"example",
+		repository.Repositories{
+			{Name: "first", Size: 1},
+			{Name: "second", Size: 1},
+			{Name: "third", Size: 1, HasDiscussions: true},
+		},
+		date,
+		client,
+		nil,
+		[]string{"commits", "discussions"},
+	)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want context.Canceled", err)
+	}
+	if !userList[0].IsActive() {
+		t.Fatal("activity found before the interrupt was lost")
+	}
+
+	uncovered := checker.Uncovered()
+	if len(uncovered) != 2 {
+		t.Fatalf("uncovered = %#v", uncovered)
+	}
+	if uncovered[0].Name != "second" || strings.Join(uncovered[0].ActivityTypes, ",") != "commits" {
+		t.Fatalf("second = %#v", uncovered[0])
+	}
+	if uncovered[1].Name != "third" || strings.Join(uncovered[1].ActivityTypes, ",") != "commits,discussions" {
+		t.Fatalf("third = %#v", uncovered[1])
+	}
+}
+
+func TestCheckActivityStopsOnceEveryUserIsActive(t *testing.T) {
+	date := "2026-07-01T00:00:00Z"
+	first := "repos/example/widgets/commits?per_page=100&since=" + date
+	client := &routeRESTClient{
+		routes: map[string]string{
+			first:                    `[{"author":{"login":"octocat"}},{"author":{"login":"hubot"}}]`,
+			"widgets-commits-page-2": `[{"author":{"login":"octocat"}}]`,
+		},
+		links: map[string]string{first: "widgets-commits-page-2"},
+	}
+	userList := users.Users{{Login: "octocat"}, {Login: "hubot"}}
+	recent := time.Date(2026, 7, 2, 0, 0, 0, 0, time.UTC)
+
+	checker := NewActivityChecker(1)
+	err := checker.CheckActivity(
+		context.Background(),
+		userList,
+		"example",
+		repository.Repositories{
+			{Name: "widgets", Size: 1},
+			{Name: "gadgets", Size: 1, PushedAt: &recent},
+			{Name: "docs", HasDiscussions: true},
+		},
+		date,
+		client,
+		nil,
+		[]string{"commits", "issues", "discussions"},
+	)
+	if err != nil {
+		t.Fatalf("CheckActivity returned error: %v", err)
+	}
+	if len(client.requests) != 1 || client.requests[0] != first {
+		t.Fatalf("requests = %v, want only %s", client.requests, first)
+	}
+	if !userList[0].IsActive() || !userList[1].IsActive() {
+		t.Fatal("users were not marked active")
+	}
+	// widgets issues, gadgets commits and issues, docs issues and one
+	// discussions query
+	if got := checker.SkippedRequests(); got != 5 {
+		t.Fatalf("skipped requests = %d, want 5", got)
+	}
+}
+
+func TestCheckActivityBreakdownStopsEachTypeOnceEveryUserIsSeen(t *testing.T) {
+	date := "2026-07-01T00:00:00Z"
+	first := "repos/example/widgets/commits?per_page=100&since=" + date
+	client := &routeRESTClient{
+		routes: map[string]string{
+			first:                    `[{"author":{"login":"octocat"}},{"author":{"login":"hubot"}}]`,
+			"widgets-commits-page-2": `[{"author":{"login":"octocat"}}]`,
+			"repos/example/widgets/issues?per_page=100&since=" + date: `[{"user":{"login":"octocat"}}]`,
+			"repos/example/gadgets/issues?per_page=100&since=" + date: `[{"user":{"login":"hubot"}}]`,
+		},
+		links: map[string]string{first: "widgets-commits-page-2"},
+	}
+	userList := users.Users{{Login: "octocat"}, {Login: "hubot"}}
+
+	checker := NewActivityChecker(1)
+	checker.UseActivityBreakdown()
+	err := checker.CheckActivity(
+		context.Background(),
+		userList,
+		"example",
+		repository.Repositories{{Name: "widgets", Size: 1}, {Name: "gadgets", Size: 1}},
+		date,
+		client,
+		nil,
+		[]string{"commits", "issues"},
+	)
+	if err != nil {
+		t.Fatalf("CheckActivity returned error: %v", err)
+	}
+
+	// Commits stop after the first page and are skipped for gadgets, but
+	// issues are still read until hubot has been seen.
+	want := []string{
+		first,
+		"repos/example/widgets/issues?per_page=100&since=" + date,
+		"repos/example/gadgets/issues?per_page=100&since=" + date,
+	}
+	if strings.Join(client.requests, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("requests = %v, want %v", client.requests, want)
+	}
+	for i := range userList {
+		if types := userList[i].GetActivityTypes(); len(types) != 2 {
+			t.Fatalf("%s activity types = %v", userList[i].Login, types)
+		}
+	}
+	// Only the gadgets commits listing; unread later pages are not counted
+	if got := checker.SkippedRequests(); got != 1 {
+		t.Fatalf("skipped requests = %d, want 1", got)
+	}
+}
+
+func TestActivityEvidenceUsesLatestTimestamp(t *testing.T) {
+	evidence := activityEvidence("widgets", "https://example.test", "2026-07-03T00:00:00Z", "not-a-date", "2026-07-04T00:00:00Z")
+	if !evidence.At.Equal(time.Date(2026, 7, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("evidence at = %v", evidence.At)
+	}
+	if empty := activityEvidence("widgets", "", ""); !empty.At.IsZero() {
+		t.Fatalf("empty evidence at = %v", empty.At)
+	}
+}
+
+func TestCheckActivityMarksPullRequestAuthorsAndReviewers(t *testing.T) {
+	date := "2026-07-01T00:00:00Z"
+	client := &routeRESTClient{routes: map[string]string{
+		"repos/example/widgets/pulls?state=all&sort=updated&direction=desc&per_page=100": `[
+			{"number":2,"created_at":"2026-07-03T00:00:00Z","updated_at":"2026-07-04T00:00:00Z","user":{"login":"author"}},
+			{"number":1,"created_at":"2026-05-01T00:00:00Z","updated_at":"2026-07-02T00:00:00Z","user":{"login":"old-author"}}
+		]`,
+		"repos/example/widgets/pulls/2/reviews?per_page=100": `[{"state":"APPROVED","submitted_at":"2026-07-04T00:00:00Z","user":{"login":"reviewer"}}]`,
+		"repos/example/widgets/pulls/1/reviews?per_page=100": `[{"state":"PENDING","user":{"login":"pending-reviewer"}}]`,
+	}}
+	userList := users.Users{{Login: "author"}, {Login: "old-author"}, {Login: "reviewer"}, {Login: "pending-reviewer"}}
+
+	err := NewActivityChecker(1).CheckActivity(
+		context.Background(),
+		userList,
+		"example",
+		repository.Repositories{{Name: "widgets", Size: 1}},
+		date,
+		client,
+		nil,
+		[]string{"pull-requests", "pr-reviews"},
+	)
+	if err != nil {
+		t.Fatalf("CheckActivity returned error: %v", err)
+	}
+
+	want := map[string]string{"author": "pull-requests", "old-author": "", "reviewer": "pr-reviews", "pending-reviewer": ""}
+	for i := range userList {
+		user := &userList[i]
+		got := strings.Join(user.GetActivityTypes(), ",")
+		if got != want[user.Login] {
+			t.Fatalf("%s activity types = %q, want %q", user.Login, got, want[user.Login])
+		}
+	}
+	if len(client.requests) != 3 {
+		t.Fatalf("requests = %v, want the pull request list fetched once", client.requests)
+	}
+}
+
+type staticGQLClient struct {
+	response string
+	queries  int
+}
+
+func (c *staticGQLClient) Do(_ string, _ map[string]interface{}, response interface{}) error {
+	c.queries++
+	return json.Unmarshal([]byte(c.response), response)
+}
+
+func (c *staticGQLClient) DoWithContext(_ context.Context, query string, variables map[string]interface{}, response interface{}) error {
+	return c.Do(query, variables, response)
+}
+
+func (c *staticGQLClient) Mutate(_ string, _ interface{}, _ map[string]interface{}) error {
+	return nil
+}
+
+func (c *staticGQLClient) MutateWithContext(_ context.Context, _ string, _ interface{}, _ map[string]interface{}) error {
+	return nil
+}
+
+func (c *staticGQLClient) Query(_ string, _ interface{}, _ map[string]interface{}) error {
+	return nil
+}
+
+func (c *staticGQLClient) QueryWithContext(_ context.Context, _ string, _ interface{}, _ map[string]interface{}) error {
+	return nil
+}
+
+func TestCheckActivityMarksDiscussionParticipants(t *testing.T) {
+	gqlClient := &staticGQLClient{response: `{"repo0": {"discussions": {"pageInfo": {}, "nodes": [{
+		"id": "D1", "url": "https://github.com/example/forum/discussions/1",
+		"createdAt": "2026-07-02T00:00:00Z", "updatedAt": "2026-07-02T00:00:00Z",
+		"author": {"login": "octocat"}, "comments": {"pageInfo": {}, "nodes": []}
+	}]}}}`}
+	userList := users.Users{{Login: "octocat"}, {Login: "hubot"}}
+
+	err := NewActivityChecker(1).CheckActivity(
+		context.Background(),
+		userList,
+		"example",
+		repository.Repositories{{Name: "forum", HasDiscussions: true}, {Name: "widgets"}},
+		"2026-07-01T00:00:00Z",
+		&countingRESTClient{},
+		gqlClient,
+		[]string{"discussions"},
+	)
+	if err != nil {
+		t.Fatalf("CheckActivity returned error: %v", err)
+	}
+	if gqlClient.queries != 1 {
+		t.Fatalf("queries = %d, want 1", gqlClient.queries)
+	}
+	if types := userList[0].GetActivityTypes(); len(types) != 1 || types[0] != "discussions" {
+		t.Fatalf("octocat activity types = %v", types)
+	}
+	if got := userList[0].GetLastActivity().URL; got != "https://github.com/example/forum/discussions/1" {
+		t.Fatalf("evidence URL = %q", got)
+	}
+	if userList[1].IsActive() {
+		t.Fatal("hubot was marked active")
+	}
+}
+
+func TestCheckActivityRequiresGraphQLForDiscussions(t *testing.T) {
+	err := NewActivityChecker(1).CheckActivity(
+		context.Background(),
+		users.Users{{Login: "octocat"}},
+		"example",
+		repository.Repositories{{Name: "forum", HasDiscussions: true}},
+		"2026-07-01T00:00:00Z",
+		&countingRESTClient{},
+		nil,
+		[]string{"discussions"},
+	)
+	if err == nil || !strings.Contains(err.Error(), "GraphQL client is required") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestCheckUserContributionsMarksContributors(t *testing.T) {
+	gqlClient := &staticGQLClient{response: `{
+		"organization": {"id": "O_1"},
+		"user0": {"login": "octocat", "contributionsCollection": {
+			"issueContributions": {"nodes": [{"occurredAt": "2026-07-03T00:00:00Z", "issue": {"url": "https://github.com/example/widgets/issues/1", "repository": {"name": "widgets"}}}]}
+		}},
+		"user1": {"login": "hubot", "contributionsCollection": {"issueContributions": {"nodes": []}}}
+	}`}
+	userList := users.Users{{Login: "octocat"}, {Login: "hubot"}}
+
+	checker := NewActivityChecker(1)
+	err := checker.CheckUserContributions(context.Background(), userList, "example", "2026-07-01T00:00:00Z", gqlClient, []string{"issues"})
+	if err != nil {
+		t.Fatalf("CheckUserContributions returned error: %v", err)
+	}
+	if gqlClient.queries != 2 {
+		t.Fatalf("queries = %d, want organization lookup and one batch", gqlClient.queries)
+	}
+	if !userList[0].IsActive() || userList[1].IsActive() {
+		t.Fatalf("active = %v/%v", userList[0].IsActive(), userList[1].IsActive())
+	}
+	if got := userList[0].GetLastActivity(); got.Repository != "widgets" || got.URL != "https://github.com/example/widgets/issues/1" {
+		t.Fatalf("evidence = %#v", got)
+	}
+	if !checker.activeUsers["octocat"] || checker.activeUsers["hubot"] {
+		t.Fatalf("active users = %v", checker.activeUsers)
+	}
+}
+
+func TestCheckSearchActivitySkipsIssueSearchForActiveUsers(t *testing.T) {
+	const (
+		octocatCommits = "search/commits?q=author%3Aoctocat+org%3Aexample+committer-date%3A%3E%3D2026-07-01T00%3A00%3A00Z&sort=committer-date&order=desc&per_page=1"
+		hubotCommits   = "search/commits?q=author%3Ahubot+org%3Aexample+committer-date%3A%3E%3D2026-07-01T00%3A00%3A00Z&sort=committer-date&order=desc&per_page=1"
+		hubotIssues    = "search/issues?q=author%3Ahubot+org%3Aexample+created%3A%3E%3D2026-07-01T00%3A00%3A00Z&sort=created&order=desc&per_page=1"
+	)
+	client := &routeRESTClient{routes: map[string]string{
+		octocatCommits: `{"items":[{"html_url":"https://github.com/example/widgets/commit/abc","repository":{"name":"widgets"},"commit":{"committer":{"date":"2026-07-02T00:00:00Z"}}}]}`,
+		hubotCommits:   `{"items":[]}`,
+		hubotIssues:    `{"items":[{"html_url":"https://github.com/example/widgets/pull/2","repository_url":"https://api.github.com/repos/example/widgets","created_at":"2026-07-03T00:00:00Z","pull_request":{}}]}`,
+	}}
+	userList := users.Users{{Login: "octocat"}, {Login: "hubot"}}
+
+	checker := NewActivityChecker(1)
+	err := checker.CheckSearchActivity(context.Background(), userList, "example", "2026-07-01T00:00:00Z", client, []string{"commits", "issues", "pull-requests"})
+	if err != nil {
+		t.Fatalf("CheckSearchActivity returned error: %v", err)
+	}
+	if len(client.requests) != 3 {
+		t.Fatalf("requests = %v", client.requests)
+	}
+	if types := userList[0].GetActivityTypes(); len(types) != 1 || types[0] != "commits" {
+		t.Fatalf("octocat activity types = %v", types)
+	}
+	if types := userList[1].GetActivityTypes(); len(types) != 1 || types[0] != "pull-requests" {
+		t.Fatalf("hubot activity types = %v", types)
+	}
+	if got := userList[1].GetLastActivity(); got.Repository != "widgets" || got.URL != "https://github.com/example/widgets/pull/2" {
+		t.Fatalf("hubot evidence = %#v", got)
+	}
+	if checker.EvidenceComplete() {
+		t.Fatal("skipping octocat's issue search should leave the evidence incomplete")
+	}
+}
+
+func TestCheckAuditLogActivityReadsExportAfterScan(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.ndjson")
+	export := `{"@timestamp": 1782950400000, "action": "org.sso_response", "actor": "hubot"}
+{"@timestamp": 1780000000000, "action": "git.clone", "actor": "mona", "repo": "example/widgets"}
+{"@timestamp": 1782950400000, "action": "git.clone", "actor": "MONALISA"}
+`
+	if err := os.WriteFile(path, []byte(export), 0o600); err != nil {
+		t.Fatalf("write export: %v", err)
+	}
+	userList := users.Users{{Login: "octocat"}, {Login: "hubot"}, {Login: "mona"}, {Login: "monalisa"}}
+
+	checker := NewActivityChecker(1)
+	checker.indexUsers(userList)
+	checker.markUserActive("octocat", "commits", users.Evidence{})
+	err := checker.CheckAuditLogActivity(context.Background(), userList, "example", "2026-07-01T00:00:00Z", &countingRESTClient{}, path)
+	if err != nil {
+		t.Fatalf("CheckAuditLogActivity returned error: %v", err)
+	}
+	if !checker.activeUsers["octocat"] || !checker.activeUsers["hubot"] || checker.activeUsers["mona"] || !checker.activeUsers["monalisa"] {
+		t.Fatalf("active users = %v", checker.activeUsers)
+	}
+	if types := userList[1].GetActivityTypes(); len(types) != 1 || types[0] != "audit-log" {
+		t.Fatalf("hubot activity types = %v", types)
+	}
+	if got := userList[1].GetLastActivity().URL; got != "https://github.com/organizations/example/settings/audit-log?q=actor%3Ahubot" {
+		t.Fatalf("hubot evidence URL = %q", got)
+	}
+}
+
+func TestCheckAuditLogActivityQueriesEachUser(t *testing.T) {
+	path := func(login string) string {
+		return "orgs/example/audit-log?phrase=actor%3A" + login + "+created%3A%3E%3D2026-07-01&include=all&order=desc&per_page=1"
+	}
+	client := &routeRESTClient{routes: map[string]string{
+		path("Octocat"): `[{"@timestamp":1782950400000,"action":"org.sso_response","actor":"octocat"}]`,
+		path("hubot"):   `[]`,
+		path("mona"):    `[{"@timestamp":1782950400000,"action":"git.clone","actor":"Mona","repo":"example/widgets"}]`,
+	}}
+	userList := users.Users{{Login: "Octocat"}, {Login: "hubot"}, {Login: "mona"}}
+
+	checker := NewActivityChecker(3)
+	err := checker.CheckAuditLogActivity(context.Background(), userList, "example", "2026-07-01T00:00:00Z", client, "")
+	if err != nil {
+		t.Fatalf("CheckAuditLogActivity returned error: %v", err)
+	}
+	if len(client.requests) != 3 {
+		t.Fatalf("requests = %v", client.requests)
+	}
+	if !userList[0].IsActive() || userList[1].IsActive() || !userList[2].IsActive() {
+		t.Fatalf("active = %v/%v/%v", userList[0].IsActive(), userList[1].IsActive(), userList[2].IsActive())
+	}
+	if got := userList[2].GetLastActivity().Repository; got != "widgets" {
+		t.Fatalf("mona evidence repository = %q", got)
+	}
+}
+
+func TestCheckAuditLogActivityStopsOnError(t *testing.T) {
+	path := "orgs/example/audit-log?phrase=actor%3Aoctocat+created%3A%3E%3D2026-07-01&include=all&order=desc&per_page=1"
+	client := &routeRESTClient{errs: map[string]error{path: errors.New("forbidden")}}
+
+	err := NewActivityChecker(1).CheckAuditLogActivity(context.Background(), users.Users{{Login: "octocat"}, {Login: "hubot"}}, "example", "2026-07-01T00:00:00Z", client, "")
+	if err == nil || !strings.Contains(err.Error(), "forbidden") {
+		t.Fatalf("error = %v", err)
+	}
+	if len(client.requests) != 1 {
+		t.Fatalf("requests = %v, want the remaining users skipped", client.requests)
+	}
+}
+
+func TestCheckCopilotActivityMarksRecentSeats(t *testing.T) {
+	client := &routeRESTClient{routes: map[string]string{
+		"orgs/example/copilot/billing/seats?per_page=100": `{"total_seats":3,"seats":[
+			{"assignee":{"login":"octocat"},"last_activity_at":"2026-07-05T00:00:00Z","last_activity_editor":"vscode"},
+			{"assignee":{"login":"hubot"},"last_activity_at":"2026-06-05T00:00:00Z","last_activity_editor":"jetbrains"},
+			{"assignee":{"login":"outsider"},"last_activity_at":"2026-07-05T00:00:00Z"}
+		]}`,
+	}}
+	userList := users.Users{{Login: "octocat"}, {Login: "hubot"}, {Login: "mona"}}
+
+	checker := NewActivityChecker(1)
+	checker.UseHost("github.example.com")
+	if err := checker.CheckCopilotActivity(context.Background(), userList, "example", "2026-07-01T00:00:00Z", client); err != nil {
+		t.Fatalf("CheckCopilotActivity returned error: %v", err)
+	}
+	if !checker.activeUsers["octocat"] || checker.activeUsers["hubot"] || checker.activeUsers["mona"] {
+		t.Fatalf("active users = %v", checker.activeUsers)
+	}
+	if types := userList[0].GetActivityTypes(); len(types) != 1 || types[0] != "copilot" {
+		t.Fatalf("octocat activity types = %v", types)
+	}
+	if got := userList[0].GetLastActivity().URL; got != "https://github.example.com/organizations/example/settings/copilot/seat_management" {
+		t.Fatalf("octocat evidence URL = %q", got)
+	}
+	if seat := userList[1].GetCopilotSeat(); !seat.Assigned || seat.LastActivityEditor != "jetbrains" {
+		t.Fatalf("hubot seat = %#v", seat)
+	}
+	if seat := userList[2].GetCopilotSeat(); !seat.Checked || seat.Assigned {
+		t.Fatalf("mona seat = %#v", seat)
+	}
+}
+
+func TestCheckActivityPrefetchesRepositoryListings(t *testing.T) {
+	date := "2026-07-01T00:00:00Z"
+	first := "repos/example/widgets/commits?per_page=100&since=" + date
+	page := func(number int) string {
+		return fmt.Sprintf("repos/example/widgets/commits?page=%d&per_page=100&since=%s", number, date)
+	}
+	// Page 2 has no next link, so page 3 is only requested when the scan
+	// prefetches the pages named by the first page's last link.
+	client := &routeRESTClient{
+		routes: map[string]string{
+			first:   `[{"author":{"login":"hubot"}}]`,
+			page(2): `[{"author":{"login":"hubot"}}]`,
+			page(3): `[{"author":{"login":"octocat"}}]`,
+		},
+		links: map[string]string{first: page(2)},
+		lasts: map[string]string{first: page(3)},
+	}
+
+	checker := NewActivityChecker(1)
+	err := checker.CheckActivity(
+		context.Background(),
+		users.Users{{Login: "octocat"}},
+		"example",
+		repository.Repositories{{Name: "widgets", Size: 1}},
+		date,
+		client,
+		nil,
+		[]string{"commits"},
+	)
+	if err != nil {
+		t.Fatalf("CheckActivity returned error: %v", err)
+	}
+	if !checker.activeUsers["octocat"] {
+		t.Fatalf("requests = %v, want page 3 prefetched and octocat active", client.requests)
+	}
+}
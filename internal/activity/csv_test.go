@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ssulei7/gh-dormant-users/internal/users"
 )
@@ -16,11 +17,15 @@ func TestGenerateUserReportCSV(t *testing.T) {
 		{Login: "inactive", Email: "inactive@example.com"},
 		{Login: "active", Email: "active@example.com"},
 	}
-	userList[1].MarkActiveWithType("issues")
+	userList[1].RecordActivity("issues", users.Evidence{
+		At:         time.Date(2026, 7, 4, 12, 30, 0, 0, time.UTC),
+		Repository: "widgets",
+		URL:        "https://github.com/example/widgets/issues/1",
+	})
 	userList[1].AddActivityType("commits")
 
 	path := filepath.Join(t.TempDir(), "report.csv")
-	if err := GenerateUserReportCSV(userList, path); err != nil {
+	if err := GenerateUserReportCSV(userList, path, false, true); err != nil {
 		t.Fatalf("GenerateUserReportCSV returned error: %v", err)
 	}
 
@@ -37,10 +42,10 @@ func TestGenerateUserReportCSV(t *testing.T) {
 	if len(records) != 3 {
 		t.Fatalf("record count = %d, want 3", len(records))
 	}
-	if got := strings.Join(records[0], ","); got != "Username,Email,Active,ActivityTypes" {
+	if got := strings.Join(records[0], ","); got != "Username,Email,Active,ActivityTypes,LastActiveAt,LastActiveRepo,EvidenceURL,CopilotSeat,CopilotLastActivityAt,CopilotLastActivityEditor,EvidenceComplete,Partial" {
 		t.Fatalf("header = %q", got)
 	}
-	if got := strings.Join(records[1], ","); got != "inactive,inactive@example.com,false,none" {
+	if got := strings.Join(records[1], ","); got != "inactive,inactive@example.com,false,none,,,,,,,false,true" {
 		t.Fatalf("inactive row = %q", got)
 	}
 	if records[2][0] != "active" || records[2][1] != "active@example.com" || records[2][2] != "true" {
@@ -51,12 +56,85 @@ func TestGenerateUserReportCSV(t *testing.T) {
 	if strings.Join(activityTypes, ",") != "commits,issues" {
 		t.Fatalf("activity types = %v", activityTypes)
 	}
+	if got := strings.Join(records[2][4:7], ","); got != "2026-07-04T12:30:00Z,widgets,https://github.com/example/widgets/issues/1" {
+		t.Fatalf("evidence columns = %q", got)
+	}
 }
 
 func TestGenerateUserReportCSVReturnsCreateError(t *testing.T) {
 	path := filepath.Join(t.TempDir(), "missing", "report.csv")
-	err := GenerateUserReportCSV(users.Users{{Login: "octocat"}}, path)
+	err := GenerateUserReportCSV(users.Users{{Login: "octocat"}}, path, true, false)
 	if err == nil {
 		t.Fatal("GenerateUserReportCSV returned nil error")
 	}
 }
+
+func TestGenerateUserReportCSVIncludesCopilotSeats(t *testing.T) {
+	userList := users.Users{{Login: "seated"}, {Login: "unseated"}, {Login: "unchecked"}}
+	userList[0].SetCopilotSeat(users.CopilotSeat{
+		Checked:            true,
+		Assigned:           true,
+		LastActivityAt:     time.Date(2026, 7, 2, 8, 0, 0, 0, time.UTC),
+		LastActivityEditor: "vscode/1.90.0",
+	})
+	userList[1].SetCopilotSeat(users.CopilotSeat{Checked: true})
+
+	path := filepath.Join(t.TempDir(), "report.csv")
+	if err := GenerateUserReportCSV(userList, path, true, false); err != nil {
+		t.Fatalf("GenerateUserReportCSV returned error: %v", err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open report: %v", err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+
+	want := []string{
+		"true,2026-07-02T08:00:00Z,vscode/1.90.0,true,false",
+		"false,,,true,false",
+		",,,true,false",
+	}
+	for index, expected := range want {
+		if got := strings.Join(records[index+1][7:], ","); got != expected {
+			t.Fatalf("%s seat and completeness columns = %q, want %q", records[index+1][0], got, expected)
+		}
+	}
+}
+
+func TestGenerateUncoveredRepositoriesCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "uncovered.csv")
+	uncovered := []UncoveredRepository{
+		{Name: "widgets", ActivityTypes: []string{"commits", "issues"}},
+		{Name: "docs", ActivityTypes: []string{"discussions"}},
+	}
+	if err := GenerateUncoveredRepositoriesCSV(uncovered, path); err != nil {
+		t.Fatalf("GenerateUncoveredRepositoriesCSV returned error: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open report: %v", err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	want := [][]string{
+		{"Repository", "MissingActivityTypes"},
+		{"widgets", "commits,issues"},
+		{"docs", "discussions"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("records = %v", records)
+	}
+	for i := range want {
+		if strings.Join(records[i], "|") != strings.Join(want[i], "|") {
+			t.Fatalf("record %d = %v, want %v", i, records[i], want[i])
+		}
+	}
+}
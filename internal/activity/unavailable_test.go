@@ -1,6 +1,7 @@
 package activity
 
 import (
+	"context"
 	"net/http"
 	"testing"
 	"time"
@@ -16,11 +17,13 @@ func TestCheckActivityContinuesWhenRepositoryEndpointIsUnavailable(t *testing.T)
 	}
 	checker := NewActivityChecker()
 	err := checker.CheckActivity(
+		context.Background(),
 		users.Users{{Login: "octocat"}},
 		"example",
 		repository.Repositories{{Name: "removed-repository", Size: 1}},
 		time.Now().UTC().Format(time.RFC3339),
 		client,
+		nil,
 		[]string{"issue-comments"},
 	)
 	if err != nil {
@@ -6,6 +6,8 @@ import (
 	"os"
 	"sort"
 	"strings"
+
+	"github.com/ssulei7/gh-dormant-users/internal/report"
 )
 
 // CSVStats holds pre-aggregated statistics from a dormant users CSV
@@ -28,8 +30,17 @@ type UserSummary struct {
 	ActivityTypes string
 }
 
-// ParseCSVStats reads a CSV file and returns aggregated statistics
+// ParseCSVStats reads a CSV report, or a JSON or NDJSON report, and returns
+// aggregated statistics
 func ParseCSVStats(csvPath string) (*CSVStats, error) {
+	structured, err := report.IsStructured(csvPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV file: %w", err)
+	}
+	if structured {
+		return parseReportStats(csvPath)
+	}
+
 	file, err := os.Open(csvPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open CSV file: %w", err)
@@ -61,11 +72,7 @@ func ParseCSVStats(csvPath string) (*CSVStats, error) {
 		}
 	}
 
-	stats := &CSVStats{
-		ActivityCounts:  make(map[string]int),
-		TopActiveUsers:  make([]UserSummary, 0, 10),
-		TopDormantUsers: make([]UserSummary, 0, 10),
-	}
+	stats := newCSVStats()
 
 	// Process data rows
 	for _, row := range records[1:] {
@@ -73,8 +80,6 @@ func ParseCSVStats(csvPath string) (*CSVStats, error) {
 			continue
 		}
 
-		stats.TotalUsers++
-
 		username := row[colIndex["username"]]
 		activeStr := strings.ToLower(strings.TrimSpace(row[colIndex["active"]]))
 		activityTypes := ""
@@ -85,52 +90,88 @@ func ParseCSVStats(csvPath string) (*CSVStats, error) {
 		hasEmail := false
 		if idx, ok := colIndex["email"]; ok && len(row) > idx && row[idx] != "" {
 			hasEmail = true
-			stats.UsersWithEmail++
 		}
 
-		isActive := activeStr == "true"
-		if isActive {
-			stats.ActiveUsers++
-
-			// Count activity types
-			if activityTypes != "" && activityTypes != "none" {
-				for _, activity := range strings.Split(activityTypes, ",") {
-					activity = strings.TrimSpace(activity)
-					if activity != "" {
-						stats.ActivityCounts[activity]++
-					}
+		stats.add(username, hasEmail, activeStr == "true", activityTypes)
+	}
+
+	stats.finish()
+	return stats, nil
+}
+
+// parseReportStats aggregates a JSON or NDJSON report
+func parseReportStats(path string) (*CSVStats, error) {
+	parsed, err := report.Read(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse report file: %w", err)
+	}
+	stats := newCSVStats()
+	for _, user := range parsed.Users {
+		activityTypes := "none"
+		if user.Active {
+			activityTypes = strings.Join(user.ActivityTypes, ",")
+		}
+		stats.add(user.Login, user.Email != "", user.Active, activityTypes)
+	}
+	stats.finish()
+	return stats, nil
+}
+
+func newCSVStats() *CSVStats {
+	return &CSVStats{
+		ActivityCounts:  make(map[string]int),
+		TopActiveUsers:  make([]UserSummary, 0, 10),
+		TopDormantUsers: make([]UserSummary, 0, 10),
+	}
+}
+
+// add counts one user, with activity types joined by commas as in the CSV
+func (stats *CSVStats) add(username string, hasEmail bool, isActive bool, activityTypes string) {
+	stats.TotalUsers++
+	if hasEmail {
+		stats.UsersWithEmail++
+	}
+	if isActive {
+		stats.ActiveUsers++
+
+		// Count activity types
+		if activityTypes != "" && activityTypes != "none" {
+			for _, activity := range strings.Split(activityTypes, ",") {
+				activity = strings.TrimSpace(activity)
+				if activity != "" {
+					stats.ActivityCounts[activity]++
 				}
 			}
+		}
 
-			// Collect sample of active users (max 10)
-			if len(stats.TopActiveUsers) < 10 {
-				stats.TopActiveUsers = append(stats.TopActiveUsers, UserSummary{
-					Username:      username,
-					HasEmail:      hasEmail,
-					Active:        true,
-					ActivityTypes: activityTypes,
-				})
-			}
-		} else {
-			stats.DormantUsers++
-
-			// Collect sample of dormant users (max 10)
-			if len(stats.TopDormantUsers) < 10 {
-				stats.TopDormantUsers = append(stats.TopDormantUsers, UserSummary{
-					Username:      username,
-					HasEmail:      hasEmail,
-					Active:        false,
-					ActivityTypes: activityTypes,
-				})
-			}
+		// Collect sample of active users (max 10)
+		if len(stats.TopActiveUsers) < 10 {
+			stats.TopActiveUsers = append(stats.TopActiveUsers, UserSummary{
+				Username:      username,
+				HasEmail:      hasEmail,
+				Active:        true,
+				ActivityTypes: activityTypes,
+			})
+		}
+	} else {
+		stats.DormantUsers++
+
+		// Collect sample of dormant users (max 10)
+		if len(stats.TopDormantUsers) < 10 {
+			stats.TopDormantUsers = append(stats.TopDormantUsers, UserSummary{
+				Username:      username,
+				HasEmail:      hasEmail,
+				Active:        false,
+				ActivityTypes: activityTypes,
+			})
 		}
 	}
+}
 
+func (stats *CSVStats) finish() {
 	if stats.TotalUsers > 0 {
 		stats.DormantPercent = float64(stats.DormantUsers) / float64(stats.TotalUsers) * 100
 	}
-
-	return stats, nil
 }
 
 // FormatForPrompt formats the stats as a concise string for the AI prompt
@@ -241,3 +241,37 @@ user2,,true,commits`
 		t.Errorf("ActivityCounts[commits] = %d, want 1", stats.ActivityCounts["commits"])
 	}
 }
+
+func TestParseCSVStats_StructuredReports(t *testing.T) {
+	document := `{
+  "schema_version": 1,
+  "metadata": {"organizations": ["example"], "activity_types": ["commits", "issues"]},
+  "users": [
+    {"login": "user1", "email": "user1@test.com", "active": true, "activity_types": ["commits", "issues"]},
+    {"login": "user2", "active": false, "activity_types": []}
+  ]
+}`
+	lines := `{"schema_version":1,"metadata":{"organizations":["example"]}}
+{"login":"user1","email":"user1@test.com","active":true,"activity_types":["commits","issues"]}
+{"login":"user2","active":false,"activity_types":[]}
+`
+	for name, content := range map[string]string{"report.json": document, "report.ndjson": lines} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create report: %v", err)
+		}
+		stats, err := ParseCSVStats(path)
+		if err != nil {
+			t.Fatalf("ParseCSVStats(%s) returned error: %v", name, err)
+		}
+		if stats.TotalUsers != 2 || stats.ActiveUsers != 1 || stats.UsersWithEmail != 1 || stats.DormantPercent != 50 {
+			t.Errorf("%s stats = %+v", name, stats)
+		}
+		if stats.ActivityCounts["issues"] != 1 || stats.TopActiveUsers[0].ActivityTypes != "commits,issues" {
+			t.Errorf("%s activity = %v, %+v", name, stats.ActivityCounts, stats.TopActiveUsers)
+		}
+		if stats.TopDormantUsers[0].ActivityTypes != "none" {
+			t.Errorf("%s dormant sample = %+v", name, stats.TopDormantUsers)
+		}
+	}
+}
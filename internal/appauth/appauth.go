@@ -0,0 +1,189 @@
+package appauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ssulei7/gh-dormant-users/internal/githubapi"
+)
+
+const (
+	// jwtLifetime stays under GitHub's ten minute limit, allowing for clock
+	// drift between this machine and GitHub.
+	jwtLifetime = 9 * time.Minute
+	jwtBackdate = time.Minute
+	// refreshMargin is how long before expiry an installation token is
+	// replaced, so requests that are retried or waiting never carry an
+	// expired token.
+	refreshMargin = 5 * time.Minute
+)
+
+// Config identifies a GitHub App installation
+type Config struct {
+	AppID          int64
+	InstallationID int64
+	PrivateKey     []byte
+	// BaseURL is the REST API root, such as https://api.github.com/ or
+	// https://github.example.com/api/v3/.
+	BaseURL string
+	// Transport sends token exchange requests. They are not rate limited, so
+	// they do not go through the Coordinator.
+	Transport http.RoundTripper
+	Now       func() time.Time
+}
+
+// Transport authenticates requests as a GitHub App installation. It sits in
+// front of the Coordinator, signing a JWT to exchange for an installation
+// token and refreshing the token before it expires.
+type Transport struct {
+	next       http.RoundTripper
+	exchange   http.RoundTripper
+	key        *rsa.PrivateKey
+	appID      int64
+	install    int64
+	tokenURL   string
+	credential string
+	now        func() time.Time
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// NewTransport returns a Transport that sends authenticated requests to next
+func NewTransport(config Config, next http.RoundTripper) (*Transport, error) {
+	if config.AppID <= 0 || config.InstallationID <= 0 {
+		return nil, errors.New("app ID and installation ID are required for GitHub App authentication")
+	}
+	key, err := parsePrivateKey(config.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	if config.Transport == nil {
+		config.Transport = http.DefaultTransport
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	return &Transport{
+		next:       next,
+		exchange:   config.Transport,
+		key:        key,
+		appID:      config.AppID,
+		install:    config.InstallationID,
+		tokenURL:   fmt.Sprintf("%sapp/installations/%d/access_tokens", config.BaseURL, config.InstallationID),
+		credential: fmt.Sprintf("app-%d-installation-%d", config.AppID, config.InstallationID),
+		now:        config.Now,
+	}, nil
+}
+
+func (t *Transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	token, err := t.installationToken(request.Context())
+	if err != nil {
+		return nil, err
+	}
+	// The installation, not the short-lived token, identifies the rate limit
+	// and the cache namespace.
+	request = request.Clone(githubapi.WithCredential(request.Context(), t.credential))
+	request.Header.Set("Authorization", "token "+token)
+	return t.next.RoundTrip(request)
+}
+
+// installationToken returns the current installation token, exchanging a new
+// JWT for one when it is missing or about to expire.
+func (t *Transport) installationToken(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.token != "" && t.now().Add(refreshMargin).Before(t.expires) {
+		return t.token, nil
+	}
+
+	jwt, err := t.signJWT()
+	if err != nil {
+		return "", err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, t.tokenURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("create installation token request: %w", err)
+	}
+	request.Header.Set("Authorization", "Bearer "+jwt)
+	request.Header.Set("Accept", "application/vnd.github+json")
+	response, err := t.exchange.RoundTrip(request)
+	if err != nil {
+		return "", fmt.Errorf("request installation token: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("request installation token for installation %d: %s", t.install, response.Status)
+	}
+	var result struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("decode installation token: %w", err)
+	}
+	if result.Token == "" {
+		return "", fmt.Errorf("installation token response for installation %d has no token", t.install)
+	}
+	t.token, t.expires = result.Token, result.ExpiresAt
+	return t.token, nil
+}
+
+// signJWT returns an RS256 JWT that authenticates as the app itself
+func (t *Transport) signJWT() (string, error) {
+	now := t.now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-jwtBackdate).Unix(),
+		"exp": now.Add(jwtLifetime).Unix(),
+		"iss": strconv.FormatInt(t.appID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, t.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("sign app JWT: %w", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// parsePrivateKey reads the PEM key GitHub generates for an app, which is
+// PKCS #1, or the same key converted to PKCS #8.
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("app private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse app private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("app private key is not an RSA key")
+	}
+	return key, nil
+}
@@ -0,0 +1,189 @@
+package appauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tokenServer issues installation tokens for app 7, installation 42, and
+// serves an API endpoint that requires the latest token.
+type tokenServer struct {
+	t         *testing.T
+	key       *rsa.PublicKey
+	now       func() time.Time
+	mu        sync.Mutex
+	exchanges int
+	current   string
+}
+
+func (s *tokenServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.URL.Path {
+	case "/app/installations/42/access_tokens":
+		if r.Method != http.MethodPost {
+			s.t.Errorf("token exchange method = %s", r.Method)
+		}
+		s.verifyJWT(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		s.exchanges++
+		s.current = fmt.Sprintf("ghs_%d", s.exchanges)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"token":      s.current,
+			"expires_at": s.now().Add(time.Hour).UTC().Format(time.RFC3339),
+		})
+	case "/orgs/example":
+		if got := r.Header.Get("Authorization"); got != "token "+s.current {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *tokenServer) verifyJWT(jwt string) {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		s.t.Errorf("JWT has %d parts", len(parts))
+		return
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		s.t.Errorf("decode JWT signature: %v", err)
+		return
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(s.key, crypto.SHA256, digest[:], signature); err != nil {
+		s.t.Errorf("JWT signature does not verify: %v", err)
+	}
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims struct {
+		IssuedAt  int64  `json:"iat"`
+		ExpiresAt int64  `json:"exp"`
+		Issuer    string `json:"iss"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		s.t.Errorf("decode JWT claims: %v", err)
+		return
+	}
+	if claims.Issuer != "7" || claims.ExpiresAt-claims.IssuedAt > 600 || claims.IssuedAt > s.now().Unix() {
+		s.t.Errorf("JWT claims = %+v", claims)
+	}
+}
+
+func newTestTransport(t *testing.T, now func() time.Time) (*Transport, *tokenServer, string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	handler := &tokenServer{t: t, key: &key.PublicKey, now: now}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	transport, err := NewTransport(Config{
+		AppID:          7,
+		InstallationID: 42,
+		PrivateKey:     pemKey,
+		BaseURL:        server.URL + "/",
+		Now:            now,
+	}, http.DefaultTransport)
+	if err != nil {
+		t.Fatalf("NewTransport returned error: %v", err)
+	}
+	return transport, handler, server.URL
+}
+
+func get(t *testing.T, transport http.RoundTripper, url string) {
+	t.Helper()
+	request, _ := http.NewRequest(http.MethodGet, url, nil)
+	response, err := transport.RoundTrip(request)
+	if err != nil {
+		t.Fatalf("RoundTrip returned error: %v", err)
+	}
+	_ = response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", response.StatusCode)
+	}
+}
+
+func TestTransportReusesInstallationToken(t *testing.T) {
+	transport, server, url := newTestTransport(t, time.Now)
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			get(t, transport, url+"/orgs/example")
+		}()
+	}
+	wg.Wait()
+	if server.exchanges != 1 {
+		t.Fatalf("token exchanges = %d, want 1", server.exchanges)
+	}
+}
+
+func TestTransportRefreshesTokenBeforeExpiry(t *testing.T) {
+	now := time.Now()
+	var mu sync.Mutex
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	transport, server, url := newTestTransport(t, clock)
+
+	get(t, transport, url+"/orgs/example")
+	mu.Lock()
+	now = now.Add(50 * time.Minute)
+	mu.Unlock()
+	get(t, transport, url+"/orgs/example")
+	if server.exchanges != 1 {
+		t.Fatalf("token exchanges = %d, want the token reused while it is fresh", server.exchanges)
+	}
+
+	mu.Lock()
+	now = now.Add(6 * time.Minute)
+	mu.Unlock()
+	get(t, transport, url+"/orgs/example")
+	if server.exchanges != 2 {
+		t.Fatalf("token exchanges = %d, want a refresh within five minutes of expiry", server.exchanges)
+	}
+}
+
+func TestTransportReportsRejectedExchange(t *testing.T) {
+	transport, _, url := newTestTransport(t, time.Now)
+	transport.tokenURL = url + "/app/installations/404/access_tokens"
+
+	request, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, url+"/orgs/example", nil)
+	if _, err := transport.RoundTrip(request); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestNewTransportValidatesConfig(t *testing.T) {
+	if _, err := NewTransport(Config{AppID: 7, InstallationID: 42, PrivateKey: []byte("not a key")}, http.DefaultTransport); err == nil || !strings.Contains(err.Error(), "PEM") {
+		t.Fatalf("error = %v", err)
+	}
+	if _, err := NewTransport(Config{AppID: 7}, http.DefaultTransport); err == nil || !strings.Contains(err.Error(), "installation ID") {
+		t.Fatalf("error = %v", err)
+	}
+}
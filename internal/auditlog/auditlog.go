@@ -0,0 +1,157 @@
+package auditlog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/pkg/api"
+	"github.com/ssulei7/gh-dormant-users/internal/githubapi"
+)
+
+// ActivityType is recorded for users found in the audit log
+const ActivityType = "audit-log"
+
+// RetentionDays is how far back the audit log API returns events. Exports
+// can reach further back.
+const RetentionDays = 180
+
+// Event is an organization audit log entry. Only the fields needed to
+// attribute activity are decoded.
+type Event struct {
+	Action    string    `json:"action"`
+	Actor     string    `json:"actor"`
+	Repo      string    `json:"repo"`
+	Timestamp Timestamp `json:"@timestamp"`
+	CreatedAt Timestamp `json:"created_at"`
+}
+
+// At returns when the event happened
+func (e Event) At() time.Time {
+	if !e.Timestamp.IsZero() {
+		return e.Timestamp.Time
+	}
+	return e.CreatedAt.Time
+}
+
+// Repository returns the event's repository name without the owner
+func (e Event) Repository() string {
+	_, name, found := strings.Cut(e.Repo, "/")
+	if !found {
+		return e.Repo
+	}
+	return name
+}
+
+// Timestamp decodes audit log times, which the API and exports write as
+// milliseconds since the epoch and some tools write as RFC 3339 strings.
+type Timestamp struct {
+	time.Time
+}
+
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if milliseconds, err := strconv.ParseInt(string(data), 10, 64); err == nil {
+		t.Time = time.UnixMilli(milliseconds).UTC()
+		return nil
+	}
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("decode audit log timestamp %s: %w", data, err)
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return fmt.Errorf("decode audit log timestamp %s: %w", data, err)
+	}
+	t.Time = parsed.UTC()
+	return nil
+}
+
+// GetLatestActorEvent returns the newest event in the organization audit log
+// performed by login since the date, or nil when there is none. Git events
+// are included where the audit log retains them.
+func GetLatestActorEvent(ctx context.Context, organization string, login string, since time.Time, client api.RESTClient) (*Event, error) {
+	phrase := fmt.Sprintf("actor:%s created:>=%s", login, since.UTC().Format("2006-01-02"))
+	path := fmt.Sprintf("orgs/%s/audit-log?phrase=%s&include=all&order=desc&per_page=1", organization, url.QueryEscape(phrase))
+	var events []Event
+	if err := client.DoWithContext(ctx, http.MethodGet, path, nil, &events); err != nil {
+		return nil, fmt.Errorf("fetch audit log events for %s: %w", login, err)
+	}
+	for _, event := range events {
+		if strings.EqualFold(event.Actor, login) && !event.At().Before(since) {
+			return &event, nil
+		}
+	}
+	return nil, nil
+}
+
+// ReadFile reads an exported audit log. Both JSON array exports and NDJSON
+// (one event per line) exports are accepted.
+func ReadFile(path string) ([]Event, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read audit log export: %w", err)
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, nil
+	}
+	if trimmed[0] == '[' {
+		var events []Event
+		if err := json.Unmarshal(trimmed, &events); err != nil {
+			return nil, fmt.Errorf("parse audit log export %s: %w", path, err)
+		}
+		return events, nil
+	}
+
+	var events []Event
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal(text, &event); err != nil {
+			return nil, fmt.Errorf("parse audit log export %s line %d: %w", path, line, err)
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read audit log export %s: %w", path, err)
+	}
+	return events, nil
+}
+
+// LatestByActor returns each actor's newest event on or after since, keyed
+// by lowercase actor, as logins are not case-sensitive.
+func LatestByActor(events []Event, since time.Time) map[string]Event {
+	latest := make(map[string]Event)
+	for _, event := range events {
+		if event.Actor == "" || event.At().Before(since) {
+			continue
+		}
+		actor := strings.ToLower(event.Actor)
+		if current, ok := latest[actor]; !ok || event.At().After(current.At()) {
+			latest[actor] = event
+		}
+	}
+	return latest
+}
+
+// EvidenceURL links to the organization audit log on host filtered to the actor
+func EvidenceURL(host string, organization string, login string) string {
+	return fmt.Sprintf("%sorganizations/%s/settings/audit-log?q=%s", githubapi.WebURL(host), organization, url.QueryEscape("actor:"+login))
+}
@@ -0,0 +1,172 @@
+package auditlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type mockRESTClient struct {
+	path string
+	body string
+	err  error
+}
+
+func (m *mockRESTClient) Request(_ string, path string, _ io.Reader) (*http.Response, error) {
+	m.path = path
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(bytes.NewBufferString(m.body)),
+	}, nil
+}
+
+func (m *mockRESTClient) RequestWithContext(_ context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	return m.Request(method, path, body)
+}
+
+func (m *mockRESTClient) Do(method, path string, body io.Reader, result interface{}) error {
+	response, err := m.Request(method, path, body)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	return json.NewDecoder(response.Body).Decode(result)
+}
+
+func (m *mockRESTClient) DoWithContext(_ context.Context, method, path string, body io.Reader, result interface{}) error {
+	return m.Do(method, path, body, result)
+}
+
+func (m *mockRESTClient) Delete(path string, result interface{}) error {
+	return m.Do(http.MethodDelete, path, nil, result)
+}
+
+func (m *mockRESTClient) Get(path string, result interface{}) error {
+	return m.Do(http.MethodGet, path, nil, result)
+}
+
+func (m *mockRESTClient) Patch(path string, body io.Reader, result interface{}) error {
+	return m.Do(http.MethodPatch, path, body, result)
+}
+
+func (m *mockRESTClient) Post(path string, body io.Reader, result interface{}) error {
+	return m.Do(http.MethodPost, path, body, result)
+}
+
+func (m *mockRESTClient) Put(path string, body io.Reader, result interface{}) error {
+	return m.Do(http.MethodPut, path, body, result)
+}
+
+var since = time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+
+func TestReadFileAcceptsJSONAndNDJSON(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"audit-log.json", "audit-log.ndjson"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			events, err := ReadFile(filepath.Join("testdata", name))
+			if err != nil {
+				t.Fatalf("ReadFile returned error: %v", err)
+			}
+			if len(events) != 4 {
+				t.Fatalf("events = %d, want 4", len(events))
+			}
+			latest := LatestByActor(events, since)
+			if len(latest) != 1 {
+				t.Fatalf("latest = %#v, want only octocat", latest)
+			}
+			event := latest["octocat"]
+			if event.Action != "org.sso_response" || !event.At().Equal(time.Date(2025, 7, 9, 0, 0, 0, 0, time.UTC)) {
+				t.Fatalf("octocat event = %#v", event)
+			}
+		})
+	}
+}
+
+func TestReadFileReportsMalformedLines(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "audit.ndjson")
+	if err := os.WriteFile(path, []byte("{\"actor\":\"octocat\"}\nnot json\n"), 0o600); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	if _, err := ReadFile(path); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestEventAcceptsRFC3339Timestamps(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "audit.json")
+	if err := os.WriteFile(path, []byte(`[{"actor":"octocat","repo":"example/widgets","created_at":"2025-07-03T12:00:00+02:00"}]`), 0o600); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	events, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile returned error: %v", err)
+	}
+	if !events[0].At().Equal(time.Date(2025, 7, 3, 10, 0, 0, 0, time.UTC)) || events[0].Repository() != "widgets" {
+		t.Fatalf("event = %#v", events[0])
+	}
+}
+
+func TestGetLatestActorEvent(t *testing.T) {
+	t.Parallel()
+
+	client := &mockRESTClient{body: `[{"@timestamp":1751932800000,"action":"git.clone","actor":"octocat","repo":"example/widgets"}]`}
+	event, err := GetLatestActorEvent(context.Background(), "example", "octocat", since, client)
+	if err != nil {
+		t.Fatalf("GetLatestActorEvent returned error: %v", err)
+	}
+	if client.path != "orgs/example/audit-log?phrase=actor%3Aoctocat+created%3A%3E%3D2025-07-01&include=all&order=desc&per_page=1" {
+		t.Fatalf("request path = %q", client.path)
+	}
+	if event == nil || event.Action != "git.clone" {
+		t.Fatalf("event = %#v", event)
+	}
+
+	client = &mockRESTClient{body: `[{"@timestamp":1751932800000,"action":"git.clone","actor":"OctoCat"}]`}
+	if event, err := GetLatestActorEvent(context.Background(), "example", "octocat", since, client); err != nil || event == nil {
+		t.Fatalf("actor in other case: event = %#v, err = %v", event, err)
+	}
+
+	event, err = GetLatestActorEvent(context.Background(), "example", "octocat", since, &mockRESTClient{body: `[]`})
+	if err != nil || event != nil {
+		t.Fatalf("empty log: event = %#v, err = %v", event, err)
+	}
+}
+
+func TestGetLatestActorEventWrapsErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := GetLatestActorEvent(context.Background(), "example", "octocat", since, &mockRESTClient{err: errors.New("boom")})
+	if err == nil || !strings.Contains(err.Error(), "fetch audit log events for octocat") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestEvidenceURL(t *testing.T) {
+	t.Parallel()
+
+	if got := EvidenceURL("", "example", "octocat"); got != "https://github.com/organizations/example/settings/audit-log?q=actor%3Aoctocat" {
+		t.Fatalf("EvidenceURL = %q", got)
+	}
+	if got := EvidenceURL("github.example.com", "example", "octocat"); got != "https://github.example.com/organizations/example/settings/audit-log?q=actor%3Aoctocat" {
+		t.Fatalf("EvidenceURL on a server = %q", got)
+	}
+}
@@ -0,0 +1,271 @@
+package checkpoint
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const version = 2
+
+// UserActivity is the activity found for one user so far
+type UserActivity struct {
+	ActivityTypes []string  `json:"activity_types"`
+	LastActiveAt  time.Time `json:"last_active_at,omitzero"`
+	Repository    string    `json:"repository,omitempty"`
+	URL           string    `json:"url,omitempty"`
+}
+
+// header is the first line of a checkpoint and identifies the run
+type header struct {
+	Version       int      `json:"version"`
+	Organization  string   `json:"organization"`
+	Date          string   `json:"date"`
+	ActivityTypes []string `json:"activity_types"`
+}
+
+// record is appended for every completed repository, and once discussions
+// are done, with the activity of the users that changed since the previous
+// record.
+type record struct {
+	Repository  string                  `json:"repository,omitempty"`
+	Discussions bool                    `json:"discussions,omitempty"`
+	Users       map[string]UserActivity `json:"users,omitempty"`
+}
+
+// File records the progress of a repository scan so that an interrupted run
+// can be resumed. The file is a journal: a header line followed by one
+// record per update, each appended before the update returns. Records only
+// hold the users that changed, and reading them back merges each user's
+// activity, so what is recorded for a user only ever grows.
+type File struct {
+	path        string
+	mu          sync.Mutex
+	header      header
+	users       map[string]UserActivity
+	completed   map[string]bool
+	discussions bool
+}
+
+// Create starts a new checkpoint at path, replacing any existing file
+func Create(path string, organization string, date string, activityTypes []string) (*File, error) {
+	file := &File{
+		path: path,
+		header: header{
+			Version:       version,
+			Organization:  organization,
+			Date:          date,
+			ActivityTypes: normalizeTypes(activityTypes),
+		},
+		users:     make(map[string]UserActivity),
+		completed: make(map[string]bool),
+	}
+	if err := file.writeHeader(); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// Resume loads the checkpoint at path and checks that it was written for the
+// same organization, date and activity types. A record cut short by a crash
+// is dropped.
+func Resume(path string, organization string, date string, activityTypes []string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read checkpoint: %w", err)
+	}
+	line, rest, _ := bytes.Cut(data, []byte("\n"))
+	var loaded header
+	if err := json.Unmarshal(line, &loaded); err != nil {
+		return nil, fmt.Errorf("parse checkpoint %s: %w", path, err)
+	}
+	if loaded.Version != version {
+		return nil, fmt.Errorf("checkpoint %s has unsupported version %d", path, loaded.Version)
+	}
+
+	var mismatches []string
+	if loaded.Organization != organization {
+		mismatches = append(mismatches, fmt.Sprintf("organization %q (this run: %q)", loaded.Organization, organization))
+	}
+	if loaded.Date != date {
+		mismatches = append(mismatches, fmt.Sprintf("date %s (this run: %s)", loaded.Date, date))
+	}
+	if types := normalizeTypes(activityTypes); !slices.Equal(loaded.ActivityTypes, types) {
+		mismatches = append(mismatches, fmt.Sprintf("activity types %s (this run: %s)", strings.Join(loaded.ActivityTypes, ","), strings.Join(types, ",")))
+	}
+	if len(mismatches) > 0 {
+		return nil, fmt.Errorf("checkpoint %s does not match this run: it was written for %s", path, strings.Join(mismatches, "; "))
+	}
+
+	file := &File{path: path, header: loaded, users: make(map[string]UserActivity), completed: make(map[string]bool)}
+	valid := len(line) + 1
+	for number := 2; len(rest) > 0; number++ {
+		line, rest, _ = bytes.Cut(rest, []byte("\n"))
+		var entry record
+		if err := json.Unmarshal(line, &entry); err != nil {
+			if len(rest) == 0 {
+				// The last record was being written when the run stopped.
+				break
+			}
+			return nil, fmt.Errorf("parse checkpoint %s line %d: %w", path, number, err)
+		}
+		file.apply(entry)
+		valid += len(line) + 1
+	}
+	if valid < len(data) {
+		if err := os.Truncate(path, int64(valid)); err != nil {
+			return nil, fmt.Errorf("repair checkpoint: %w", err)
+		}
+	}
+	return file, nil
+}
+
+// Path returns where the checkpoint is written
+func (f *File) Path() string {
+	return f.path
+}
+
+// Users returns the activity recorded by earlier runs
+func (f *File) Users() map[string]UserActivity {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	users := make(map[string]UserActivity, len(f.users))
+	for login, activity := range f.users {
+		users[login] = activity
+	}
+	return users
+}
+
+// CompletedRepositories returns the number of repositories already scanned
+func (f *File) CompletedRepositories() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.completed)
+}
+
+// RepositoryDone reports whether a repository was fully scanned
+func (f *File) RepositoryDone(name string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.completed[name]
+}
+
+// DiscussionsDone reports whether discussions were fully scanned
+func (f *File) DiscussionsDone() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.discussions
+}
+
+// CompleteRepository records a scanned repository together with the activity
+// that changed since the last record. changed is called while the checkpoint
+// is locked, so activity it returns is written before any later record marks
+// another repository complete.
+func (f *File) CompleteRepository(name string, changed func() map[string]UserActivity) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.append(record{Repository: name, Users: changed()})
+}
+
+// CompleteDiscussions records that discussions were scanned, together with
+// the activity that changed since the last record.
+func (f *File) CompleteDiscussions(changed func() map[string]UserActivity) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.append(record{Discussions: true, Users: changed()})
+}
+
+// append writes a record to the journal and applies it
+func (f *File) append(entry record) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode checkpoint: %w", err)
+	}
+	journal, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	if _, err := journal.Write(append(data, '\n')); err != nil {
+		_ = journal.Close()
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	if err := journal.Close(); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	f.apply(entry)
+	return nil
+}
+
+// apply merges a record into the state: activity types are combined and the
+// newest evidence is kept.
+func (f *File) apply(entry record) {
+	if entry.Repository != "" {
+		f.completed[entry.Repository] = true
+	}
+	if entry.Discussions {
+		f.discussions = true
+	}
+	for login, found := range entry.Users {
+		current, ok := f.users[login]
+		if !ok {
+			f.users[login] = found
+			continue
+		}
+		current.ActivityTypes = normalizeTypes(append(slices.Clone(current.ActivityTypes), found.ActivityTypes...))
+		if found.LastActiveAt.After(current.LastActiveAt) {
+			current.LastActiveAt = found.LastActiveAt
+			current.Repository = found.Repository
+			current.URL = found.URL
+		}
+		f.users[login] = current
+	}
+}
+
+// Remove deletes the checkpoint once the report has been written
+func (f *File) Remove() error {
+	if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove checkpoint: %w", err)
+	}
+	return nil
+}
+
+// writeHeader starts the journal atomically, so a crash mid-write leaves any
+// previous file intact.
+func (f *File) writeHeader() error {
+	data, err := json.Marshal(f.header)
+	if err != nil {
+		return fmt.Errorf("encode checkpoint: %w", err)
+	}
+	data = append(data, '\n')
+	temp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	if _, err := temp.Write(data); err != nil {
+		_ = temp.Close()
+		_ = os.Remove(temp.Name())
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		_ = os.Remove(temp.Name())
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	if err := os.Rename(temp.Name(), f.path); err != nil {
+		_ = os.Remove(temp.Name())
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	return nil
+}
+
+func normalizeTypes(activityTypes []string) []string {
+	types := slices.Clone(activityTypes)
+	slices.Sort(types)
+	return slices.Compact(types)
+}
@@ -0,0 +1,194 @@
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const date = "2026-07-01T00:00:00Z"
+
+func changes(users map[string]UserActivity) func() map[string]UserActivity {
+	return func() map[string]UserActivity { return users }
+}
+
+func TestCreateAndResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "example.checkpoint.ndjson")
+	file, err := Create(path, "example", date, []string{"issues", "commits"})
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("checkpoint was not written: %v", err)
+	}
+
+	at := time.Date(2026, 7, 5, 10, 0, 0, 0, time.UTC)
+	found := map[string]UserActivity{
+		"octocat": {ActivityTypes: []string{"commits"}, LastActiveAt: at, Repository: "widgets", URL: "https://github.com/example/widgets/commit/1"},
+	}
+	if err := file.CompleteRepository("widgets", changes(found)); err != nil {
+		t.Fatalf("CompleteRepository returned error: %v", err)
+	}
+	if err := file.CompleteDiscussions(changes(nil)); err != nil {
+		t.Fatalf("CompleteDiscussions returned error: %v", err)
+	}
+
+	// Activity types are compared regardless of order.
+	resumed, err := Resume(path, "example", date, []string{"commits", "issues"})
+	if err != nil {
+		t.Fatalf("Resume returned error: %v", err)
+	}
+	if !resumed.RepositoryDone("widgets") || resumed.RepositoryDone("gadgets") || resumed.CompletedRepositories() != 1 {
+		t.Fatalf("completed repositories were not restored")
+	}
+	if !resumed.DiscussionsDone() {
+		t.Fatal("discussions were not restored")
+	}
+	user := resumed.Users()["octocat"]
+	if !user.LastActiveAt.Equal(at) || user.Repository != "widgets" || strings.Join(user.ActivityTypes, ",") != "commits" {
+		t.Fatalf("user activity = %#v", user)
+	}
+
+	if err := resumed.Remove(); err != nil {
+		t.Fatalf("Remove returned error: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("checkpoint still exists: %v", err)
+	}
+	if err := resumed.Remove(); err != nil {
+		t.Fatalf("second Remove returned error: %v", err)
+	}
+}
+
+func TestResumeRejectsDifferentRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "example.checkpoint.ndjson")
+	if _, err := Create(path, "example", date, []string{"commits"}); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+
+	_, err := Resume(path, "other", "2026-08-01T00:00:00Z", []string{"commits", "issues"})
+	if err == nil {
+		t.Fatal("expected mismatch error")
+	}
+	for _, want := range []string{`organization "example"`, "date 2026-07-01T00:00:00Z", "activity types commits (this run: commits,issues)"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestResumeRejectsUnreadableCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Resume(filepath.Join(dir, "missing.json"), "example", date, nil); err == nil || !strings.Contains(err.Error(), "read checkpoint") {
+		t.Fatalf("missing file error = %v", err)
+	}
+
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Resume(invalid, "example", date, nil); err == nil || !strings.Contains(err.Error(), "parse checkpoint") {
+		t.Fatalf("invalid file error = %v", err)
+	}
+
+	future := filepath.Join(dir, "future.json")
+	if err := os.WriteFile(future, []byte(`{"version":3}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Resume(future, "example", date, nil); err == nil || !strings.Contains(err.Error(), "unsupported version 3") {
+		t.Fatalf("version error = %v", err)
+	}
+}
+
+func TestWriteLeavesNoTemporaryFiles(t *testing.T) {
+	dir := t.TempDir()
+	file, err := Create(filepath.Join(dir, "example.checkpoint.ndjson"), "example", date, []string{"commits"})
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	for _, name := range []string{"a", "b", "a"} {
+		if err := file.CompleteRepository(name, changes(nil)); err != nil {
+			t.Fatalf("CompleteRepository returned error: %v", err)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("directory has %d entries, want only the checkpoint", len(entries))
+	}
+	if file.CompletedRepositories() != 2 {
+		t.Fatalf("completed repositories = %d, want 2", file.CompletedRepositories())
+	}
+}
+
+func TestRecordsMergeEachUsersActivity(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "example.checkpoint.ndjson")
+	file, err := Create(path, "example", date, []string{"commits", "issues"})
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	newer := time.Date(2026, 7, 9, 0, 0, 0, 0, time.UTC)
+	older := time.Date(2026, 7, 2, 0, 0, 0, 0, time.UTC)
+	// Records can arrive in any order; neither may undo the other.
+	if err := file.CompleteRepository("a", changes(map[string]UserActivity{
+		"octocat": {ActivityTypes: []string{"issues"}, LastActiveAt: newer, Repository: "a"},
+	})); err != nil {
+		t.Fatalf("CompleteRepository returned error: %v", err)
+	}
+	if err := file.CompleteRepository("b", changes(map[string]UserActivity{
+		"octocat": {ActivityTypes: []string{"commits"}, LastActiveAt: older, Repository: "b"},
+		"hubot":   {ActivityTypes: []string{"commits"}, LastActiveAt: older, Repository: "b"},
+	})); err != nil {
+		t.Fatalf("CompleteRepository returned error: %v", err)
+	}
+
+	resumed, err := Resume(path, "example", date, []string{"commits", "issues"})
+	if err != nil {
+		t.Fatalf("Resume returned error: %v", err)
+	}
+	octocat := resumed.Users()["octocat"]
+	if strings.Join(octocat.ActivityTypes, ",") != "commits,issues" || !octocat.LastActiveAt.Equal(newer) || octocat.Repository != "a" {
+		t.Fatalf("octocat = %#v", octocat)
+	}
+	if _, ok := resumed.Users()["hubot"]; !ok || resumed.CompletedRepositories() != 2 {
+		t.Fatalf("users = %v, repositories = %d", resumed.Users(), resumed.CompletedRepositories())
+	}
+}
+
+func TestResumeDropsRecordCutShort(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "example.checkpoint.ndjson")
+	file, err := Create(path, "example", date, []string{"commits"})
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if err := file.CompleteRepository("a", changes(nil)); err != nil {
+		t.Fatalf("CompleteRepository returned error: %v", err)
+	}
+	journal, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := journal.WriteString(`{"repository":"b","us`); err != nil {
+		t.Fatal(err)
+	}
+	journal.Close()
+
+	resumed, err := Resume(path, "example", date, []string{"commits"})
+	if err != nil {
+		t.Fatalf("Resume returned error: %v", err)
+	}
+	if !resumed.RepositoryDone("a") || resumed.RepositoryDone("b") {
+		t.Fatal("the partial record was applied")
+	}
+	// Later records follow the last complete one
+	if err := resumed.CompleteRepository("c", changes(nil)); err != nil {
+		t.Fatalf("CompleteRepository returned error: %v", err)
+	}
+	if final, err := Resume(path, "example", date, []string{"commits"}); err != nil || !final.RepositoryDone("c") {
+		t.Fatalf("Resume after repair = %v, %v", final, err)
+	}
+}
@@ -1,7 +1,9 @@
 package commits
 
 import (
+	"context"
 	"fmt"
+	"iter"
 	"strings"
 
 	"github.com/cli/go-gh/pkg/api"
@@ -9,13 +11,18 @@ import (
 )
 
 type Commit struct {
-	Sha    string `json:"sha"`
-	Commit struct {
+	Sha     string `json:"sha"`
+	HTMLURL string `json:"html_url"`
+	Commit  struct {
 		Author struct {
 			Name  string `json:"name"`
 			Email string `json:"email"`
 			Date  string `json:"date"`
 		} `json:"author"`
+		// Committer.Date is what the since filter of the listing compares
+		Committer struct {
+			Date string `json:"date"`
+		} `json:"committer"`
 	} `json:"commit"`
 	Author struct {
 		Login string `json:"login"`
@@ -24,14 +31,20 @@ type Commit struct {
 
 type Commits []Commit
 
-func GetCommitsSinceDate(organization string, repository string, date string, client api.RESTClient) (Commits, error) {
+// CommitPagesSinceDate streams the commits made since the date one page at a time
+func CommitPagesSinceDate(ctx context.Context, organization string, repository string, date string, client api.RESTClient, prefetch bool) iter.Seq2[[]Commit, error] {
 	url := fmt.Sprintf("repos/%s/%s/commits?per_page=100&since=%s", organization, repository, date)
-	commitList, err := githubapi.GetAll[Commit](client, url)
-	if err != nil {
-		if strings.Contains(err.Error(), "Git Repository is empty.") {
-			return nil, nil
+	return func(yield func([]Commit, error) bool) {
+		for page, err := range githubapi.Pages[Commit](ctx, client, url, prefetch) {
+			if err != nil {
+				if !strings.Contains(err.Error(), "Git Repository is empty.") {
+					yield(nil, fmt.Errorf("fetch commits for %s/%s: %w", organization, repository, err))
+				}
+				return
+			}
+			if !yield(page, nil) {
+				return
+			}
 		}
-		return nil, fmt.Errorf("fetch commits for %s/%s: %w", organization, repository, err)
 	}
-	return Commits(commitList), nil
 }
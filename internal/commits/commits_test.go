@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+
+	"github.com/ssulei7/gh-dormant-users/internal/githubapi"
 )
 
 type mockRESTClient struct {
@@ -68,13 +70,13 @@ func (m *mockRESTClient) Put(path string, body io.Reader, result interface{}) er
 
 func (m *mockRESTClient) RESTPrefix() string { return "" }
 
-func TestGetCommitsSinceDate(t *testing.T) {
+func TestCommitPagesSinceDate(t *testing.T) {
 	t.Parallel()
 
 	client := &mockRESTClient{body: `[{"sha":"abc123","author":{"login":"octocat"}}]`}
-	commits, err := GetCommitsSinceDate("example", "widgets", "2026-07-01T00:00:00Z", client)
+	commits, err := githubapi.Collect(CommitPagesSinceDate(context.Background(), "example", "widgets", "2026-07-01T00:00:00Z", client, false))
 	if err != nil {
-		t.Fatalf("GetCommitsSinceDate returned error: %v", err)
+		t.Fatalf("CommitPagesSinceDate returned error: %v", err)
 	}
 	if client.path != "repos/example/widgets/commits?per_page=100&since=2026-07-01T00:00:00Z" {
 		t.Fatalf("request path = %q", client.path)
@@ -84,24 +86,24 @@ func TestGetCommitsSinceDate(t *testing.T) {
 	}
 }
 
-func TestGetCommitsSinceDateEmptyRepository(t *testing.T) {
+func TestCommitPagesSinceDateEmptyRepository(t *testing.T) {
 	t.Parallel()
 
 	client := &mockRESTClient{err: errors.New("Git Repository is empty.")}
-	commits, err := GetCommitsSinceDate("example", "empty", "2026-07-01T00:00:00Z", client)
+	commits, err := githubapi.Collect(CommitPagesSinceDate(context.Background(), "example", "empty", "2026-07-01T00:00:00Z", client, false))
 	if err != nil {
-		t.Fatalf("GetCommitsSinceDate returned error: %v", err)
+		t.Fatalf("CommitPagesSinceDate returned error: %v", err)
 	}
 	if commits != nil {
 		t.Fatalf("commits = %#v, want nil", commits)
 	}
 }
 
-func TestGetCommitsSinceDateWrapsError(t *testing.T) {
+func TestCommitPagesSinceDateWrapsError(t *testing.T) {
 	t.Parallel()
 
 	client := &mockRESTClient{err: errors.New("boom")}
-	_, err := GetCommitsSinceDate("example", "widgets", "2026-07-01T00:00:00Z", client)
+	_, err := githubapi.Collect(CommitPagesSinceDate(context.Background(), "example", "widgets", "2026-07-01T00:00:00Z", client, false))
 	if err == nil || !strings.Contains(err.Error(), "fetch commits for example/widgets") || !strings.Contains(err.Error(), "boom") {
 		t.Fatalf("error = %v", err)
 	}
@@ -0,0 +1,224 @@
+package contributions
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/pkg/api"
+	"github.com/ssulei7/gh-dormant-users/internal/githubapi"
+)
+
+const (
+	// UserBatchSize is the number of users asked about in one query.
+	UserBatchSize = 25
+	// maxCommitRepositories bounds how many repositories are inspected to find
+	// a user's newest commit contribution.
+	maxCommitRepositories = 25
+)
+
+// SupportedActivityTypes lists the activity types that contributionsCollection
+// can answer. Comments and discussions are not reported as contributions.
+var SupportedActivityTypes = []string{"commits", "issues", "pull-requests", "pr-reviews"}
+
+// UnsupportedActivityTypes returns the requested activity types that
+// contributionsCollection cannot answer.
+func UnsupportedActivityTypes(activityTypes []string) []string {
+	var unsupported []string
+	for _, activityType := range activityTypes {
+		if !slices.Contains(SupportedActivityTypes, activityType) {
+			unsupported = append(unsupported, activityType)
+		}
+	}
+	return unsupported
+}
+
+// Contribution is the newest contribution of one activity type for a user
+type Contribution struct {
+	ActivityType string
+	Repository   string
+	URL          string
+	At           time.Time
+}
+
+type repositoryName struct {
+	Name string `json:"name"`
+}
+
+type contributionsCollection struct {
+	CommitContributionsByRepository []struct {
+		Repository    repositoryName `json:"repository"`
+		Contributions struct {
+			Nodes []struct {
+				OccurredAt time.Time `json:"occurredAt"`
+				URL        string    `json:"url"`
+			} `json:"nodes"`
+		} `json:"contributions"`
+	} `json:"commitContributionsByRepository"`
+	IssueContributions struct {
+		Nodes []struct {
+			OccurredAt time.Time `json:"occurredAt"`
+			Issue      struct {
+				URL        string         `json:"url"`
+				Repository repositoryName `json:"repository"`
+			} `json:"issue"`
+		} `json:"nodes"`
+	} `json:"issueContributions"`
+	PullRequestContributions struct {
+		Nodes []struct {
+			OccurredAt  time.Time `json:"occurredAt"`
+			PullRequest struct {
+				URL        string         `json:"url"`
+				Repository repositoryName `json:"repository"`
+			} `json:"pullRequest"`
+		} `json:"nodes"`
+	} `json:"pullRequestContributions"`
+	PullRequestReviewContributions struct {
+		Nodes []struct {
+			OccurredAt        time.Time      `json:"occurredAt"`
+			Repository        repositoryName `json:"repository"`
+			PullRequestReview struct {
+				URL string `json:"url"`
+			} `json:"pullRequestReview"`
+		} `json:"nodes"`
+	} `json:"pullRequestReviewContributions"`
+}
+
+type userContributions struct {
+	Login                   string                  `json:"login"`
+	ContributionsCollection contributionsCollection `json:"contributionsCollection"`
+}
+
+// GetOrganizationID resolves the GraphQL node ID used to scope contributions
+func GetOrganizationID(ctx context.Context, organization string, client api.GQLClient) (string, error) {
+	var result struct {
+		Organization *struct {
+			ID string `json:"id"`
+		} `json:"organization"`
+	}
+	query := "query($login:String!){organization(login:$login){id}}"
+	if err := client.DoWithContext(ctx, query, map[string]interface{}{"login": organization}, &result); err != nil {
+		return "", fmt.Errorf("fetch organization ID for %s: %w", organization, err)
+	}
+	if result.Organization == nil || result.Organization.ID == "" {
+		return "", fmt.Errorf("organization %s not found", organization)
+	}
+	return result.Organization.ID, nil
+}
+
+// GetUserContributions returns, for each login, the newest contribution of
+// each requested activity type made to the organization since the date.
+// Users are queried in aliased batches; afterBatch is called with the number
+// of users in each completed batch.
+func GetUserContributions(ctx context.Context, logins []string, organizationID string, since time.Time, client api.GQLClient, activityTypes []string, afterBatch func(int)) (map[string][]Contribution, error) {
+	fields := contributionFields(activityTypes)
+	all := make(map[string][]Contribution, len(logins))
+	for start := 0; start < len(logins); start += UserBatchSize {
+		end := min(start+UserBatchSize, len(logins))
+		if err := getUserContributionBatch(ctx, logins[start:end], organizationID, since, client, fields, all); err != nil {
+			return nil, fmt.Errorf("fetch contribution batch starting at %d: %w", start, err)
+		}
+		if afterBatch != nil {
+			afterBatch(end - start)
+		}
+	}
+	return all, nil
+}
+
+func contributionFields(activityTypes []string) string {
+	enabled := make(map[string]bool, len(activityTypes))
+	for _, activityType := range activityTypes {
+		enabled[activityType] = true
+	}
+	var fields []string
+	if enabled["commits"] {
+		fields = append(fields, fmt.Sprintf(
+			"commitContributionsByRepository(maxRepositories:%d){repository{name} contributions(first:1,orderBy:{field:OCCURRED_AT,direction:DESC}){nodes{occurredAt url}}}",
+			maxCommitRepositories,
+		))
+	}
+	if enabled["issues"] {
+		fields = append(fields, "issueContributions(first:1,orderBy:{direction:DESC}){nodes{occurredAt issue{url repository{name}}}}")
+	}
+	if enabled["pull-requests"] {
+		fields = append(fields, "pullRequestContributions(first:1,orderBy:{direction:DESC}){nodes{occurredAt pullRequest{url repository{name}}}}")
+	}
+	if enabled["pr-reviews"] {
+		fields = append(fields, "pullRequestReviewContributions(first:1,orderBy:{direction:DESC}){nodes{occurredAt repository{name} pullRequestReview{url}}}")
+	}
+	return strings.Join(fields, " ")
+}
+
+func getUserContributionBatch(ctx context.Context, logins []string, organizationID string, since time.Time, client api.GQLClient, fields string, all map[string][]Contribution) error {
+	if fields == "" {
+		return nil
+	}
+	declarations := []string{"$org:ID!", "$from:DateTime!"}
+	selections := make([]string, 0, len(logins))
+	variables := map[string]interface{}{
+		"org":  organizationID,
+		"from": since.UTC().Format(time.RFC3339),
+	}
+	aliases := make(map[string]struct{}, len(logins))
+	for index, login := range logins {
+		variable := fmt.Sprintf("login%d", index)
+		alias := fmt.Sprintf("user%d", index)
+		declarations = append(declarations, fmt.Sprintf("$%s:String!", variable))
+		selections = append(selections, fmt.Sprintf(
+			"%s:user(login:$%s){login contributionsCollection(organizationID:$org,from:$from){%s}}",
+			alias, variable, fields,
+		))
+		variables[variable] = login
+		aliases[alias] = struct{}{}
+	}
+
+	query := fmt.Sprintf("query(%s){%s}", strings.Join(declarations, ","), strings.Join(selections, " "))
+	result := make(map[string]*userContributions, len(logins))
+	if err := client.DoWithContext(ctx, query, variables, &result); err != nil && !githubapi.IsPartialAliasError(err, aliases) {
+		return err
+	}
+
+	for index, login := range logins {
+		user := result[fmt.Sprintf("user%d", index)]
+		if user == nil {
+			continue
+		}
+		if found := newestContributions(user.ContributionsCollection); len(found) > 0 {
+			all[login] = found
+		}
+	}
+	return nil
+}
+
+// newestContributions flattens a collection; the from argument of the query
+// already limits it to contributions since the date.
+func newestContributions(collection contributionsCollection) []Contribution {
+	var found []Contribution
+	add := func(activityType string, repo string, url string, at time.Time) {
+		found = append(found, Contribution{ActivityType: activityType, Repository: repo, URL: url, At: at.UTC()})
+	}
+
+	var newestCommit *Contribution
+	for _, byRepository := range collection.CommitContributionsByRepository {
+		for _, node := range byRepository.Contributions.Nodes {
+			if newestCommit == nil || node.OccurredAt.After(newestCommit.At) {
+				newestCommit = &Contribution{Repository: byRepository.Repository.Name, URL: node.URL, At: node.OccurredAt}
+			}
+		}
+	}
+	if newestCommit != nil {
+		add("commits", newestCommit.Repository, newestCommit.URL, newestCommit.At)
+	}
+	for _, node := range collection.IssueContributions.Nodes {
+		add("issues", node.Issue.Repository.Name, node.Issue.URL, node.OccurredAt)
+	}
+	for _, node := range collection.PullRequestContributions.Nodes {
+		add("pull-requests", node.PullRequest.Repository.Name, node.PullRequest.URL, node.OccurredAt)
+	}
+	for _, node := range collection.PullRequestReviewContributions.Nodes {
+		add("pr-reviews", node.Repository.Name, node.PullRequestReview.URL, node.OccurredAt)
+	}
+	return found
+}
@@ -0,0 +1,131 @@
+package contributions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cli/go-gh/pkg/api"
+)
+
+type scriptedGQLClient struct {
+	responses []string
+	errs      []error
+	queries   []string
+	variables []map[string]interface{}
+}
+
+func (c *scriptedGQLClient) Do(query string, variables map[string]interface{}, response interface{}) error {
+	index := len(c.queries)
+	c.queries = append(c.queries, query)
+	c.variables = append(c.variables, variables)
+	if index >= len(c.responses) {
+		return fmt.Errorf("unexpected query %d: %s", index, query)
+	}
+	if err := json.Unmarshal([]byte(c.responses[index]), response); err != nil {
+		return err
+	}
+	if index < len(c.errs) {
+		return c.errs[index]
+	}
+	return nil
+}
+
+func (c *scriptedGQLClient) DoWithContext(_ context.Context, query string, variables map[string]interface{}, response interface{}) error {
+	return c.Do(query, variables, response)
+}
+
+func (c *scriptedGQLClient) Mutate(_ string, _ interface{}, _ map[string]interface{}) error {
+	return nil
+}
+
+func (c *scriptedGQLClient) MutateWithContext(_ context.Context, _ string, _ interface{}, _ map[string]interface{}) error {
+	return nil
+}
+
+func (c *scriptedGQLClient) Query(_ string, _ interface{}, _ map[string]interface{}) error {
+	return nil
+}
+
+func (c *scriptedGQLClient) QueryWithContext(_ context.Context, _ string, _ interface{}, _ map[string]interface{}) error {
+	return nil
+}
+
+var since = time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+
+func TestGetUserContributionsBatchesUsers(t *testing.T) {
+	t.Parallel()
+
+	client := &scriptedGQLClient{
+		responses: []string{`{
+			"user0": {"login": "alice", "contributionsCollection": {
+				"commitContributionsByRepository": [
+					{"repository": {"name": "old"}, "contributions": {"nodes": [{"occurredAt": "2026-07-02T00:00:00Z", "url": "https://github.com/example/old/commits"}]}},
+					{"repository": {"name": "new"}, "contributions": {"nodes": [{"occurredAt": "2026-07-09T00:00:00Z", "url": "https://github.com/example/new/commits"}]}}
+				],
+				"pullRequestReviewContributions": {"nodes": [{"occurredAt": "2026-07-04T00:00:00Z", "repository": {"name": "widgets"}, "pullRequestReview": {"url": "https://github.com/example/widgets/pull/1#review"}}]}
+			}},
+			"user1": {"login": "bob", "contributionsCollection": {"commitContributionsByRepository": []}},
+			"user2": null
+		}`},
+		errs: []error{api.GQLError{Errors: []api.GQLErrorItem{{Message: "Could not resolve to a User", Path: []interface{}{"user2"}}}}},
+	}
+
+	batches := 0
+	found, err := GetUserContributions(context.Background(), []string{"alice", "bob", "ghost"}, "O_1", since, client, []string{"commits", "pr-reviews", "issue-comments"}, func(count int) {
+		batches += count
+	})
+	if err != nil {
+		t.Fatalf("GetUserContributions returned error: %v", err)
+	}
+	if len(client.queries) != 1 || batches != 3 {
+		t.Fatalf("queries = %d, users reported = %d", len(client.queries), batches)
+	}
+	if client.variables[0]["org"] != "O_1" || client.variables[0]["from"] != "2026-07-01T00:00:00Z" || client.variables[0]["login2"] != "ghost" {
+		t.Fatalf("variables = %v", client.variables[0])
+	}
+	if strings.Contains(client.queries[0], "issueContributions") {
+		t.Fatalf("query requested unselected contributions: %s", client.queries[0])
+	}
+	if len(found) != 1 || len(found["alice"]) != 2 {
+		t.Fatalf("found = %#v", found)
+	}
+	if commit := found["alice"][0]; commit.ActivityType != "commits" || commit.Repository != "new" {
+		t.Fatalf("newest commit = %#v", commit)
+	}
+	if review := found["alice"][1]; review.ActivityType != "pr-reviews" || review.URL != "https://github.com/example/widgets/pull/1#review" {
+		t.Fatalf("review = %#v", review)
+	}
+}
+
+func TestGetUserContributionsWrapsErrors(t *testing.T) {
+	t.Parallel()
+
+	client := &scriptedGQLClient{responses: []string{`{}`}, errs: []error{errors.New("boom")}}
+	_, err := GetUserContributions(context.Background(), []string{"alice"}, "O_1", since, client, []string{"issues"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "fetch contribution batch starting at 0") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestGetOrganizationIDRequiresOrganization(t *testing.T) {
+	t.Parallel()
+
+	client := &scriptedGQLClient{responses: []string{`{"organization": null}`}}
+	if _, err := GetOrganizationID(context.Background(), "missing", client); err == nil || !strings.Contains(err.Error(), "organization missing not found") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestUnsupportedActivityTypes(t *testing.T) {
+	t.Parallel()
+
+	got := UnsupportedActivityTypes([]string{"commits", "discussions", "issue-comments"})
+	if strings.Join(got, ",") != "discussions,issue-comments" {
+		t.Fatalf("unsupported = %v", got)
+	}
+}
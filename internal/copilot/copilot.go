@@ -0,0 +1,39 @@
+package copilot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cli/go-gh/pkg/api"
+	"github.com/ssulei7/gh-dormant-users/internal/githubapi"
+)
+
+// ActivityType is recorded for users with recent Copilot activity
+const ActivityType = "copilot"
+
+type Seat struct {
+	CreatedAt          time.Time  `json:"created_at"`
+	LastActivityAt     *time.Time `json:"last_activity_at"`
+	LastActivityEditor string     `json:"last_activity_editor"`
+	PlanType           string     `json:"plan_type"`
+	Assignee           struct {
+		Login string `json:"login"`
+		Type  string `json:"type"`
+	} `json:"assignee"`
+}
+
+// GetSeats returns every Copilot seat assigned in the organization
+func GetSeats(ctx context.Context, organization string, client api.RESTClient) ([]Seat, error) {
+	url := fmt.Sprintf("orgs/%s/copilot/billing/seats?per_page=100", organization)
+	seats, err := githubapi.GetAllWrapped[Seat](ctx, client, url, "seats")
+	if err != nil {
+		return nil, fmt.Errorf("fetch Copilot seats for %s: %w", organization, err)
+	}
+	return seats, nil
+}
+
+// SeatManagementURL links to the organization's Copilot seat settings on host
+func SeatManagementURL(host string, organization string) string {
+	return fmt.Sprintf("%sorganizations/%s/settings/copilot/seat_management", githubapi.WebURL(host), organization)
+}
@@ -0,0 +1,92 @@
+package copilot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type mockRESTClient struct {
+	path string
+	body string
+	err  error
+}
+
+func (m *mockRESTClient) Request(_ string, path string, _ io.Reader) (*http.Response, error) {
+	m.path = path
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(bytes.NewBufferString(m.body)),
+	}, nil
+}
+
+func (m *mockRESTClient) RequestWithContext(_ context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	return m.Request(method, path, body)
+}
+
+func (m *mockRESTClient) Do(method, path string, body io.Reader, result interface{}) error {
+	response, err := m.Request(method, path, body)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	return json.NewDecoder(response.Body).Decode(result)
+}
+
+func (m *mockRESTClient) DoWithContext(_ context.Context, method, path string, body io.Reader, result interface{}) error {
+	return m.Do(method, path, body, result)
+}
+
+func (m *mockRESTClient) Delete(path string, result interface{}) error {
+	return m.Do(http.MethodDelete, path, nil, result)
+}
+
+func (m *mockRESTClient) Get(path string, result interface{}) error {
+	return m.Do(http.MethodGet, path, nil, result)
+}
+
+func (m *mockRESTClient) Patch(path string, body io.Reader, result interface{}) error {
+	return m.Do(http.MethodPatch, path, body, result)
+}
+
+func (m *mockRESTClient) Post(path string, body io.Reader, result interface{}) error {
+	return m.Do(http.MethodPost, path, body, result)
+}
+
+func (m *mockRESTClient) Put(path string, body io.Reader, result interface{}) error {
+	return m.Do(http.MethodPut, path, body, result)
+}
+
+func TestGetSeats(t *testing.T) {
+	t.Parallel()
+
+	client := &mockRESTClient{body: `{"total_seats":1,"seats":[{"assignee":{"login":"octocat","type":"User"},"last_activity_at":"2026-07-05T00:00:00Z","last_activity_editor":"vscode"}]}`}
+	seats, err := GetSeats(context.Background(), "example", client)
+	if err != nil {
+		t.Fatalf("GetSeats returned error: %v", err)
+	}
+	if client.path != "orgs/example/copilot/billing/seats?per_page=100" {
+		t.Fatalf("request path = %q", client.path)
+	}
+	if len(seats) != 1 || seats[0].Assignee.Login != "octocat" || seats[0].LastActivityAt == nil {
+		t.Fatalf("seats = %#v", seats)
+	}
+}
+
+func TestGetSeatsWrapsErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := GetSeats(context.Background(), "example", &mockRESTClient{err: errors.New("boom")})
+	if err == nil || !strings.Contains(err.Error(), "fetch Copilot seats for example") {
+		t.Fatalf("error = %v", err)
+	}
+}
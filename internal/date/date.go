@@ -6,14 +6,18 @@ import (
 )
 
 func ValidateDate(date string) error {
+	return ValidateDateAfter(date, time.Now().AddDate(0, -3, 0), "3 months")
+}
+
+// ValidateDateAfter checks that the date is not before oldest, which is
+// described by limit in the error, such as "3 months".
+func ValidateDateAfter(date string, oldest time.Time, limit string) error {
 	parsedDate, err := time.Parse("Jan 2 2006", date)
 	if err != nil {
 		return fmt.Errorf("failed to parse date: %w", err)
 	}
-	// Check if date is within the last 3 months
-	threeMonthsAgo := time.Now().AddDate(0, -3, 0)
-	if parsedDate.Before(threeMonthsAgo) {
-		return fmt.Errorf("date must be within the last 3 months")
+	if parsedDate.Before(oldest) {
+		return fmt.Errorf("date must be within the last %s", limit)
 	}
 	return nil
 }
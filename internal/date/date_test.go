@@ -42,6 +42,19 @@ func TestValidateDate(t *testing.T) {
 	}
 }
 
+func TestValidateDateAfter(t *testing.T) {
+	t.Parallel()
+
+	oldest := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+	if err := ValidateDateAfter("Jan 15 2026", oldest, "180 days"); err != nil {
+		t.Fatalf("ValidateDateAfter returned error: %v", err)
+	}
+	err := ValidateDateAfter("Jan 14 2026", oldest, "180 days")
+	if err == nil || err.Error() != "date must be within the last 180 days" {
+		t.Fatalf("error = %v", err)
+	}
+}
+
 func TestGetISODate(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,259 @@
+package discussions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/pkg/api"
+	"github.com/ssulei7/gh-dormant-users/internal/githubapi"
+)
+
+const (
+	// RepositoryBatchSize keeps each batched query well below GitHub's
+	// GraphQL node limit given the nested comment and reply connections.
+	RepositoryBatchSize = 10
+	discussionPageSize  = 20
+	commentPageSize     = 20
+	replyPageSize       = 10
+	followUpPageSize    = 50
+)
+
+const replyFields = `url createdAt author{login}`
+
+var commentFields = fmt.Sprintf(
+	`id url createdAt author{login} replies(last:%d){pageInfo{hasPreviousPage startCursor} nodes{%s}}`,
+	replyPageSize,
+	replyFields,
+)
+
+var discussionFields = fmt.Sprintf(
+	`id url createdAt updatedAt author{login} comments(last:%d){pageInfo{hasPreviousPage startCursor} nodes{%s}}`,
+	commentPageSize,
+	commentFields,
+)
+
+var discussionConnectionFields = fmt.Sprintf(
+	`pageInfo{hasNextPage endCursor} nodes{%s}`,
+	discussionFields,
+)
+
+// Activity is a discussion, comment or reply authored on or after the cutoff
+type Activity struct {
+	Login      string
+	Repository string
+	URL        string
+	At         time.Time
+}
+
+type author struct {
+	Login string `json:"login"`
+}
+
+type pageInfo struct {
+	HasNextPage     bool   `json:"hasNextPage"`
+	EndCursor       string `json:"endCursor"`
+	HasPreviousPage bool   `json:"hasPreviousPage"`
+	StartCursor     string `json:"startCursor"`
+}
+
+type reply struct {
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"createdAt"`
+	Author    *author   `json:"author"`
+}
+
+type replyConnection struct {
+	PageInfo pageInfo `json:"pageInfo"`
+	Nodes    []reply  `json:"nodes"`
+}
+
+type comment struct {
+	ID        string          `json:"id"`
+	URL       string          `json:"url"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Author    *author         `json:"author"`
+	Replies   replyConnection `json:"replies"`
+}
+
+type commentConnection struct {
+	PageInfo pageInfo  `json:"pageInfo"`
+	Nodes    []comment `json:"nodes"`
+}
+
+type discussion struct {
+	ID        string            `json:"id"`
+	URL       string            `json:"url"`
+	CreatedAt time.Time         `json:"createdAt"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+	Author    *author           `json:"author"`
+	Comments  commentConnection `json:"comments"`
+}
+
+type discussionConnection struct {
+	PageInfo pageInfo     `json:"pageInfo"`
+	Nodes    []discussion `json:"nodes"`
+}
+
+type repositoryDiscussions struct {
+	Discussions discussionConnection `json:"discussions"`
+}
+
+type collector struct {
+	ctx          context.Context
+	organization string
+	since        time.Time
+	client       api.GQLClient
+	activity     []Activity
+}
+
+// GetDiscussionActivitySinceDate returns every discussion, comment and reply
+// created on or after since in the given repositories. Repositories are
+// queried in batches; only connections that may still hold recent activity
+// are paginated further.
+func GetDiscussionActivitySinceDate(ctx context.Context, organization string, repositories []string, since time.Time, client api.GQLClient) ([]Activity, error) {
+	c := &collector{ctx: ctx, organization: organization, since: since, client: client}
+	for start := 0; start < len(repositories); start += RepositoryBatchSize {
+		end := min(start+RepositoryBatchSize, len(repositories))
+		if err := c.collectBatch(repositories[start:end]); err != nil {
+			return nil, fmt.Errorf("fetch discussions batch starting at %s/%s: %w", organization, repositories[start], err)
+		}
+	}
+	return c.activity, nil
+}
+
+func (c *collector) collectBatch(repositories []string) error {
+	declarations := []string{"$owner:String!"}
+	fields := make([]string, 0, len(repositories))
+	variables := map[string]interface{}{"owner": c.organization}
+	aliases := make(map[string]struct{}, len(repositories))
+	for index, name := range repositories {
+		variable := fmt.Sprintf("name%d", index)
+		alias := fmt.Sprintf("repo%d", index)
+		declarations = append(declarations, fmt.Sprintf("$%s:String!", variable))
+		fields = append(fields, fmt.Sprintf(
+			"%s:repository(owner:$owner,name:$%s){discussions(first:%d,orderBy:{field:UPDATED_AT,direction:DESC}){%s}}",
+			alias, variable, discussionPageSize, discussionConnectionFields,
+		))
+		variables[variable] = name
+		aliases[alias] = struct{}{}
+	}
+
+	query := fmt.Sprintf("query(%s){%s}", strings.Join(declarations, ","), strings.Join(fields, " "))
+	result := make(map[string]*repositoryDiscussions, len(repositories))
+	if err := c.client.DoWithContext(c.ctx, query, variables, &result); err != nil && !githubapi.IsPartialAliasError(err, aliases) {
+		return err
+	}
+
+	for index, name := range repositories {
+		repo := result[fmt.Sprintf("repo%d", index)]
+		if repo == nil {
+			continue
+		}
+		if err := c.addDiscussions(name, repo.Discussions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *collector) addDiscussions(repoName string, connection discussionConnection) error {
+	for {
+		recent := true
+		for _, item := range connection.Nodes {
+			if item.UpdatedAt.Before(c.since) {
+				recent = false
+				break
+			}
+			c.add(item.Author, repoName, item.URL, item.CreatedAt)
+			if err := c.addComments(repoName, item.ID, item.Comments); err != nil {
+				return err
+			}
+		}
+		if !recent || !connection.PageInfo.HasNextPage {
+			return nil
+		}
+
+		query := fmt.Sprintf(
+			"query($owner:String!,$name:String!,$after:String){repository(owner:$owner,name:$name){discussions(first:%d,after:$after,orderBy:{field:UPDATED_AT,direction:DESC}){%s}}}",
+			discussionPageSize, discussionConnectionFields,
+		)
+		variables := map[string]interface{}{"owner": c.organization, "name": repoName, "after": connection.PageInfo.EndCursor}
+		var result struct {
+			Repository repositoryDiscussions `json:"repository"`
+		}
+		if err := c.client.DoWithContext(c.ctx, query, variables, &result); err != nil {
+			return fmt.Errorf("fetch discussions for %s/%s: %w", c.organization, repoName, err)
+		}
+		connection = result.Repository.Discussions
+	}
+}
+
+// addComments walks every comment of a discussion updated since the cutoff,
+// newest first. Comments created before the cutoff are still read, as a reply
+// to one of them may be recent; each comment's replies are checked by
+// addReplies.
+func (c *collector) addComments(repoName string, discussionID string, connection commentConnection) error {
+	for {
+		for _, item := range connection.Nodes {
+			c.add(item.Author, repoName, item.URL, item.CreatedAt)
+			if err := c.addReplies(repoName, item.ID, item.Replies); err != nil {
+				return err
+			}
+		}
+		if !connection.PageInfo.HasPreviousPage || len(connection.Nodes) == 0 {
+			return nil
+		}
+
+		query := fmt.Sprintf(
+			"query($id:ID!,$before:String){node(id:$id){... on Discussion{comments(last:%d,before:$before){pageInfo{hasPreviousPage startCursor} nodes{%s}}}}}",
+			followUpPageSize, commentFields,
+		)
+		variables := map[string]interface{}{"id": discussionID, "before": connection.PageInfo.StartCursor}
+		var result struct {
+			Node struct {
+				Comments commentConnection `json:"comments"`
+			} `json:"node"`
+		}
+		if err := c.client.DoWithContext(c.ctx, query, variables, &result); err != nil {
+			return fmt.Errorf("fetch discussion comments in %s/%s: %w", c.organization, repoName, err)
+		}
+		connection = result.Node.Comments
+	}
+}
+
+// addReplies walks a comment's replies newest first, paging backwards until
+// it reaches replies created before the cutoff.
+func (c *collector) addReplies(repoName string, commentID string, connection replyConnection) error {
+	for {
+		for _, item := range connection.Nodes {
+			c.add(item.Author, repoName, item.URL, item.CreatedAt)
+		}
+		if !connection.PageInfo.HasPreviousPage || len(connection.Nodes) == 0 || connection.Nodes[0].CreatedAt.Before(c.since) {
+			return nil
+		}
+
+		query := fmt.Sprintf(
+			"query($id:ID!,$before:String){node(id:$id){... on DiscussionComment{replies(last:%d,before:$before){pageInfo{hasPreviousPage startCursor} nodes{%s}}}}}",
+			followUpPageSize, replyFields,
+		)
+		variables := map[string]interface{}{"id": commentID, "before": connection.PageInfo.StartCursor}
+		var result struct {
+			Node struct {
+				Replies replyConnection `json:"replies"`
+			} `json:"node"`
+		}
+		if err := c.client.DoWithContext(c.ctx, query, variables, &result); err != nil {
+			return fmt.Errorf("fetch discussion replies in %s/%s: %w", c.organization, repoName, err)
+		}
+		connection = result.Node.Replies
+	}
+}
+
+func (c *collector) add(author *author, repoName string, url string, at time.Time) {
+	if author == nil || author.Login == "" || at.Before(c.since) {
+		return
+	}
+	c.activity = append(c.activity, Activity{Login: author.Login, Repository: repoName, URL: url, At: at.UTC()})
+}
@@ -0,0 +1,157 @@
+package discussions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cli/go-gh/pkg/api"
+)
+
+type scriptedGQLClient struct {
+	responses []string
+	errs      []error
+	queries   []string
+	variables []map[string]interface{}
+}
+
+func (c *scriptedGQLClient) Do(query string, variables map[string]interface{}, response interface{}) error {
+	index := len(c.queries)
+	c.queries = append(c.queries, query)
+	c.variables = append(c.variables, variables)
+	if index >= len(c.responses) {
+		return fmt.Errorf("unexpected query %d: %s", index, query)
+	}
+	if err := json.Unmarshal([]byte(c.responses[index]), response); err != nil {
+		return err
+	}
+	if index < len(c.errs) {
+		return c.errs[index]
+	}
+	return nil
+}
+
+func (c *scriptedGQLClient) DoWithContext(_ context.Context, query string, variables map[string]interface{}, response interface{}) error {
+	return c.Do(query, variables, response)
+}
+
+func (c *scriptedGQLClient) Mutate(_ string, _ interface{}, _ map[string]interface{}) error {
+	return nil
+}
+
+func (c *scriptedGQLClient) MutateWithContext(_ context.Context, _ string, _ interface{}, _ map[string]interface{}) error {
+	return nil
+}
+
+func (c *scriptedGQLClient) Query(_ string, _ interface{}, _ map[string]interface{}) error {
+	return nil
+}
+
+func (c *scriptedGQLClient) QueryWithContext(_ context.Context, _ string, _ interface{}, _ map[string]interface{}) error {
+	return nil
+}
+
+var since = time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+
+func logins(activity []Activity) string {
+	names := make([]string, 0, len(activity))
+	for _, item := range activity {
+		names = append(names, item.Login)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestGetDiscussionActivityBatchesRepositories(t *testing.T) {
+	t.Parallel()
+
+	client := &scriptedGQLClient{
+		responses: []string{`{
+			"repo0": {"discussions": {"pageInfo": {"hasNextPage": false}, "nodes": [{
+				"id": "D1", "url": "https://github.com/example/widgets/discussions/1",
+				"createdAt": "2026-07-02T00:00:00Z", "updatedAt": "2026-07-05T00:00:00Z",
+				"author": {"login": "alice"},
+				"comments": {"pageInfo": {"hasPreviousPage": false}, "nodes": [
+					{"id": "C0", "url": "https://github.com/example/widgets/discussions/1#c0", "createdAt": "2026-06-01T00:00:00Z", "author": {"login": "dave"},
+					 "replies": {"pageInfo": {"hasPreviousPage": false}, "nodes": []}},
+					{"id": "C1", "url": "https://github.com/example/widgets/discussions/1#c1", "createdAt": "2026-07-03T00:00:00Z", "author": {"login": "bob"},
+					 "replies": {"pageInfo": {"hasPreviousPage": false}, "nodes": [
+						{"url": "https://github.com/example/widgets/discussions/1#r1", "createdAt": "2026-07-04T00:00:00Z", "author": {"login": "carol"}},
+						{"url": "https://github.com/example/widgets/discussions/1#r2", "createdAt": "2026-07-04T00:00:00Z", "author": null}
+					 ]}}
+				]}
+			}]}},
+			"repo1": null
+		}`},
+		errs: []error{api.GQLError{Errors: []api.GQLErrorItem{{Message: "Could not resolve to a Repository", Path: []interface{}{"repo1"}}}}},
+	}
+
+	activity, err := GetDiscussionActivitySinceDate(context.Background(), "example", []string{"widgets", "removed"}, since, client)
+	if err != nil {
+		t.Fatalf("GetDiscussionActivitySinceDate returned error: %v", err)
+	}
+	if got := logins(activity); got != "alice,bob,carol" {
+		t.Fatalf("logins = %q", got)
+	}
+	if len(client.queries) != 1 {
+		t.Fatalf("queries = %d, want a single batched query", len(client.queries))
+	}
+	if client.variables[0]["owner"] != "example" || client.variables[0]["name1"] != "removed" {
+		t.Fatalf("variables = %v", client.variables[0])
+	}
+	if activity[2].Repository != "widgets" || activity[2].URL != "https://github.com/example/widgets/discussions/1#r1" {
+		t.Fatalf("reply activity = %#v", activity[2])
+	}
+}
+
+func TestGetDiscussionActivityPaginatesRecentConnections(t *testing.T) {
+	t.Parallel()
+
+	client := &scriptedGQLClient{responses: []string{
+		`{"repo0": {"discussions": {"pageInfo": {"hasNextPage": true, "endCursor": "D-CURSOR"}, "nodes": [{
+			"id": "D2", "url": "d2", "createdAt": "2026-07-02T00:00:00Z", "updatedAt": "2026-07-05T00:00:00Z", "author": {"login": "alice"},
+			"comments": {"pageInfo": {"hasPreviousPage": true, "startCursor": "C-CURSOR"}, "nodes": [
+				{"id": "C2", "url": "c2", "createdAt": "2026-07-03T00:00:00Z", "author": {"login": "bob"}, "replies": {"pageInfo": {}, "nodes": []}}
+			]}
+		}]}}}`,
+		`{"node": {"comments": {"pageInfo": {"hasPreviousPage": true, "startCursor": "OLDER"}, "nodes": [
+			{"id": "C1", "url": "c1", "createdAt": "2026-06-20T00:00:00Z", "author": {"login": "old"}, "replies": {"pageInfo": {}, "nodes": []}}
+		]}}}`,
+		`{"node": {"comments": {"pageInfo": {"hasPreviousPage": false}, "nodes": [
+			{"id": "C0", "url": "c0", "createdAt": "2026-05-20T00:00:00Z", "author": {"login": "older"}, "replies": {"pageInfo": {}, "nodes": [
+				{"url": "r0", "createdAt": "2026-07-04T00:00:00Z", "author": {"login": "carol"}}
+			]}}
+		]}}}`,
+		`{"repository": {"discussions": {"pageInfo": {"hasNextPage": true, "endCursor": "NEXT"}, "nodes": [
+			{"id": "D1", "url": "d1", "createdAt": "2026-05-02T00:00:00Z", "updatedAt": "2026-06-05T00:00:00Z", "author": {"login": "stale"},
+			 "comments": {"pageInfo": {}, "nodes": []}}
+		]}}}`,
+	}}
+
+	activity, err := GetDiscussionActivitySinceDate(context.Background(), "example", []string{"widgets"}, since, client)
+	if err != nil {
+		t.Fatalf("GetDiscussionActivitySinceDate returned error: %v", err)
+	}
+	if got := logins(activity); got != "alice,bob,carol" {
+		t.Fatalf("logins = %q, want the recent reply to an old comment counted", got)
+	}
+	if len(client.queries) != 4 {
+		t.Fatalf("queries = %d, want 4", len(client.queries))
+	}
+	if client.variables[1]["before"] != "C-CURSOR" || client.variables[2]["before"] != "OLDER" || client.variables[3]["after"] != "D-CURSOR" {
+		t.Fatalf("pagination variables = %v", client.variables)
+	}
+}
+
+func TestGetDiscussionActivityWrapsErrors(t *testing.T) {
+	t.Parallel()
+
+	client := &scriptedGQLClient{responses: []string{`{}`}, errs: []error{errors.New("boom")}}
+	_, err := GetDiscussionActivitySinceDate(context.Background(), "example", []string{"widgets"}, since, client)
+	if err == nil || !strings.Contains(err.Error(), "fetch discussions batch starting at example/widgets") {
+		t.Fatalf("error = %v", err)
+	}
+}
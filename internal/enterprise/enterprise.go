@@ -0,0 +1,242 @@
+package enterprise
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/pkg/api"
+	"github.com/ssulei7/gh-dormant-users/internal/ui"
+	"github.com/ssulei7/gh-dormant-users/internal/users"
+)
+
+const organizationsQuery = `query($slug:String!,$cursor:String){enterprise(slug:$slug){organizations(first:100,after:$cursor){nodes{login} pageInfo{hasNextPage endCursor}}}}`
+
+// GetOrganizations returns the logins of the enterprise's organizations that
+// the token can see, in the order GitHub lists them.
+func GetOrganizations(ctx context.Context, slug string, client api.GQLClient) ([]string, error) {
+	var organizations []string
+	var cursor *string
+	for {
+		var result struct {
+			Enterprise *struct {
+				Organizations struct {
+					Nodes []struct {
+						Login string `json:"login"`
+					} `json:"nodes"`
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+				} `json:"organizations"`
+			} `json:"enterprise"`
+		}
+		variables := map[string]interface{}{"slug": slug, "cursor": cursor}
+		if err := client.DoWithContext(ctx, organizationsQuery, variables, &result); err != nil {
+			return nil, fmt.Errorf("fetch organizations of enterprise %s: %w", slug, err)
+		}
+		if result.Enterprise == nil {
+			return nil, fmt.Errorf("enterprise %s not found", slug)
+		}
+		for _, node := range result.Enterprise.Organizations.Nodes {
+			organizations = append(organizations, node.Login)
+		}
+		pageInfo := result.Enterprise.Organizations.PageInfo
+		if !pageInfo.HasNextPage {
+			return organizations, nil
+		}
+		cursor = &pageInfo.EndCursor
+	}
+}
+
+// OrganizationUsers are the members of one organization after its scan
+type OrganizationUsers struct {
+	Organization string
+	Users        users.Users
+}
+
+// Membership is a user's record in one organization
+type Membership struct {
+	Organization string
+	User         *users.User
+}
+
+// Member is a user across every scanned organization, whether they were
+// listed from an enterprise or named on the command line.
+type Member struct {
+	Login       string
+	Email       string
+	Memberships []Membership
+}
+
+// Active reports whether the member was active in any organization
+func (m Member) Active() bool {
+	for _, membership := range m.Memberships {
+		if membership.User.IsActive() {
+			return true
+		}
+	}
+	return false
+}
+
+// Organizations returns the organizations the member belongs to, and those
+// they were active in.
+func (m Member) Organizations() ([]string, []string) {
+	var memberOf, activeIn []string
+	for _, membership := range m.Memberships {
+		memberOf = append(memberOf, membership.Organization)
+		if membership.User.IsActive() {
+			activeIn = append(activeIn, membership.Organization)
+		}
+	}
+	return memberOf, activeIn
+}
+
+// LastActivity returns the newest evidence across the member's organizations
+// and the organization it was found in.
+func (m Member) LastActivity() (string, users.Evidence) {
+	var organization string
+	var newest users.Evidence
+	for _, membership := range m.Memberships {
+		evidence := membership.User.GetLastActivity()
+		if evidence == (users.Evidence{}) {
+			continue
+		}
+		if organization == "" || evidence.At.After(newest.At) {
+			organization, newest = membership.Organization, evidence
+		}
+	}
+	return organization, newest
+}
+
+// CopilotSeat merges the member's Copilot seats across organizations. It is
+// assigned when any organization assigns one, and holds the most recent use.
+func (m Member) CopilotSeat() users.CopilotSeat {
+	var merged users.CopilotSeat
+	for _, membership := range m.Memberships {
+		seat := membership.User.GetCopilotSeat()
+		merged.Checked = merged.Checked || seat.Checked
+		merged.Assigned = merged.Assigned || seat.Assigned
+		newer := seat.LastActivityAt.After(merged.LastActivityAt)
+		if newer || merged.LastActivityEditor == "" && seat.LastActivityAt.Equal(merged.LastActivityAt) {
+			merged.LastActivityAt, merged.LastActivityEditor = seat.LastActivityAt, seat.LastActivityEditor
+		}
+	}
+	return merged
+}
+
+// Consolidate merges the members of each organization into one entry per
+// login, sorted by login. Memberships keep the order of organizations.
+func Consolidate(organizations []OrganizationUsers) []Member {
+	byLogin := make(map[string]*Member)
+	for _, organization := range organizations {
+		for index := range organization.Users {
+			user := &organization.Users[index]
+			member, ok := byLogin[user.Login]
+			if !ok {
+				member = &Member{Login: user.Login}
+				byLogin[user.Login] = member
+			}
+			if member.Email == "" {
+				member.Email = user.Email
+			}
+			member.Memberships = append(member.Memberships, Membership{Organization: organization.Organization, User: user})
+		}
+	}
+
+	members := make([]Member, 0, len(byLogin))
+	for _, member := range byLogin {
+		members = append(members, *member)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return strings.ToLower(members[i].Login) < strings.ToLower(members[j].Login)
+	})
+	return members
+}
+
+// OrganizationColumnPrefix starts the name of each organization's column in
+// a consolidated CSV report.
+const OrganizationColumnPrefix = "Org:"
+
+// organizationColumn is a member's activity in one organization: empty when
+// they are not a member, "none" when they were inactive, and otherwise the
+// activity types found.
+func organizationColumn(member Member, organization string) string {
+	for _, membership := range member.Memberships {
+		if membership.Organization != organization {
+			continue
+		}
+		if !membership.User.IsActive() {
+			return "none"
+		}
+		activityTypes := membership.User.GetActivityTypes()
+		sort.Strings(activityTypes)
+		return strings.Join(activityTypes, ",")
+	}
+	return ""
+}
+
+// GenerateReportCSV writes one row per member with the verdict across every
+// organization, where they belong and were active, the newest evidence and
+// their Copilot seat, followed by a column for each organization. The
+// organization columns are prefixed with OrganizationColumnPrefix, so an
+// organization cannot share its name with another column. EvidenceComplete is false on
+// every row when a scan stopped reading activity early, and Partial is true
+// on every row when the run was interrupted.
+func GenerateReportCSV(organizations []string, members []Member, filePath string, evidenceComplete bool, partial bool) error {
+	ui.Info("Generating CSV report: %s", filePath)
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	header := []string{
+		"Username", "Email", "Active", "Organizations", "ActiveOrganizations", "LastActiveAt", "LastActiveOrganization", "LastActiveRepo", "EvidenceURL",
+		"CopilotSeat", "CopilotLastActivityAt", "CopilotLastActivityEditor", "EvidenceComplete", "Partial",
+	}
+	for _, name := range organizations {
+		header = append(header, OrganizationColumnPrefix+name)
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, member := range members {
+		memberOf, activeIn := member.Organizations()
+		organization, evidence := member.LastActivity()
+		lastActiveAt := ""
+		if !evidence.At.IsZero() {
+			lastActiveAt = evidence.At.UTC().Format(time.RFC3339)
+		}
+		record := []string{
+			member.Login,
+			member.Email,
+			strconv.FormatBool(member.Active()),
+			strings.Join(memberOf, ","),
+			strings.Join(activeIn, ","),
+			lastActiveAt,
+			organization,
+			evidence.Repository,
+			evidence.URL,
+		}
+		record = append(record, member.CopilotSeat().Columns()...)
+		record = append(record, strconv.FormatBool(evidenceComplete), strconv.FormatBool(partial))
+		for _, name := range organizations {
+			record = append(record, organizationColumn(member, name))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	ui.Success("Report saved to %s", filePath)
+	return nil
+}
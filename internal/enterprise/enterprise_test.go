@@ -0,0 +1,121 @@
+package enterprise
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ssulei7/gh-dormant-users/internal/users"
+)
+
+type scriptedGQLClient struct {
+	responses []string
+	variables []map[string]interface{}
+}
+
+func (c *scriptedGQLClient) Do(query string, variables map[string]interface{}, response interface{}) error {
+	index := len(c.variables)
+	c.variables = append(c.variables, variables)
+	if index >= len(c.responses) {
+		return fmt.Errorf("unexpected query %d: %s", index, query)
+	}
+	return json.Unmarshal([]byte(c.responses[index]), response)
+}
+
+func (c *scriptedGQLClient) DoWithContext(_ context.Context, query string, variables map[string]interface{}, response interface{}) error {
+	return c.Do(query, variables, response)
+}
+
+func (c *scriptedGQLClient) Mutate(_ string, _ interface{}, _ map[string]interface{}) error {
+	return nil
+}
+
+func (c *scriptedGQLClient) MutateWithContext(_ context.Context, _ string, _ interface{}, _ map[string]interface{}) error {
+	return nil
+}
+
+func (c *scriptedGQLClient) Query(_ string, _ interface{}, _ map[string]interface{}) error {
+	return nil
+}
+
+func (c *scriptedGQLClient) QueryWithContext(_ context.Context, _ string, _ interface{}, _ map[string]interface{}) error {
+	return nil
+}
+
+func TestGetOrganizationsFollowsPages(t *testing.T) {
+	client := &scriptedGQLClient{responses: []string{
+		`{"enterprise":{"organizations":{"nodes":[{"login":"one"}],"pageInfo":{"hasNextPage":true,"endCursor":"abc"}}}}`,
+		`{"enterprise":{"organizations":{"nodes":[{"login":"two"}],"pageInfo":{"hasNextPage":false,"endCursor":"def"}}}}`,
+	}}
+
+	organizations, err := GetOrganizations(context.Background(), "acme", client)
+	if err != nil {
+		t.Fatalf("GetOrganizations returned error: %v", err)
+	}
+	if strings.Join(organizations, ",") != "one,two" {
+		t.Fatalf("organizations = %v", organizations)
+	}
+	if cursor, ok := client.variables[1]["cursor"].(*string); !ok || *cursor != "abc" {
+		t.Fatalf("second page variables = %v", client.variables[1])
+	}
+}
+
+func TestGetOrganizationsRequiresEnterprise(t *testing.T) {
+	client := &scriptedGQLClient{responses: []string{`{"enterprise":null}`}}
+	if _, err := GetOrganizations(context.Background(), "missing", client); err == nil || !strings.Contains(err.Error(), "enterprise missing not found") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestConsolidateGivesEnterpriseVerdict(t *testing.T) {
+	platform := users.Users{{Login: "alice", Email: "alice@example.com"}, {Login: "bob"}}
+	research := users.Users{{Login: "alice"}, {Login: "carol"}}
+	research[0].RecordActivity("commits", users.Evidence{
+		At:         time.Date(2026, 7, 4, 0, 0, 0, 0, time.UTC),
+		Repository: "lab",
+		URL:        "https://github.com/research/lab/commit/1",
+	})
+	research[1].RecordActivity("issues", users.Evidence{At: time.Date(2026, 7, 2, 0, 0, 0, 0, time.UTC), Repository: "notes"})
+	// Only platform's Copilot seats were checked.
+	platform[0].SetCopilotSeat(users.CopilotSeat{Checked: true, Assigned: true, LastActivityAt: time.Date(2026, 7, 3, 0, 0, 0, 0, time.UTC), LastActivityEditor: "vscode/1.99.0"})
+	platform[1].SetCopilotSeat(users.CopilotSeat{Checked: true})
+
+	members := Consolidate([]OrganizationUsers{
+		{Organization: "platform", Users: platform},
+		{Organization: "research", Users: research},
+	})
+	path := filepath.Join(t.TempDir(), "report.csv")
+	if err := GenerateReportCSV([]string{"platform", "research"}, members, path, true, false); err != nil {
+		t.Fatalf("GenerateReportCSV returned error: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open report: %v", err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	want := []string{
+		"Username,Email,Active,Organizations,ActiveOrganizations,LastActiveAt,LastActiveOrganization,LastActiveRepo,EvidenceURL,CopilotSeat,CopilotLastActivityAt,CopilotLastActivityEditor,EvidenceComplete,Partial,Org:platform,Org:research",
+		`alice,alice@example.com,true,platform,research,research,2026-07-04T00:00:00Z,research,lab,https://github.com/research/lab/commit/1,true,2026-07-03T00:00:00Z,vscode/1.99.0,true,false,none,commits`,
+		"bob,,false,platform,,,,,,false,,,true,false,none,",
+		"carol,,true,research,research,2026-07-02T00:00:00Z,research,notes,,,,,true,false,,issues",
+	}
+	if len(records) != len(want) {
+		t.Fatalf("records = %v", records)
+	}
+	for index, record := range records {
+		if got := strings.Join(record, ","); got != want[index] {
+			t.Fatalf("row %d = %q, want %q", index, got, want[index])
+		}
+	}
+}
@@ -2,6 +2,7 @@ package githubapi
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
@@ -9,13 +10,16 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"math/rand/v2"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -23,8 +27,21 @@ const (
 	defaultMaxRetries = 3
 	cacheVersion      = 1
 	cacheMarker       = ".gh-dormant-users-cache"
+	// APIVersionHeader selects the REST API version. Older GitHub Enterprise
+	// Server releases reject versions they do not know.
+	APIVersionHeader = "X-GitHub-Api-Version"
+	// APIVersion is the REST API version requests are written against
+	APIVersion = "2026-03-10"
 )
 
+// WebURL is the root of a host's web interface, github.com when host is empty
+func WebURL(host string) string {
+	if host == "" {
+		host = "github.com"
+	}
+	return "https://" + host + "/"
+}
+
 type Config struct {
 	Transport          http.RoundTripper
 	CacheDir           string
@@ -37,6 +54,10 @@ type Config struct {
 	Now                func() time.Time
 	Sleep              func(context.Context, time.Duration) error
 	Jitter             func(time.Duration) time.Duration
+	// Tokens, when set, form a pool: each request is sent with the token that
+	// has the most remaining budget for its rate-limit resource, replacing its
+	// Authorization header.
+	Tokens []string
 }
 
 func isSecondaryLimitResponse(response *http.Response) bool {
@@ -105,6 +126,9 @@ type Stats struct {
 	P95Latency                time.Duration
 	AchievedRequestsPerSecond float64
 	ConcurrencyWaitDuration   time.Duration
+	// APIVersionDropped is set once the server rejected the API version
+	// header and later requests were sent without it.
+	APIVersionDropped bool
 }
 
 type Coordinator struct {
@@ -119,22 +143,54 @@ type Coordinator struct {
 	jitter           func(time.Duration) time.Duration
 	gate             *concurrencyGate
 	controller       *adaptiveController
+	pool             []poolCredential
 
 	scheduleMu   sync.Mutex
 	nextRequest  time.Time
 	blockedUntil time.Time
+	// resourceBlockedUntil holds waits for exhausted primary limits, which
+	// only affect requests against the same rate-limit resource made with the
+	// same credential. It is keyed by limitKey.
+	resourceBlockedUntil map[string]time.Time
+	// rejected holds the names of pool tokens the server answered with 401,
+	// which are not used again.
+	rejected map[string]bool
 
 	statsMu sync.Mutex
 	stats   Stats
-	rates   map[string]rateState
+	// rates is keyed by limitKey, as each credential has its own limits
+	rates map[string]rateState
+	// cachedBy names the pool token whose cached response this run last
+	// stored or revalidated, keyed by requestKey.
+	cachedBy map[string]string
+
+	// dropAPIVersion is set once the server rejects the API version header
+	dropAPIVersion atomic.Bool
+	// replaying is set when responses come from a Replayer, whose recorded
+	// rate limits and Retry-After headers are not waited for.
+	replaying bool
+}
+
+// poolCredential is one token of the Coordinator's pool
+type poolCredential struct {
+	name          string
+	authorization string
 }
 
 type rateState struct {
+	resource  string
 	limit     int
 	remaining int
 	reset     time.Time
 }
 
+// RateLimit is the latest primary rate-limit state seen for one resource
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
 type cacheEntry struct {
 	Version      int       `json:"version"`
 	ETag         string    `json:"etag,omitempty"`
@@ -196,6 +252,21 @@ func NewCoordinator(config Config) (*Coordinator, error) {
 		}
 	}
 
+	var pool []poolCredential
+	seen := make(map[string]bool)
+	for _, token := range config.Tokens {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			return nil, errors.New("token pool contains an empty token")
+		}
+		authorization := "token " + token
+		if seen[authorization] {
+			continue
+		}
+		seen[authorization] = true
+		pool = append(pool, poolCredential{name: tokenCredential(authorization), authorization: authorization})
+	}
+
 	gate := newConcurrencyGate(config.InitialConcurrency, config.MaxConcurrency)
 	coordinator := &Coordinator{
 		transport:        config.Transport,
@@ -208,10 +279,18 @@ func NewCoordinator(config Config) (*Coordinator, error) {
 		sleep:            config.Sleep,
 		jitter:           config.Jitter,
 		gate:             gate,
+		pool:             pool,
 		stats: Stats{
 			EndpointCounts: make(map[string]int),
 		},
-		rates: make(map[string]rateState),
+		rates:                make(map[string]rateState),
+		cachedBy:             make(map[string]string),
+		resourceBlockedUntil: make(map[string]time.Time),
+		rejected:             make(map[string]bool),
+	}
+	if _, replaying := config.Transport.(*Replayer); replaying {
+		coordinator.replaying = true
+		coordinator.minInterval = 0
 	}
 	coordinator.controller = newAdaptiveController(
 		gate,
@@ -229,25 +308,16 @@ func (c *Coordinator) RoundTrip(request *http.Request) (*http.Response, error) {
 	}
 	defer c.release()
 
-	var entry *cacheEntry
-	var cachePath string
-	if c.cacheEnabled && request.Method == http.MethodGet {
-		cachePath = c.cachePath(request)
-		var cacheErr error
-		entry, cacheErr = c.readCache(cachePath)
-		if cacheErr != nil {
-			c.recordCacheError()
-		}
-		if entry != nil {
-			request = request.Clone(request.Context())
-			if entry.ETag != "" {
-				request.Header.Set("If-None-Match", entry.ETag)
-			} else if entry.LastModified != "" {
-				request.Header.Set("If-Modified-Since", entry.LastModified)
-			}
-		}
+	if c.dropAPIVersion.Load() && request.Header.Get(APIVersionHeader) != "" {
+		request = request.Clone(request.Context())
+		request.Header.Del(APIVersionHeader)
 	}
 
+	if len(c.pool) > 0 {
+		request = c.assignCredential(request)
+	}
+	request, entry, cachePath := c.prepareCache(request)
+
 	for attempt := 0; ; attempt++ {
 		primaryCandidate := entry == nil
 		if err := c.waitForTurn(request.Context(), request, primaryCandidate); err != nil {
@@ -277,11 +347,46 @@ func (c *Coordinator) RoundTrip(request *http.Request) (*http.Response, error) {
 		}
 
 		c.recordResponse(response)
-		c.updateRateState(response)
+		c.updateRateState(request, response)
+
+		if rejectsAPIVersion(request, response) {
+			_ = response.Body.Close()
+			c.observeRequest(requestStarted)
+			c.dropAPIVersion.Store(true)
+			c.statsMu.Lock()
+			c.stats.APIVersionDropped = true
+			c.statsMu.Unlock()
+			// Resend at once without the header; this is not a retry.
+			request, err = resetRequestBody(request)
+			if err != nil {
+				return nil, err
+			}
+			request = request.Clone(request.Context())
+			request.Header.Del(APIVersionHeader)
+			attempt--
+			continue
+		}
+
+		if response.StatusCode == http.StatusUnauthorized && c.reject(requestCredential(request)) {
+			_ = response.Body.Close()
+			c.observeRequest(requestStarted)
+			// Resend at once with another token; this is not a retry.
+			request, err = resetRequestBody(request)
+			if err != nil {
+				return nil, err
+			}
+			request = request.Clone(request.Context())
+			request.Header.Del("If-None-Match")
+			request.Header.Del("If-Modified-Since")
+			request, entry, cachePath = c.prepareCache(c.assignCredential(request))
+			attempt--
+			continue
+		}
 
 		if entry != nil && response.StatusCode == http.StatusNotModified {
 			_ = response.Body.Close()
 			c.recordCacheHit()
+			c.rememberCached(request)
 			c.observeRequest(requestStarted)
 			return cachedResponse(request, response, entry), nil
 		}
@@ -294,8 +399,17 @@ func (c *Coordinator) RoundTrip(request *http.Request) (*http.Response, error) {
 			delay := c.rateLimitDelay(response, attempt)
 			_ = response.Body.Close()
 			c.observeRequest(requestStarted)
-			c.blockFor(delay)
+			blocked := blockedResource(request, response)
+			c.blockFor(blocked, delay)
 			c.recordRetry()
+			if blocked != "" && len(c.pool) > 0 {
+				// Another token may still have budget. Validators cached for
+				// this token are not sent with a different one.
+				request = request.Clone(request.Context())
+				request.Header.Del("If-None-Match")
+				request.Header.Del("If-Modified-Since")
+				request, entry, cachePath = c.prepareCache(c.assignCredential(request))
+			}
 			continue
 		}
 
@@ -311,6 +425,8 @@ func (c *Coordinator) RoundTrip(request *http.Request) (*http.Response, error) {
 			if validatorPresent(response.Header) {
 				if err := c.writeCache(cachePath, response, body); err != nil {
 					c.recordCacheError()
+				} else {
+					c.rememberCached(request)
 				}
 			}
 		}
@@ -320,6 +436,156 @@ func (c *Coordinator) RoundTrip(request *http.Request) (*http.Response, error) {
 	}
 }
 
+// prepareCache looks up the cached response for a GET request and, when there
+// is one, adds its validator to the request.
+func (c *Coordinator) prepareCache(request *http.Request) (*http.Request, *cacheEntry, string) {
+	if !c.cacheEnabled || request.Method != http.MethodGet {
+		return request, nil, ""
+	}
+	cachePath := c.cachePath(request)
+	entry, err := c.readCache(cachePath)
+	if err != nil {
+		c.recordCacheError()
+	}
+	if entry != nil {
+		request = request.Clone(request.Context())
+		if entry.ETag != "" {
+			request.Header.Set("If-None-Match", entry.ETag)
+		} else if entry.LastModified != "" {
+			request.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+	return request, entry, cachePath
+}
+
+// assignCredential sends a request with the pool token chosen for it. The
+// token's name becomes the request's credential, so rate limits and cached
+// responses are kept per token.
+func (c *Coordinator) assignCredential(request *http.Request) *http.Request {
+	credential := c.pickCredential(request)
+	request = request.Clone(WithCredential(request.Context(), credential.name))
+	request.Header.Set("Authorization", credential.authorization)
+	return request
+}
+
+// pickCredential chooses the pool token for a request. Tokens that are above
+// the rate-limit reserve and not blocked are preferred: first the one this run
+// cached the response for the request with, as revalidating it costs no
+// budget, then the one with the most requests remaining. When every token is
+// exhausted, the one available soonest is chosen. Tokens the server rejected
+// are skipped.
+func (c *Coordinator) pickCredential(request *http.Request) poolCredential {
+	resource := rateResource(request)
+	now := c.now()
+
+	c.scheduleMu.Lock()
+	var candidates []int
+	blocked := make([]time.Time, len(c.pool))
+	for index, credential := range c.pool {
+		if c.rejected[credential.name] {
+			continue
+		}
+		candidates = append(candidates, index)
+		blocked[index] = c.resourceBlockedUntil[limitKey(credential.name, resource)]
+	}
+	c.scheduleMu.Unlock()
+	if len(candidates) == 0 {
+		return c.pool[0]
+	}
+
+	c.statsMu.Lock()
+	cached := ""
+	if c.cacheEnabled && request.Method == http.MethodGet {
+		cached = c.cachedBy[requestKey(request)]
+	}
+	var available []int
+	soonest := candidates[0]
+	var soonestUntil time.Time
+	for _, index := range candidates {
+		until := c.reserveReachedUntil(c.rates[limitKey(c.pool[index].name, resource)], now)
+		if blocked[index].After(until) {
+			until = blocked[index]
+		}
+		if !until.After(now) {
+			available = append(available, index)
+		} else if soonestUntil.IsZero() || until.Before(soonestUntil) {
+			soonest, soonestUntil = index, until
+		}
+	}
+	slices.SortStableFunc(available, func(a, b int) int {
+		if (c.pool[a].name == cached) != (c.pool[b].name == cached) {
+			if c.pool[a].name == cached {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(c.remainingBudget(c.pool[b], resource, now), c.remainingBudget(c.pool[a], resource, now))
+	})
+	chosen := soonest
+	if len(available) > 0 {
+		chosen = available[0]
+		// Count the request against the token now, so concurrent requests
+		// spread across the pool before responses report the new remaining
+		// budget.
+		key := limitKey(c.pool[chosen].name, resource)
+		if rate, ok := c.rates[key]; ok && rate.reset.After(now) && rate.remaining > 0 {
+			rate.remaining--
+			c.rates[key] = rate
+		}
+	}
+	c.statsMu.Unlock()
+	return c.pool[chosen]
+}
+
+// reject stops using a pool token the server answered with 401. It reports
+// whether the request can be resent with another token; the last usable token
+// is kept, so its error reaches the caller.
+func (c *Coordinator) reject(credential string) bool {
+	c.scheduleMu.Lock()
+	defer c.scheduleMu.Unlock()
+
+	inPool := false
+	usable := 0
+	for _, token := range c.pool {
+		if token.name == credential {
+			inPool = true
+		}
+		if !c.rejected[token.name] && token.name != credential {
+			usable++
+		}
+	}
+	if !inPool || c.rejected[credential] || usable == 0 {
+		return false
+	}
+	c.rejected[credential] = true
+	return true
+}
+
+// rememberCached notes the pool token a cached response was stored or
+// revalidated with, so later requests for it use the same token.
+func (c *Coordinator) rememberCached(request *http.Request) {
+	if len(c.pool) == 0 {
+		return
+	}
+	c.statsMu.Lock()
+	c.cachedBy[requestKey(request)] = requestCredential(request)
+	c.statsMu.Unlock()
+}
+
+// remainingBudget is how many requests a pool token has left for a resource.
+// A token without a response yet is assumed to have its full limit. The
+// caller holds statsMu.
+func (c *Coordinator) remainingBudget(credential poolCredential, resource string, now time.Time) int {
+	rate, known := c.rates[limitKey(credential.name, resource)]
+	if !known {
+		return math.MaxInt
+	}
+	if !rate.reset.After(now) {
+		return rate.limit
+	}
+	return rate.remaining
+}
+
 func (c *Coordinator) observeRequest(started time.Time) {
 	gate := c.gate.snapshot()
 	c.controller.observe(c.now().Sub(started), gate.inFlight >= gate.limit)
@@ -334,6 +600,10 @@ func (c *Coordinator) Stats() Stats {
 	}
 	c.statsMu.Unlock()
 
+	// The core limit is summed across credentials, as in RateLimits
+	core := c.RateLimits()["core"]
+	stats.RateLimit, stats.RateRemaining, stats.RateReset = core.Limit, core.Remaining, core.Reset
+
 	adaptive := c.controller.snapshot()
 	stats.InitialConcurrency = adaptive.initial
 	stats.CurrentConcurrency = adaptive.current
@@ -345,6 +615,126 @@ func (c *Coordinator) Stats() Stats {
 	return stats
 }
 
+// RateLimits returns the rate-limit state of every resource the coordinator
+// has seen a response for, keyed by resource name. When requests were made
+// with several credentials, their limits and remaining requests are added up
+// and Reset is the earliest reset.
+func (c *Coordinator) RateLimits() map[string]RateLimit {
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+
+	limits := make(map[string]RateLimit, len(c.rates))
+	for _, state := range c.rates {
+		limit, seen := limits[state.resource]
+		limit.Limit += state.limit
+		limit.Remaining += state.remaining
+		if !seen || state.reset.Before(limit.Reset) {
+			limit.Reset = state.reset
+		}
+		limits[state.resource] = limit
+	}
+	return limits
+}
+
+// CheckTokens asks the rate-limit endpoint about every pool token before the
+// scan. Tokens the server rejects with 401 are dropped from the pool, and the
+// limits of the others are recorded, so requests are spread by their real
+// budget. It returns how many tokens were dropped, and an error when none is
+// left. Other responses leave a token as it is, as servers with rate limiting
+// disabled answer the endpoint with 404.
+func (c *Coordinator) CheckTokens(ctx context.Context, rateLimitURL string) (int, error) {
+	dropped := 0
+	for _, credential := range c.pool {
+		request, err := http.NewRequestWithContext(WithCredential(ctx, credential.name), http.MethodGet, rateLimitURL, nil)
+		if err != nil {
+			return dropped, fmt.Errorf("create rate limit request: %w", err)
+		}
+		request.Header.Set("Authorization", credential.authorization)
+		request.Header.Set("Accept", "application/vnd.github+json")
+		response, err := c.transport.RoundTrip(request)
+		if err != nil {
+			return dropped, fmt.Errorf("check token: %w", err)
+		}
+		body, err := io.ReadAll(response.Body)
+		_ = response.Body.Close()
+		if err != nil {
+			return dropped, fmt.Errorf("read rate limit response: %w", err)
+		}
+
+		switch response.StatusCode {
+		case http.StatusUnauthorized:
+			c.scheduleMu.Lock()
+			c.rejected[credential.name] = true
+			c.scheduleMu.Unlock()
+			dropped++
+		case http.StatusOK:
+			var limits struct {
+				Resources map[string]struct {
+					Limit     int   `json:"limit"`
+					Remaining int   `json:"remaining"`
+					Reset     int64 `json:"reset"`
+				} `json:"resources"`
+			}
+			if err := json.Unmarshal(body, &limits); err != nil {
+				continue
+			}
+			c.statsMu.Lock()
+			for _, resource := range []string{"core", "search", "graphql"} {
+				if limit, ok := limits.Resources[resource]; ok && limit.Limit > 0 {
+					c.rates[limitKey(credential.name, resource)] = rateState{
+						resource:  resource,
+						limit:     limit.Limit,
+						remaining: limit.Remaining,
+						reset:     time.Unix(limit.Reset, 0),
+					}
+				}
+			}
+			c.statsMu.Unlock()
+		}
+	}
+	if len(c.pool) > 0 && dropped == len(c.pool) {
+		return dropped, errors.New("every token in the token file was rejected as invalid")
+	}
+	return dropped, nil
+}
+
+type credentialKey struct{}
+
+// WithCredential names the credential a request is made with. Transports that
+// rotate tokens, such as GitHub App installations, set it so rate limits and
+// cached responses follow the credential rather than each token.
+func WithCredential(ctx context.Context, credential string) context.Context {
+	return context.WithValue(ctx, credentialKey{}, credential)
+}
+
+// requestCredential identifies the credential of a request: the name set by
+// WithCredential, or otherwise a hash of its Authorization header.
+func requestCredential(request *http.Request) string {
+	if credential, ok := request.Context().Value(credentialKey{}).(string); ok && credential != "" {
+		return credential
+	}
+	authorization := request.Header.Get("Authorization")
+	if authorization == "" {
+		return ""
+	}
+	return tokenCredential(authorization)
+}
+
+// tokenCredential names a token credential without revealing the token
+func tokenCredential(authorization string) string {
+	hash := sha256.Sum256([]byte(authorization))
+	return "token-" + hex.EncodeToString(hash[:8])
+}
+
+// limitKey identifies the primary rate limit of one resource for one
+// credential.
+func limitKey(credential string, resource string) string {
+	if credential == "" {
+		return resource
+	}
+	return credential + "/" + resource
+}
+
 func ClearCache(cacheDir string) error {
 	if cacheDir == "" {
 		return errors.New("cache directory is required")
@@ -401,6 +791,9 @@ func (c *Coordinator) waitForTurn(ctx context.Context, request *http.Request, pr
 	if c.blockedUntil.After(start) {
 		start = c.blockedUntil
 	}
+	if blockedUntil := c.resourceBlockedUntil[limitKey(requestCredential(request), rateResource(request))]; blockedUntil.After(start) {
+		start = blockedUntil
+	}
 	if primaryCandidate {
 		if primaryBlockedUntil := c.primaryBlockedUntil(request, now); primaryBlockedUntil.After(start) {
 			start = primaryBlockedUntil
@@ -417,7 +810,13 @@ func (c *Coordinator) primaryBlockedUntil(request *http.Request, now time.Time)
 	c.statsMu.Lock()
 	defer c.statsMu.Unlock()
 
-	rate := c.rates[rateResource(request)]
+	return c.reserveReachedUntil(c.rates[limitKey(requestCredential(request), rateResource(request))], now)
+}
+
+// reserveReachedUntil returns when a credential may use a resource again once
+// its remaining requests are down to the reserve, or the zero time while it is
+// above the reserve. The caller holds statsMu.
+func (c *Coordinator) reserveReachedUntil(rate rateState, now time.Time) time.Time {
 	if rate.limit <= 0 || !rate.reset.After(now) {
 		return time.Time{}
 	}
@@ -429,7 +828,7 @@ func (c *Coordinator) primaryBlockedUntil(request *http.Request, now time.Time)
 }
 
 func (c *Coordinator) wait(ctx context.Context, delay time.Duration) error {
-	if delay <= 0 {
+	if delay <= 0 || c.replaying {
 		return nil
 	}
 	c.statsMu.Lock()
@@ -470,6 +869,22 @@ func (c *Coordinator) shouldRetry(request *http.Request, response *http.Response
 	return strings.Contains(message, "secondary rate limit") || strings.Contains(message, "abuse detection")
 }
 
+// rejectsAPIVersion reports whether a server refused a request because of its
+// API version header, as GitHub Enterprise Server releases older than the
+// requested version do. The response body is kept readable otherwise.
+func rejectsAPIVersion(request *http.Request, response *http.Response) bool {
+	if response.StatusCode != http.StatusBadRequest || request.Header.Get(APIVersionHeader) == "" {
+		return false
+	}
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return false
+	}
+	response.Body = io.NopCloser(bytes.NewReader(body))
+	message := strings.ToLower(string(body))
+	return strings.Contains(message, "api version") || strings.Contains(message, strings.ToLower(APIVersionHeader))
+}
+
 func (c *Coordinator) rateLimitDelay(response *http.Response, attempt int) time.Duration {
 	if retryAfter, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil && retryAfter > 0 {
 		return time.Duration(retryAfter) * time.Second
@@ -486,16 +901,32 @@ func (c *Coordinator) rateLimitDelay(response *http.Response, attempt int) time.
 	return base + c.jitter(base/4)
 }
 
-func (c *Coordinator) blockFor(delay time.Duration) {
+// blockFor delays later requests. An empty key blocks every request, as
+// secondary limits are shared; otherwise only requests with that limitKey
+// are blocked.
+func (c *Coordinator) blockFor(key string, delay time.Duration) {
 	c.scheduleMu.Lock()
 	until := c.now().Add(delay)
-	if until.After(c.blockedUntil) {
-		c.blockedUntil = until
+	if key == "" {
+		if until.After(c.blockedUntil) {
+			c.blockedUntil = until
+		}
+	} else if until.After(c.resourceBlockedUntil[key]) {
+		c.resourceBlockedUntil[key] = until
 	}
 	c.scheduleMu.Unlock()
 }
 
-func (c *Coordinator) updateRateState(response *http.Response) {
+// blockedResource returns the limitKey of the primary limit a response
+// reports as exhausted, or "" for secondary limits and server errors.
+func blockedResource(request *http.Request, response *http.Response) string {
+	if response.Header.Get("Retry-After") == "" && response.Header.Get("X-RateLimit-Remaining") == "0" {
+		return limitKey(requestCredential(request), rateResource(request))
+	}
+	return ""
+}
+
+func (c *Coordinator) updateRateState(request *http.Request, response *http.Response) {
 	limit, limitErr := strconv.Atoi(response.Header.Get("X-RateLimit-Limit"))
 	remaining, remainingErr := strconv.Atoi(response.Header.Get("X-RateLimit-Remaining"))
 	reset, resetErr := strconv.ParseInt(response.Header.Get("X-RateLimit-Reset"), 10, 64)
@@ -507,15 +938,10 @@ func (c *Coordinator) updateRateState(response *http.Response) {
 	if resource == "" {
 		resource = "core"
 	}
-	state := rateState{limit: limit, remaining: remaining, reset: time.Unix(reset, 0)}
+	state := rateState{resource: resource, limit: limit, remaining: remaining, reset: time.Unix(reset, 0)}
 
 	c.statsMu.Lock()
-	c.rates[resource] = state
-	if resource == "core" {
-		c.stats.RateLimit = limit
-		c.stats.RateRemaining = remaining
-		c.stats.RateReset = state.reset
-	}
+	c.rates[limitKey(requestCredential(request), resource)] = state
 	c.statsMu.Unlock()
 }
 
@@ -558,15 +984,35 @@ func (c *Coordinator) recordCacheError() {
 }
 
 func (c *Coordinator) cachePath(request *http.Request) string {
-	authHash := sha256.Sum256([]byte(request.Header.Get("Authorization")))
-	requestHash := sha256.Sum256([]byte(request.Method + "\n" + request.URL.String()))
+	identity := request.Header.Get("Authorization")
+	if credential, ok := request.Context().Value(credentialKey{}).(string); ok && credential != "" {
+		identity = "credential:" + credential
+	}
+	authHash := sha256.Sum256([]byte(identity))
 	return filepath.Join(
 		c.cacheDir,
+		cacheHostDir(request.URL.Host),
 		hex.EncodeToString(authHash[:]),
-		hex.EncodeToString(requestHash[:])+".json",
+		requestKey(request)+".json",
 	)
 }
 
+// requestKey identifies a request regardless of the credential it is made
+// with.
+func requestKey(request *http.Request) string {
+	hash := sha256.Sum256([]byte(request.Method + "\n" + request.URL.String()))
+	return hex.EncodeToString(hash[:])
+}
+
+// cacheHostDir names the cache namespace for an API host, so responses from
+// GitHub.com and each Enterprise Server instance are kept apart.
+func cacheHostDir(host string) string {
+	if host == "" {
+		return "default"
+	}
+	return strings.ReplaceAll(strings.ToLower(host), ":", "_")
+}
+
 func ensureCacheMarker(cacheDir string) error {
 	path := filepath.Join(cacheDir, cacheMarker)
 	if _, err := os.Stat(path); err == nil {
@@ -683,6 +1129,9 @@ func endpointFamily(request *http.Request) string {
 	if strings.HasSuffix(request.URL.Path, "/graphql") {
 		return "graphql"
 	}
+	if searchType, ok := searchPath(request.URL.Path); ok {
+		return "search-" + searchType
+	}
 	parts := strings.Split(strings.Trim(request.URL.Path, "/"), "/")
 	for index, part := range parts {
 		if part == "members" || part == "repos" || part == "commits" || part == "issues" || part == "comments" {
@@ -699,9 +1148,23 @@ func rateResource(request *http.Request) string {
 	if strings.HasSuffix(request.URL.Path, "/graphql") {
 		return "graphql"
 	}
+	if _, ok := searchPath(request.URL.Path); ok {
+		return "search"
+	}
 	return "core"
 }
 
+// searchPath reports whether a REST path is a search endpoint and returns the
+// searched type. GitHub Enterprise Server paths carry an /api/v3 prefix.
+func searchPath(path string) (string, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimPrefix(path, "/api/v3"), "/search/")
+	if !ok {
+		return "", false
+	}
+	searchType, _, _ := strings.Cut(rest, "/")
+	return searchType, true
+}
+
 func sleepContext(ctx context.Context, delay time.Duration) error {
 	timer := time.NewTimer(delay)
 	defer timer.Stop()
@@ -7,6 +7,9 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -243,6 +246,37 @@ func TestCoordinatorRetriesGraphQLQueriesButNotMutations(t *testing.T) {
 	}
 }
 
+func TestCoordinatorDoesNotRetryMembershipChanges(t *testing.T) {
+	for _, method := range []string{http.MethodDelete, http.MethodPut} {
+		t.Run(method, func(t *testing.T) {
+			requests := 0
+			coordinator, err := NewCoordinator(Config{
+				Transport: roundTripFunc(func(_ *http.Request) (*http.Response, error) {
+					requests++
+					return response(http.StatusBadGateway, `{}`, nil), nil
+				}),
+				MaxConcurrency:   1,
+				RateLimitReserve: 0.1,
+				Sleep:            noSleep,
+				Jitter:           noJitter,
+			})
+			if err != nil {
+				t.Fatalf("NewCoordinator returned error: %v", err)
+			}
+
+			request, _ := http.NewRequest(method, "https://api.github.com/orgs/example/members/octocat", nil)
+			result, requestErr := coordinator.RoundTrip(request)
+			if requestErr != nil {
+				t.Fatalf("RoundTrip returned error: %v", requestErr)
+			}
+			_ = result.Body.Close()
+			if requests != 1 {
+				t.Fatalf("expected a single attempt, got %d", requests)
+			}
+		})
+	}
+}
+
 func TestCoordinatorBoundsConcurrency(t *testing.T) {
 	var active atomic.Int32
 	var maximum atomic.Int32
@@ -407,6 +441,212 @@ func TestCoordinatorIsolatesCacheByAuthentication(t *testing.T) {
 	}
 }
 
+func TestCoordinatorIsolatesCacheByHost(t *testing.T) {
+	requests := 0
+	transport := roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		requests++
+		if request.URL.Host == "github.example.com" && request.Header.Get("If-None-Match") != "" {
+			t.Fatal("enterprise host received github.com's validator")
+		}
+		if request.Header.Get("If-None-Match") != "" {
+			return response(http.StatusNotModified, "", nil), nil
+		}
+		return response(http.StatusOK, `{}`, map[string]string{"ETag": `"` + request.URL.Host + `"`}), nil
+	})
+	cacheDir := t.TempDir()
+	coordinator, err := NewCoordinator(Config{
+		Transport:        transport,
+		CacheDir:         cacheDir,
+		CacheEnabled:     true,
+		MaxConcurrency:   1,
+		RateLimitReserve: 0.1,
+		Sleep:            noSleep,
+		Jitter:           noJitter,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator returned error: %v", err)
+	}
+
+	for _, url := range []string{"https://api.github.com/user", "https://github.example.com/api/v3/user", "https://api.github.com/user"} {
+		request, _ := http.NewRequest(http.MethodGet, url, nil)
+		result, requestErr := coordinator.RoundTrip(request)
+		if requestErr != nil {
+			t.Fatalf("RoundTrip returned error: %v", requestErr)
+		}
+		_ = result.Body.Close()
+	}
+	if requests != 3 || coordinator.Stats().CacheHits != 1 {
+		t.Fatalf("unexpected cache behavior: requests=%d stats=%+v", requests, coordinator.Stats())
+	}
+	for _, host := range []string{"api.github.com", "github.example.com"} {
+		if _, err := os.Stat(filepath.Join(cacheDir, host)); err != nil {
+			t.Fatalf("expected a cache namespace for %s: %v", host, err)
+		}
+	}
+}
+
+func TestCoordinatorDropsRejectedAPIVersion(t *testing.T) {
+	var versions []string
+	transport := roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		version := request.Header.Get(APIVersionHeader)
+		versions = append(versions, version)
+		if request.Body != nil {
+			body, _ := io.ReadAll(request.Body)
+			if string(body) != `{"query":"query{viewer{login}}"}` {
+				t.Fatalf("request body = %q", body)
+			}
+		}
+		if version != "" {
+			return response(http.StatusBadRequest, `{"message":"Unsupported API version 2026-03-10"}`, nil), nil
+		}
+		return response(http.StatusOK, `{}`, nil), nil
+	})
+	coordinator, err := NewCoordinator(Config{
+		Transport:        transport,
+		MaxConcurrency:   1,
+		RateLimitReserve: 0.1,
+		Sleep:            noSleep,
+		Jitter:           noJitter,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator returned error: %v", err)
+	}
+
+	for _, method := range []string{http.MethodPost, http.MethodGet} {
+		var body io.Reader
+		if method == http.MethodPost {
+			body = strings.NewReader(`{"query":"query{viewer{login}}"}`)
+		}
+		request, _ := http.NewRequest(method, "https://github.example.com/api/graphql", body)
+		request.Header.Set(APIVersionHeader, APIVersion)
+		result, requestErr := coordinator.RoundTrip(request)
+		if requestErr != nil {
+			t.Fatalf("RoundTrip returned error: %v", requestErr)
+		}
+		if result.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d", result.StatusCode)
+		}
+		_ = result.Body.Close()
+	}
+	if strings.Join(versions, ",") != "2026-03-10,," {
+		t.Fatalf("API versions sent = %q", versions)
+	}
+	if stats := coordinator.Stats(); !stats.APIVersionDropped || stats.Retries != 0 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
+
+func TestCoordinatorKeepsOtherBadRequests(t *testing.T) {
+	coordinator, err := NewCoordinator(Config{
+		Transport: roundTripFunc(func(_ *http.Request) (*http.Response, error) {
+			return response(http.StatusBadRequest, `{"message":"Problems parsing JSON"}`, nil), nil
+		}),
+		MaxConcurrency:   1,
+		RateLimitReserve: 0.1,
+		Sleep:            noSleep,
+		Jitter:           noJitter,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator returned error: %v", err)
+	}
+	request, _ := http.NewRequest(http.MethodGet, "https://api.github.com/user", nil)
+	request.Header.Set(APIVersionHeader, APIVersion)
+	result, err := coordinator.RoundTrip(request)
+	if err != nil {
+		t.Fatalf("RoundTrip returned error: %v", err)
+	}
+	body, _ := io.ReadAll(result.Body)
+	if result.StatusCode != http.StatusBadRequest || !strings.Contains(string(body), "Problems parsing JSON") {
+		t.Fatalf("response = %d %s", result.StatusCode, body)
+	}
+	if coordinator.Stats().APIVersionDropped {
+		t.Fatal("unrelated bad request dropped the API version")
+	}
+}
+
+func TestCoordinatorTracksRateLimitsPerCredential(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var delays []time.Duration
+	coordinator, err := NewCoordinator(Config{
+		Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+			if request.Header.Get("Authorization") == "token installation" {
+				return response(http.StatusOK, `{}`, map[string]string{
+					"X-RateLimit-Limit":     "15000",
+					"X-RateLimit-Remaining": "14000",
+					"X-RateLimit-Reset":     "1600",
+				}), nil
+			}
+			return response(http.StatusOK, `{}`, map[string]string{
+				"X-RateLimit-Limit":     "5000",
+				"X-RateLimit-Remaining": "0",
+				"X-RateLimit-Reset":     "1600",
+			}), nil
+		}),
+		MaxConcurrency:   1,
+		RateLimitReserve: 0.1,
+		Now:              func() time.Time { return now },
+		Sleep: func(_ context.Context, delay time.Duration) error {
+			delays = append(delays, delay)
+			return nil
+		},
+		Jitter: noJitter,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator returned error: %v", err)
+	}
+
+	for _, token := range []string{"token personal", "token installation", "token personal"} {
+		request, _ := http.NewRequest(http.MethodGet, "https://api.github.com/orgs/example/repos", nil)
+		request.Header.Set("Authorization", token)
+		result, requestErr := coordinator.RoundTrip(request)
+		if requestErr != nil {
+			t.Fatalf("RoundTrip returned error: %v", requestErr)
+		}
+		_ = result.Body.Close()
+	}
+	if len(delays) != 1 || delays[0] != 601*time.Second {
+		t.Fatalf("delays = %v, want only the exhausted credential to wait", delays)
+	}
+	if core := coordinator.RateLimits()["core"]; core.Limit != 20000 || core.Remaining != 14000 {
+		t.Fatalf("core rate limit = %+v, want the sum of both credentials", core)
+	}
+}
+
+func TestCoordinatorKeepsCacheAcrossTokensOfOneCredential(t *testing.T) {
+	transport := roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		if request.Header.Get("If-None-Match") == `"v1"` {
+			return response(http.StatusNotModified, "", nil), nil
+		}
+		return response(http.StatusOK, `{}`, map[string]string{"ETag": `"v1"`}), nil
+	})
+	coordinator, err := NewCoordinator(Config{
+		Transport:        transport,
+		CacheDir:         t.TempDir(),
+		CacheEnabled:     true,
+		MaxConcurrency:   1,
+		RateLimitReserve: 0.1,
+		Sleep:            noSleep,
+		Jitter:           noJitter,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator returned error: %v", err)
+	}
+
+	ctx := WithCredential(context.Background(), "app-1-installation-2")
+	for _, token := range []string{"token first", "token refreshed"} {
+		request, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.github.com/orgs/example/members", nil)
+		request.Header.Set("Authorization", token)
+		result, requestErr := coordinator.RoundTrip(request)
+		if requestErr != nil {
+			t.Fatalf("RoundTrip returned error: %v", requestErr)
+		}
+		_ = result.Body.Close()
+	}
+	if coordinator.Stats().CacheHits != 1 {
+		t.Fatalf("stats = %+v, want the refreshed token to reuse the cache", coordinator.Stats())
+	}
+}
+
 func TestCoordinatorRefusesNonEmptyUnrecognizedCacheDirectory(t *testing.T) {
 	cacheDir := t.TempDir()
 	if err := os.WriteFile(filepath.Join(cacheDir, "keep"), []byte("important"), 0o600); err != nil {
@@ -442,3 +682,399 @@ func noSleep(_ context.Context, _ time.Duration) error {
 func noJitter(_ time.Duration) time.Duration {
 	return 0
 }
+
+func TestCoordinatorReportsRateLimitsByResource(t *testing.T) {
+	coordinator, err := NewCoordinator(Config{
+		Transport: roundTripFunc(func(_ *http.Request) (*http.Response, error) {
+			return response(http.StatusOK, `{}`, map[string]string{
+				"X-RateLimit-Limit":     "5000",
+				"X-RateLimit-Remaining": "4990",
+				"X-RateLimit-Reset":     "1100",
+				"X-RateLimit-Resource":  "graphql",
+			}), nil
+		}),
+		MaxConcurrency: 1,
+		Jitter:         noJitter,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator returned error: %v", err)
+	}
+
+	request, _ := http.NewRequest(http.MethodPost, "https://api.github.com/graphql", bytes.NewBufferString(`{"query":"query{viewer{login}}"}`))
+	result, err := coordinator.RoundTrip(request)
+	if err != nil {
+		t.Fatalf("RoundTrip returned error: %v", err)
+	}
+	_ = result.Body.Close()
+
+	limits := coordinator.RateLimits()
+	if got := limits["graphql"]; got.Limit != 5000 || got.Remaining != 4990 || !got.Reset.Equal(time.Unix(1100, 0)) {
+		t.Fatalf("graphql rate limit = %#v", got)
+	}
+	if _, ok := limits["core"]; ok {
+		t.Fatal("core rate limit should be unknown")
+	}
+}
+
+func TestCoordinatorBlocksOnlyExhaustedSearchResource(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var delays []time.Duration
+	coordinator, err := NewCoordinator(Config{
+		Transport: roundTripFunc(func(_ *http.Request) (*http.Response, error) {
+			return response(http.StatusOK, `{}`, nil), nil
+		}),
+		MaxConcurrency: 1,
+		Now:            func() time.Time { return now },
+		Sleep: func(_ context.Context, delay time.Duration) error {
+			delays = append(delays, delay)
+			return nil
+		},
+		Jitter: noJitter,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator returned error: %v", err)
+	}
+
+	exhausted := response(http.StatusForbidden, `{}`, map[string]string{"X-RateLimit-Remaining": "0"})
+	search, _ := http.NewRequest(http.MethodGet, "https://api.github.com/search/commits?q=author:octocat", nil)
+	resource := blockedResource(search, exhausted)
+	if resource != "search" {
+		t.Fatalf("blocked resource = %q, want search", resource)
+	}
+	coordinator.blockFor(resource, time.Minute)
+
+	core, _ := http.NewRequest(http.MethodGet, "https://api.github.com/repos/example/widgets", nil)
+	for _, request := range []*http.Request{core, search} {
+		result, requestErr := coordinator.RoundTrip(request)
+		if requestErr != nil {
+			t.Fatalf("RoundTrip returned error: %v", requestErr)
+		}
+		_ = result.Body.Close()
+	}
+	if len(delays) != 1 || delays[0] != time.Minute {
+		t.Fatalf("delays = %v, want only the search request to wait", delays)
+	}
+	if got := coordinator.Stats().EndpointCounts["search-commits"]; got != 1 {
+		t.Fatalf("search-commits requests = %d, want 1", got)
+	}
+
+	secondary := response(http.StatusForbidden, `{}`, map[string]string{"Retry-After": "60"})
+	if resource := blockedResource(search, secondary); resource != "" {
+		t.Fatalf("secondary limit blocked resource = %q, want every resource", resource)
+	}
+}
+
+func TestCoordinatorRoutesPoolRequestsToTokenWithMostBudget(t *testing.T) {
+	remaining := map[string]int{"token a": 100, "token b": 4000, "token c": 2500}
+	var sent []string
+	coordinator, err := NewCoordinator(Config{
+		Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+			authorization := request.Header.Get("Authorization")
+			sent = append(sent, authorization)
+			remaining[authorization]--
+			return response(http.StatusOK, `{}`, map[string]string{
+				"X-RateLimit-Limit":     "5000",
+				"X-RateLimit-Remaining": strconv.Itoa(remaining[authorization]),
+				"X-RateLimit-Reset":     "1600",
+			}), nil
+		}),
+		MaxConcurrency:   1,
+		RateLimitReserve: 0.01,
+		Now:              func() time.Time { return time.Unix(1000, 0) },
+		Sleep:            noSleep,
+		Jitter:           noJitter,
+		Tokens:           []string{"a", "b", "c", "a"},
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator returned error: %v", err)
+	}
+
+	for range 4 {
+		request, _ := http.NewRequest(http.MethodGet, "https://api.github.com/orgs/example/repos", nil)
+		request.Header.Set("Authorization", "token gh")
+		result, requestErr := coordinator.RoundTrip(request)
+		if requestErr != nil {
+			t.Fatalf("RoundTrip returned error: %v", requestErr)
+		}
+		_ = result.Body.Close()
+	}
+	want := []string{"token a", "token b", "token c", "token b"}
+	if !slices.Equal(sent, want) {
+		t.Fatalf("tokens sent = %v, want untried tokens first and then the one with most budget %v", sent, want)
+	}
+	if core := coordinator.Stats(); core.RateLimit != 15000 || core.RateRemaining != 99+3998+2499 {
+		t.Fatalf("core rate limit = %d/%d, want the pool's total", core.RateRemaining, core.RateLimit)
+	}
+}
+
+func TestCoordinatorWaitsWhenEveryPoolTokenReachesReserve(t *testing.T) {
+	resets := map[string]string{"token a": "1600", "token b": "1300"}
+	var sent []string
+	var delays []time.Duration
+	coordinator, err := NewCoordinator(Config{
+		Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+			authorization := request.Header.Get("Authorization")
+			sent = append(sent, authorization)
+			return response(http.StatusOK, `{}`, map[string]string{
+				"X-RateLimit-Limit":     "5000",
+				"X-RateLimit-Remaining": "100",
+				"X-RateLimit-Reset":     resets[authorization],
+			}), nil
+		}),
+		MaxConcurrency:   1,
+		RateLimitReserve: 0.1,
+		Now:              func() time.Time { return time.Unix(1000, 0) },
+		Sleep: func(_ context.Context, delay time.Duration) error {
+			delays = append(delays, delay)
+			return nil
+		},
+		Jitter: noJitter,
+		Tokens: []string{"a", "b"},
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator returned error: %v", err)
+	}
+
+	for range 3 {
+		request, _ := http.NewRequest(http.MethodGet, "https://api.github.com/orgs/example/repos", nil)
+		result, requestErr := coordinator.RoundTrip(request)
+		if requestErr != nil {
+			t.Fatalf("RoundTrip returned error: %v", requestErr)
+		}
+		_ = result.Body.Close()
+	}
+	if want := []string{"token a", "token b", "token b"}; !slices.Equal(sent, want) {
+		t.Fatalf("tokens sent = %v, want %v", sent, want)
+	}
+	if len(delays) != 1 || delays[0] != 301*time.Second {
+		t.Fatalf("delays = %v, want one wait for the token that resets first", delays)
+	}
+}
+
+func TestCoordinatorMovesExhaustedPoolRequestToAnotherToken(t *testing.T) {
+	var sent []string
+	var delays []time.Duration
+	coordinator, err := NewCoordinator(Config{
+		Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+			authorization := request.Header.Get("Authorization")
+			sent = append(sent, authorization)
+			if authorization == "token a" {
+				return response(http.StatusForbidden, `{"message":"API rate limit exceeded"}`, map[string]string{
+					"X-RateLimit-Limit":     "5000",
+					"X-RateLimit-Remaining": "0",
+					"X-RateLimit-Reset":     "1600",
+				}), nil
+			}
+			return response(http.StatusOK, `{}`, nil), nil
+		}),
+		MaxConcurrency:   1,
+		RateLimitReserve: 0.1,
+		Now:              func() time.Time { return time.Unix(1000, 0) },
+		Sleep: func(_ context.Context, delay time.Duration) error {
+			delays = append(delays, delay)
+			return nil
+		},
+		Jitter: noJitter,
+		Tokens: []string{"a", "b"},
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator returned error: %v", err)
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, "https://api.github.com/orgs/example/repos", nil)
+	result, err := coordinator.RoundTrip(request)
+	if err != nil {
+		t.Fatalf("RoundTrip returned error: %v", err)
+	}
+	_ = result.Body.Close()
+	if result.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want the retry with another token to succeed", result.StatusCode)
+	}
+	if want := []string{"token a", "token b"}; !slices.Equal(sent, want) {
+		t.Fatalf("tokens sent = %v, want %v", sent, want)
+	}
+	if len(delays) != 0 {
+		t.Fatalf("delays = %v, want no wait while another token has budget", delays)
+	}
+}
+
+func TestCoordinatorPrefersPoolTokenWithCachedResponse(t *testing.T) {
+	var sent []string
+	coordinator, err := NewCoordinator(Config{
+		Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+			authorization := request.Header.Get("Authorization")
+			sent = append(sent, authorization)
+			if request.Header.Get("If-None-Match") == `"v1"` {
+				return response(http.StatusNotModified, "", nil), nil
+			}
+			return response(http.StatusOK, `{}`, map[string]string{
+				"ETag":                  `"v1"`,
+				"X-RateLimit-Limit":     "5000",
+				"X-RateLimit-Remaining": "1000",
+				"X-RateLimit-Reset":     "1600",
+			}), nil
+		}),
+		CacheDir:         t.TempDir(),
+		CacheEnabled:     true,
+		MaxConcurrency:   1,
+		RateLimitReserve: 0.1,
+		Now:              func() time.Time { return time.Unix(1000, 0) },
+		Sleep:            noSleep,
+		Jitter:           noJitter,
+		Tokens:           []string{"a", "b"},
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator returned error: %v", err)
+	}
+
+	for range 2 {
+		request, _ := http.NewRequest(http.MethodGet, "https://api.github.com/orgs/example/members", nil)
+		result, requestErr := coordinator.RoundTrip(request)
+		if requestErr != nil {
+			t.Fatalf("RoundTrip returned error: %v", requestErr)
+		}
+		_ = result.Body.Close()
+	}
+	if want := []string{"token a", "token a"}; !slices.Equal(sent, want) {
+		t.Fatalf("tokens sent = %v, want the token with the cached response %v", sent, want)
+	}
+	if hits := coordinator.Stats().CacheHits; hits != 1 {
+		t.Fatalf("cache hits = %d, want 1", hits)
+	}
+}
+
+func TestCoordinatorDropsPoolTokenRejectedAsUnauthorized(t *testing.T) {
+	var sent []string
+	coordinator, err := NewCoordinator(Config{
+		Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+			authorization := request.Header.Get("Authorization")
+			sent = append(sent, authorization)
+			if authorization == "token a" {
+				return response(http.StatusUnauthorized, `{"message":"Bad credentials"}`, nil), nil
+			}
+			return response(http.StatusOK, `{}`, nil), nil
+		}),
+		MaxConcurrency: 1,
+		Now:            func() time.Time { return time.Unix(1000, 0) },
+		Sleep:          noSleep,
+		Jitter:         noJitter,
+		Tokens:         []string{"a", "b"},
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator returned error: %v", err)
+	}
+
+	for range 2 {
+		request, _ := http.NewRequest(http.MethodGet, "https://api.github.com/orgs/example/repos", nil)
+		result, requestErr := coordinator.RoundTrip(request)
+		if requestErr != nil {
+			t.Fatalf("RoundTrip returned error: %v", requestErr)
+		}
+		_ = result.Body.Close()
+		if result.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d, want the request resent with the other token", result.StatusCode)
+		}
+	}
+	if want := []string{"token a", "token b", "token b"}; !slices.Equal(sent, want) {
+		t.Fatalf("tokens sent = %v, want the rejected token dropped %v", sent, want)
+	}
+}
+
+func TestCoordinatorReturnsUnauthorizedWhenNoPoolTokenIsLeft(t *testing.T) {
+	coordinator, err := NewCoordinator(Config{
+		Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+			return response(http.StatusUnauthorized, `{"message":"Bad credentials"}`, nil), nil
+		}),
+		MaxConcurrency: 1,
+		Sleep:          noSleep,
+		Jitter:         noJitter,
+		Tokens:         []string{"a", "b"},
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator returned error: %v", err)
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, "https://api.github.com/orgs/example/repos", nil)
+	result, err := coordinator.RoundTrip(request)
+	if err != nil {
+		t.Fatalf("RoundTrip returned error: %v", err)
+	}
+	_ = result.Body.Close()
+	if result.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", result.StatusCode)
+	}
+}
+
+func TestCoordinatorCheckTokensDropsRejectedTokensAndRecordsLimits(t *testing.T) {
+	var sent []string
+	coordinator, err := NewCoordinator(Config{
+		Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+			authorization := request.Header.Get("Authorization")
+			if request.URL.Path == "/rate_limit" {
+				switch authorization {
+				case "token a":
+					return response(http.StatusUnauthorized, `{"message":"Bad credentials"}`, nil), nil
+				case "token b":
+					return response(http.StatusOK, `{"resources":{"core":{"limit":5000,"remaining":10,"reset":1600}}}`, nil), nil
+				default:
+					return response(http.StatusOK, `{"resources":{"core":{"limit":5000,"remaining":4000,"reset":1600},"search":{"limit":30,"remaining":30,"reset":1060}}}`, nil), nil
+				}
+			}
+			sent = append(sent, authorization)
+			return response(http.StatusOK, `{}`, nil), nil
+		}),
+		MaxConcurrency: 1,
+		Now:            func() time.Time { return time.Unix(1000, 0) },
+		Sleep:          noSleep,
+		Jitter:         noJitter,
+		Tokens:         []string{"a", "b", "c"},
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator returned error: %v", err)
+	}
+
+	dropped, err := coordinator.CheckTokens(context.Background(), "https://api.github.com/rate_limit")
+	if err != nil {
+		t.Fatalf("CheckTokens returned error: %v", err)
+	}
+	if dropped != 1 {
+		t.Fatalf("dropped = %d, want 1", dropped)
+	}
+	if limits := coordinator.RateLimits(); limits["core"].Remaining != 4010 || limits["search"].Limit != 30 {
+		t.Fatalf("rate limits = %+v, want the limits reported for the accepted tokens", limits)
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, "https://api.github.com/orgs/example/repos", nil)
+	result, err := coordinator.RoundTrip(request)
+	if err != nil {
+		t.Fatalf("RoundTrip returned error: %v", err)
+	}
+	_ = result.Body.Close()
+	if want := []string{"token c"}; !slices.Equal(sent, want) {
+		t.Fatalf("tokens sent = %v, want the token with most budget %v", sent, want)
+	}
+}
+
+func TestCoordinatorCheckTokensFailsWhenEveryTokenIsRejected(t *testing.T) {
+	coordinator, err := NewCoordinator(Config{
+		Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+			return response(http.StatusUnauthorized, `{"message":"Bad credentials"}`, nil), nil
+		}),
+		MaxConcurrency: 1,
+		Tokens:         []string{"a", "b"},
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator returned error: %v", err)
+	}
+
+	if _, err := coordinator.CheckTokens(context.Background(), "https://api.github.com/rate_limit"); err == nil || !strings.Contains(err.Error(), "every token") {
+		t.Fatalf("err = %v, want every token rejected", err)
+	}
+}
+
+func TestNewCoordinatorRejectsEmptyPoolToken(t *testing.T) {
+	_, err := NewCoordinator(Config{MaxConcurrency: 1, Tokens: []string{"a", " "}})
+	if err == nil || !strings.Contains(err.Error(), "empty token") {
+		t.Fatalf("err = %v, want empty token error", err)
+	}
+}
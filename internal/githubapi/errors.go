@@ -14,3 +14,25 @@ func IsRepositoryUnavailable(err error) bool {
 	}
 	return httpError.StatusCode == http.StatusNotFound || httpError.StatusCode == http.StatusConflict
 }
+
+// IsPartialAliasError reports whether a GraphQL error only concerns aliased
+// fields of a batched query, so the data returned for other aliases is usable.
+func IsPartialAliasError(err error, aliases map[string]struct{}) bool {
+	var gqlError api.GQLError
+	if !errors.As(err, &gqlError) || len(gqlError.Errors) == 0 {
+		return false
+	}
+	for _, item := range gqlError.Errors {
+		if len(item.Path) == 0 {
+			return false
+		}
+		alias, ok := item.Path[0].(string)
+		if !ok {
+			return false
+		}
+		if _, ok := aliases[alias]; !ok {
+			return false
+		}
+	}
+	return true
+}
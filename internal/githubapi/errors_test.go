@@ -19,3 +19,18 @@ func TestIsRepositoryUnavailable(t *testing.T) {
 		t.Fatal("403 must not be treated as repository-unavailable")
 	}
 }
+
+func TestIsPartialAliasError(t *testing.T) {
+	aliases := map[string]struct{}{"repo0": {}}
+	partial := api.GQLError{Errors: []api.GQLErrorItem{{Path: []interface{}{"repo0", "discussions"}}}}
+	if !IsPartialAliasError(fmt.Errorf("wrapped: %w", partial), aliases) {
+		t.Fatal("expected alias error to be partial")
+	}
+	unknown := api.GQLError{Errors: []api.GQLErrorItem{{Path: []interface{}{"repo1"}}}}
+	if IsPartialAliasError(unknown, aliases) {
+		t.Fatal("unknown alias must not be treated as partial")
+	}
+	if IsPartialAliasError(fmt.Errorf("boom"), aliases) {
+		t.Fatal("non-GraphQL error must not be treated as partial")
+	}
+}
@@ -1,34 +1,237 @@
 package githubapi
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"iter"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/cli/go-gh/pkg/api"
 	"github.com/ssulei7/gh-dormant-users/internal/header"
 )
 
-func GetAll[T any](client api.RESTClient, url string) ([]T, error) {
-	var all []T
-	for url != "" {
-		response, err := client.Request(http.MethodGet, url, nil)
-		if err != nil {
-			return nil, fmt.Errorf("request %s: %w", url, err)
-		}
+// GetAll reads every page of a listing. When the first page links to a
+// numbered last page, the remaining pages are requested concurrently and
+// reassembled in order.
+func GetAll[T any](ctx context.Context, client api.RESTClient, url string) ([]T, error) {
+	return getPages(ctx, client, url, decodeArray[T], nil)
+}
 
+// GetAllWhile follows pagination like GetAll but stops after any page for
+// which more returns false. Pages are requested one at a time so that nothing
+// past the stopping point is fetched. A nil more reads every page.
+func GetAllWhile[T any](ctx context.Context, client api.RESTClient, url string, more func(page []T) bool) ([]T, error) {
+	return getPages(ctx, client, url, decodeArray[T], more)
+}
+
+// GetAllWrapped follows pagination for endpoints that wrap each page in an
+// object, such as {"total_seats": 2, "seats": [...]}, collecting the items
+// in the named field.
+func GetAllWrapped[T any](ctx context.Context, client api.RESTClient, url string, field string) ([]T, error) {
+	decode := func(body io.Reader) ([]T, error) {
+		var envelope map[string]json.RawMessage
+		if err := json.NewDecoder(body).Decode(&envelope); err != nil {
+			return nil, err
+		}
+		items, ok := envelope[field]
+		if !ok {
+			return nil, fmt.Errorf("response has no %q field", field)
+		}
 		var page []T
-		decodeErr := json.NewDecoder(response.Body).Decode(&page)
-		closeErr := response.Body.Close()
-		if decodeErr != nil {
-			return nil, fmt.Errorf("decode %s: %w", url, decodeErr)
+		if err := json.Unmarshal(items, &page); err != nil {
+			return nil, err
 		}
-		if closeErr != nil {
-			return nil, fmt.Errorf("close %s response: %w", url, closeErr)
+		return page, nil
+	}
+	return getPages(ctx, client, url, decode, nil)
+}
+
+func decodeArray[T any](body io.Reader) ([]T, error) {
+	var page []T
+	err := json.NewDecoder(body).Decode(&page)
+	return page, err
+}
+
+// Pages streams a paginated listing one page at a time, so breaking out of
+// the loop stops pagination and no page past the last one read is requested.
+// With prefetch, when the first page links to a numbered last page, later
+// pages are instead requested concurrently ahead of the caller, so up to
+// pageConcurrency-1 requests are wasted by stopping early. A request or
+// decode error is yielded once and ends the sequence.
+func Pages[T any](ctx context.Context, client api.RESTClient, url string, prefetch bool) iter.Seq2[[]T, error] {
+	return pages(ctx, client, url, decodeArray[T], prefetch)
+}
+
+// Collect reads every page of a sequence into one slice
+func Collect[T any](pages iter.Seq2[[]T, error]) ([]T, error) {
+	var all []T
+	for page, err := range pages {
+		if err != nil {
+			return nil, err
 		}
+		all = append(all, page...)
+	}
+	return all, nil
+}
+
+// pageConcurrency is how many pages of one listing are requested at a time.
+// The Coordinator still bounds the total number of requests in flight.
+const pageConcurrency = 4
 
+// getPages reads every page, or stops after a page for which more returns
+// false. Pages are only fetched concurrently when every page will be read.
+func getPages[T any](ctx context.Context, client api.RESTClient, url string, decode func(io.Reader) ([]T, error), more func(page []T) bool) ([]T, error) {
+	var all []T
+	for page, err := range pages(ctx, client, url, decode, more == nil) {
+		if err != nil {
+			return nil, err
+		}
 		all = append(all, page...)
-		url = header.GetNextPageURL(response.Header.Get("Link"))
+		if more != nil && !more(page) {
+			break
+		}
 	}
 	return all, nil
 }
+
+func pages[T any](ctx context.Context, client api.RESTClient, url string, decode func(io.Reader) ([]T, error), concurrent bool) iter.Seq2[[]T, error] {
+	return func(yield func([]T, error) bool) {
+		page, links, err := fetchPage(ctx, client, url, decode)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		if !yield(page, nil) {
+			return
+		}
+		if remaining := numberedPageURLs(links); concurrent && len(remaining) > 0 {
+			fetchInOrder(ctx, client, remaining, decode, yield)
+			return
+		}
+		for next := links.Next; next != ""; next = links.Next {
+			page, links, err = fetchPage(ctx, client, next, decode)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(page, nil) {
+				return
+			}
+		}
+	}
+}
+
+// fetchInOrder requests the pages at urls, up to pageConcurrency at a time,
+// and yields them in order.
+func fetchInOrder[T any](ctx context.Context, client api.RESTClient, urls []string, decode func(io.Reader) ([]T, error), yield func([]T, error) bool) {
+	type result struct {
+		page []T
+		err  error
+	}
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(ctx)
+	defer wg.Wait()
+	defer cancel()
+
+	results := make([]chan result, len(urls))
+	start := func(index int) {
+		ch := make(chan result, 1)
+		results[index] = ch
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			page, _, err := fetchPage(ctx, client, urls[index], decode)
+			ch <- result{page: page, err: err}
+		}()
+	}
+	for index := range min(pageConcurrency, len(urls)) {
+		start(index)
+	}
+	for index := range urls {
+		r := <-results[index]
+		if next := index + pageConcurrency; next < len(urls) {
+			start(next)
+		}
+		if r.err != nil {
+			yield(nil, r.err)
+			return
+		}
+		if !yield(r.page, nil) {
+			return
+		}
+	}
+}
+
+// numberedPageURLs returns the URLs from links.Next through links.Last when
+// both differ only in their page query parameter, so the remaining pages are
+// known up front. It returns nil for cursor-based or inconsistent links.
+func numberedPageURLs(links header.Links) []string {
+	if links.Next == "" || links.Last == "" {
+		return nil
+	}
+	nextPage, nextRest, ok := splitPageParameter(links.Next)
+	if !ok {
+		return nil
+	}
+	lastPage, lastRest, ok := splitPageParameter(links.Last)
+	if !ok || lastRest != nextRest || lastPage < nextPage {
+		return nil
+	}
+	urls := make([]string, 0, lastPage-nextPage+1)
+	for page := nextPage; page <= lastPage; page++ {
+		urls = append(urls, strings.Replace(nextRest, pagePlaceholder, strconv.Itoa(page), 1))
+	}
+	return urls
+}
+
+// pagePlaceholder stands in for the page number when comparing URLs
+const pagePlaceholder = "{page}"
+
+// splitPageParameter returns a URL's page number and the URL with that number
+// replaced by pagePlaceholder, leaving the rest of the URL untouched.
+func splitPageParameter(rawURL string) (int, string, bool) {
+	base, query, found := strings.Cut(rawURL, "?")
+	if !found {
+		return 0, "", false
+	}
+	params := strings.Split(query, "&")
+	for index, param := range params {
+		value, isPage := strings.CutPrefix(param, "page=")
+		if !isPage {
+			continue
+		}
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			return 0, "", false
+		}
+		params[index] = "page=" + pagePlaceholder
+		return page, base + "?" + strings.Join(params, "&"), true
+	}
+	return 0, "", false
+}
+
+// fetchPage requests and decodes one page, returning its pagination links
+func fetchPage[T any](ctx context.Context, client api.RESTClient, url string, decode func(io.Reader) ([]T, error)) ([]T, header.Links, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, header.Links{}, err
+	}
+	response, err := client.RequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, header.Links{}, fmt.Errorf("request %s: %w", url, err)
+	}
+
+	page, decodeErr := decode(response.Body)
+	closeErr := response.Body.Close()
+	if decodeErr != nil {
+		return nil, header.Links{}, fmt.Errorf("decode %s: %w", url, decodeErr)
+	}
+	if closeErr != nil {
+		return nil, header.Links{}, fmt.Errorf("close %s response: %w", url, closeErr)
+	}
+	return page, header.ParseLinks(response.Header.Get("Link")), nil
+}
@@ -4,18 +4,26 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
+	"sync"
 	"testing"
+
+	"github.com/ssulei7/gh-dormant-users/internal/header"
 )
 
 type mockRESTClient struct {
 	responses map[string]*http.Response
 	requests  map[string]int
+	mu        sync.Mutex
 }
 
 func (m *mockRESTClient) Request(_ string, path string, _ io.Reader) (*http.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.requests[path]++
 	response, ok := m.responses[path]
 	if !ok {
@@ -86,7 +94,7 @@ func TestGetAllRequestsEachPageOnce(t *testing.T) {
 	type item struct {
 		Name string `json:"name"`
 	}
-	items, err := GetAll[item](client, first)
+	items, err := GetAll[item](context.Background(), client, first)
 	if err != nil {
 		t.Fatalf("GetAll returned error: %v", err)
 	}
@@ -98,7 +106,155 @@ func TestGetAllRequestsEachPageOnce(t *testing.T) {
 			t.Fatalf("expected %s to be requested once, got %d", url, client.requests[url])
 		}
 	}
+}
+
+func TestGetAllFetchesNumberedPagesConcurrentlyInOrder(t *testing.T) {
+	first := "items?per_page=1"
+	last := 10
+	pageURL := func(page int) string {
+		return fmt.Sprintf("https://api.github.com/items?per_page=1&page=%d", page)
+	}
+	responses := map[string]*http.Response{
+		first: jsonResponse(`[{"name":"1"}]`, fmt.Sprintf("<%s>; rel=\"next\", <%s>; rel=\"last\"", pageURL(2), pageURL(last))),
+	}
+	for page := 2; page <= last; page++ {
+		responses[pageURL(page)] = jsonResponse(fmt.Sprintf(`[{"name":"%d"}]`, page), "")
+	}
+	client := &mockRESTClient{requests: make(map[string]int), responses: responses}
+
+	type item struct {
+		Name string `json:"name"`
+	}
+	items, err := GetAll[item](context.Background(), client, first)
+	if err != nil {
+		t.Fatalf("GetAll returned error: %v", err)
+	}
+	if len(items) != last {
+		t.Fatalf("expected %d items, got %d", last, len(items))
+	}
+	for index, item := range items {
+		if item.Name != fmt.Sprint(index+1) {
+			t.Fatalf("items out of order: %v", items)
+		}
+	}
+	for url, count := range client.requests {
+		if count != 1 {
+			t.Fatalf("expected %s to be requested once, got %d", url, count)
+		}
+	}
+}
+
+func TestPagesRequestsNumberedPagesLazily(t *testing.T) {
+	first := "items?per_page=1"
+	pageURL := func(page int) string {
+		return fmt.Sprintf("https://api.github.com/items?page=%d&per_page=1", page)
+	}
+	last := 3 * pageConcurrency
+	responses := map[string]*http.Response{
+		first: jsonResponse(`[{"name":"1"}]`, fmt.Sprintf("<%s>; rel=\"next\", <%s>; rel=\"last\"", pageURL(2), pageURL(last))),
+	}
+	for page := 2; page <= last; page++ {
+		responses[pageURL(page)] = jsonResponse(`[{"name":"more"}]`, fmt.Sprintf("<%s>; rel=\"next\", <%s>; rel=\"last\"", pageURL(page+1), pageURL(last)))
+	}
+	client := &mockRESTClient{requests: make(map[string]int), responses: responses}
+
+	read := 0
+	for _, err := range Pages[struct{}](context.Background(), client, first, false) {
+		if err != nil {
+			t.Fatalf("Pages yielded error: %v", err)
+		}
+		if read++; read == 2 {
+			break
+		}
+	}
+	requested := 0
+	for _, count := range client.requests {
+		requested += count
+	}
+	if requested != 2 {
+		t.Fatalf("requested %d pages after reading 2, want no page requested ahead", requested)
+	}
+}
+
+func TestPagesWithPrefetchStopsFanOutWhenCallerBreaks(t *testing.T) {
+	first := "items?per_page=1"
+	pageURL := func(page int) string {
+		return fmt.Sprintf("https://api.github.com/items?page=%d&per_page=1", page)
+	}
+	last := 3 * pageConcurrency
+	responses := map[string]*http.Response{
+		first: jsonResponse(`[{"name":"1"}]`, fmt.Sprintf("<%s>; rel=\"next\", <%s>; rel=\"last\"", pageURL(2), pageURL(last))),
+	}
+	for page := 2; page <= last; page++ {
+		responses[pageURL(page)] = jsonResponse(`[{"name":"more"}]`, "")
+	}
+	client := &mockRESTClient{requests: make(map[string]int), responses: responses}
+
+	read := 0
+	for _, err := range Pages[struct{}](context.Background(), client, first, true) {
+		if err != nil {
+			t.Fatalf("Pages yielded error: %v", err)
+		}
+		if read++; read == 2 {
+			break
+		}
+	}
+	requested := 0
+	for _, count := range client.requests {
+		requested += count
+	}
+	if requested > 2+pageConcurrency {
+		t.Fatalf("requested %d pages after reading 2, want at most %d", requested, 2+pageConcurrency)
+	}
+}
 
+func TestNumberedPageURLs(t *testing.T) {
+	tests := []struct {
+		name  string
+		links header.Links
+		want  []string
+	}{
+		{name: "next only", links: header.Links{Next: "items?page=2"}},
+		{name: "cursor", links: header.Links{Next: "items?after=abc", Last: "items?after=xyz"}},
+		{name: "different query", links: header.Links{Next: "items?page=2&q=a", Last: "items?page=3&q=b"}},
+		{
+			name:  "numbered",
+			links: header.Links{Next: "items?per_page=100&page=2", Last: "items?per_page=100&page=4"},
+			want:  []string{"items?per_page=100&page=2", "items?per_page=100&page=3", "items?per_page=100&page=4"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := numberedPageURLs(tt.links); !slices.Equal(got, tt.want) {
+				t.Fatalf("numberedPageURLs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAllWhileStopsWhenPageIsRejected(t *testing.T) {
+	first := "items?per_page=100"
+	second := "https://api.github.com/items?page=2"
+	client := &mockRESTClient{
+		requests: make(map[string]int),
+		responses: map[string]*http.Response{
+			first:  jsonResponse(`[{"name":"one"}]`, fmt.Sprintf("<%s>; rel=\"next\"", second)),
+			second: jsonResponse(`[{"name":"two"}]`, ""),
+		},
+	}
+
+	type item struct {
+		Name string `json:"name"`
+	}
+	items, err := GetAllWhile(context.Background(), client, first, func(page []item) bool {
+		return page[len(page)-1].Name != "one"
+	})
+	if err != nil {
+		t.Fatalf("GetAllWhile returned error: %v", err)
+	}
+	if len(items) != 1 || client.requests[second] != 0 {
+		t.Fatalf("items = %v, requests = %v", items, client.requests)
+	}
 }
 
 func jsonResponse(body, link string) *http.Response {
@@ -112,3 +268,86 @@ func jsonResponse(body, link string) *http.Response {
 		Body:       io.NopCloser(bytes.NewBufferString(body)),
 	}
 }
+
+func TestGetAllWrappedCollectsNamedField(t *testing.T) {
+	first := "seats?per_page=100"
+	second := "https://api.github.com/seats?page=2"
+	client := &mockRESTClient{
+		requests: make(map[string]int),
+		responses: map[string]*http.Response{
+			first:  jsonResponse(`{"total_seats":2,"seats":[{"name":"one"}]}`, fmt.Sprintf("<%s>; rel=\"next\"", second)),
+			second: jsonResponse(`{"total_seats":2,"seats":[{"name":"two"}]}`, ""),
+		},
+	}
+
+	items, err := GetAllWrapped[struct {
+		Name string `json:"name"`
+	}](context.Background(), client, first, "seats")
+	if err != nil {
+		t.Fatalf("GetAllWrapped returned error: %v", err)
+	}
+	if len(items) != 2 || items[0].Name != "one" || items[1].Name != "two" {
+		t.Fatalf("items = %#v", items)
+	}
+
+	missing := &mockRESTClient{
+		requests:  make(map[string]int),
+		responses: map[string]*http.Response{first: jsonResponse(`{"total_seats":0}`, "")},
+	}
+	if _, err := GetAllWrapped[struct{}](context.Background(), missing, first, "seats"); err == nil {
+		t.Fatal("expected an error for a response without the field")
+	}
+}
+
+func TestGetAllStopsWhenContextIsCancelled(t *testing.T) {
+	first := "items?per_page=100"
+	client := &mockRESTClient{
+		requests:  make(map[string]int),
+		responses: map[string]*http.Response{first: jsonResponse(`[]`, "")},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := GetAll[struct{}](ctx, client, first); !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want context.Canceled", err)
+	}
+	if client.requests[first] != 0 {
+		t.Fatalf("requests = %d, want 0", client.requests[first])
+	}
+}
+
+func TestPagesStopsRequestingWhenCallerBreaks(t *testing.T) {
+	first := "items?per_page=100"
+	second := "https://api.github.com/items?page=2"
+	client := &mockRESTClient{
+		requests: make(map[string]int),
+		responses: map[string]*http.Response{
+			first:  jsonResponse(`[{"name":"one"},{"name":"two"}]`, fmt.Sprintf("<%s>; rel=\"next\"", second)),
+			second: jsonResponse(`[{"name":"three"}]`, ""),
+		},
+	}
+
+	type item struct {
+		Name string `json:"name"`
+	}
+	var names []string
+	for page, err := range Pages[item](context.Background(), client, first, false) {
+		if err != nil {
+			t.Fatalf("Pages yielded error: %v", err)
+		}
+		for _, item := range page {
+			names = append(names, item.Name)
+		}
+		break
+	}
+	if len(names) != 2 || client.requests[second] != 0 {
+		t.Fatalf("names = %v, requests = %v", names, client.requests)
+	}
+}
+
+func TestCollectReturnsPageError(t *testing.T) {
+	client := &mockRESTClient{requests: make(map[string]int), responses: map[string]*http.Response{}}
+	if _, err := Collect(Pages[struct{}](context.Background(), client, "missing", false)); err == nil {
+		t.Fatal("expected an error for a failed request")
+	}
+}
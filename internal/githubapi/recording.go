@@ -0,0 +1,243 @@
+package githubapi
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const recordingVersion = 1
+
+// sensitiveHeaders are never written to a recording
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-GitHub-OTP"}
+
+// exchange is one recorded request and response pair
+type exchange struct {
+	Version     int         `json:"version"`
+	Sequence    int         `json:"sequence"`
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	RequestBody string      `json:"request_body,omitempty"`
+	Header      http.Header `json:"request_header,omitempty"`
+	Status      int         `json:"status"`
+	Response    http.Header `json:"response_header,omitempty"`
+	Body        []byte      `json:"body"`
+}
+
+// Recorder is a transport that saves every request and response pair it
+// sends to a directory, without credentials, so a run can be replayed.
+// It goes below the Coordinator as its Transport, so retries and each page
+// are recorded as they were sent.
+type Recorder struct {
+	dir       string
+	next      http.RoundTripper
+	mu        sync.Mutex
+	sequences map[string]int
+}
+
+// NewRecorder returns a Recorder that writes to dir and sends requests
+// through next, or http.DefaultTransport when next is nil.
+func NewRecorder(dir string, next http.RoundTripper) (*Recorder, error) {
+	if dir == "" {
+		return nil, errors.New("recording directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create recording directory: %w", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("inspect recording directory: %w", err)
+	}
+	if len(entries) != 0 {
+		// Exchanges left by another run would be replayed as part of this one
+		return nil, fmt.Errorf("refusing to record into non-empty directory %s", dir)
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{dir: dir, next: next, sequences: make(map[string]int)}, nil
+}
+
+func (r *Recorder) RoundTrip(request *http.Request) (*http.Response, error) {
+	requestBody, err := readRequestBody(request)
+	if err != nil {
+		return nil, err
+	}
+	response, err := r.next.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read response for recording: %w", err)
+	}
+	response.Body = io.NopCloser(bytes.NewReader(body))
+
+	key := exchangeKey(request.Method, request.URL.String(), requestBody)
+	r.mu.Lock()
+	sequence := r.sequences[key]
+	r.sequences[key]++
+	r.mu.Unlock()
+
+	recorded := exchange{
+		Version:     recordingVersion,
+		Sequence:    sequence,
+		Method:      request.Method,
+		URL:         request.URL.String(),
+		RequestBody: string(requestBody),
+		Header:      withoutSensitiveHeaders(request.Header),
+		Status:      response.StatusCode,
+		Response:    withoutSensitiveHeaders(response.Header),
+		Body:        body,
+	}
+	if err := r.write(key, recorded); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (r *Recorder) write(key string, recorded exchange) error {
+	data, err := json.MarshalIndent(recorded, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode recorded exchange: %w", err)
+	}
+	path := filepath.Join(r.dir, fmt.Sprintf("%s-%04d.json", key, recorded.Sequence))
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("write recorded exchange: %w", err)
+	}
+	return nil
+}
+
+// Replayer is a transport that answers requests from a Recorder's directory
+// and never touches the network. Requests that were sent several times get
+// their recorded responses in order, and the last one after that.
+type Replayer struct {
+	mu         sync.Mutex
+	exchanges  map[string][]exchange
+	served     map[string]int
+	recordedAt time.Time
+}
+
+// NewReplayer loads every exchange recorded in dir
+func NewReplayer(dir string) (*Replayer, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("list recording: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no recorded exchanges in %s", dir)
+	}
+	exchanges := make(map[string][]exchange)
+	var recordedAt time.Time
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read recorded exchange: %w", err)
+		}
+		var recorded exchange
+		if err := json.Unmarshal(data, &recorded); err != nil {
+			return nil, fmt.Errorf("decode recorded exchange %s: %w", filepath.Base(path), err)
+		}
+		if recorded.Version != recordingVersion {
+			return nil, fmt.Errorf("unsupported recording version %d in %s", recorded.Version, filepath.Base(path))
+		}
+		key := exchangeKey(recorded.Method, recorded.URL, []byte(recorded.RequestBody))
+		exchanges[key] = append(exchanges[key], recorded)
+		if date, err := http.ParseTime(recorded.Response.Get("Date")); err == nil && date.After(recordedAt) {
+			recordedAt = date
+		}
+	}
+	for _, sequence := range exchanges {
+		sort.Slice(sequence, func(i, j int) bool { return sequence[i].Sequence < sequence[j].Sequence })
+	}
+	return &Replayer{exchanges: exchanges, served: make(map[string]int), recordedAt: recordedAt}, nil
+}
+
+// RecordedAt is the latest Date header of the recorded responses, or the zero
+// time when they have none.
+func (r *Replayer) RecordedAt() time.Time {
+	return r.recordedAt
+}
+
+func (r *Replayer) RoundTrip(request *http.Request) (*http.Response, error) {
+	requestBody, err := readRequestBody(request)
+	if err != nil {
+		return nil, err
+	}
+	key := exchangeKey(request.Method, request.URL.String(), requestBody)
+
+	r.mu.Lock()
+	sequence := r.exchanges[key]
+	index := min(r.served[key], len(sequence)-1)
+	r.served[key]++
+	r.mu.Unlock()
+	if len(sequence) == 0 {
+		return nil, fmt.Errorf("no recorded response for %s %s", request.Method, request.URL)
+	}
+
+	recorded := sequence[index]
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.Response.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       request,
+	}, nil
+}
+
+// exchangeKey identifies a request by its method, URL and body, so GraphQL
+// queries sent to the same URL are told apart.
+func exchangeKey(method string, url string, body []byte) string {
+	hash := sha256.Sum256([]byte(method + "\n" + url + "\n" + string(body)))
+	return hex.EncodeToString(hash[:16])
+}
+
+// readRequestBody returns a request's body and leaves it readable for the
+// next transport.
+func readRequestBody(request *http.Request) ([]byte, error) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return nil, nil
+	}
+	if request.GetBody != nil {
+		body, err := request.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("read request body: %w", err)
+		}
+		defer body.Close()
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("read request body: %w", err)
+		}
+		return data, nil
+	}
+	body, err := io.ReadAll(request.Body)
+	_ = request.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read request body: %w", err)
+	}
+	request.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+func withoutSensitiveHeaders(header http.Header) http.Header {
+	clean := header.Clone()
+	for _, name := range sensitiveHeaders {
+		clean.Del(name)
+	}
+	return clean
+}
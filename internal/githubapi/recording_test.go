@@ -0,0 +1,178 @@
+package githubapi
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecorderAndReplayerReproduceRun(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "recording")
+	calls := 0
+	recorder, err := NewRecorder(dir, roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		calls++
+		if request.Method == http.MethodPost {
+			body, _ := io.ReadAll(request.Body)
+			return response(http.StatusOK, `{"echo":`+string(body)+`}`, nil), nil
+		}
+		if calls == 1 {
+			return response(http.StatusBadGateway, "", nil), nil
+		}
+		return response(http.StatusOK, `[{"login":"octocat"}]`, map[string]string{
+			"Link":       `<https://api.github.com/orgs/example/members?page=2>; rel="next"`,
+			"Set-Cookie": "session=secret",
+		}), nil
+	}))
+	if err != nil {
+		t.Fatalf("NewRecorder returned error: %v", err)
+	}
+
+	send := func(transport http.RoundTripper, method string, body string) (int, string, http.Header) {
+		t.Helper()
+		var reader io.Reader
+		if body != "" {
+			reader = bytes.NewBufferString(body)
+		}
+		request, _ := http.NewRequest(method, "https://api.github.com/orgs/example/members", reader)
+		request.Header.Set("Authorization", "token secret-token")
+		result, err := transport.RoundTrip(request)
+		if err != nil {
+			t.Fatalf("RoundTrip returned error: %v", err)
+		}
+		defer result.Body.Close()
+		data, _ := io.ReadAll(result.Body)
+		return result.StatusCode, string(data), result.Header
+	}
+
+	var recorded []string
+	for _, call := range []struct{ method, body string }{{http.MethodGet, ""}, {http.MethodGet, ""}, {http.MethodPost, `{"query":"a"}`}, {http.MethodPost, `{"query":"b"}`}} {
+		status, body, _ := send(recorder, call.method, call.body)
+		recorded = append(recorded, http.StatusText(status)+" "+body)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 4 {
+		t.Fatalf("recorded %d exchanges, want 4", len(files))
+	}
+	for _, file := range files {
+		data, _ := os.ReadFile(file)
+		if strings.Contains(string(data), "secret") {
+			t.Fatalf("%s contains a credential: %s", filepath.Base(file), data)
+		}
+	}
+
+	replayer, err := NewReplayer(dir)
+	if err != nil {
+		t.Fatalf("NewReplayer returned error: %v", err)
+	}
+	var replayed []string
+	var link string
+	for _, call := range []struct{ method, body string }{{http.MethodGet, ""}, {http.MethodGet, ""}, {http.MethodPost, `{"query":"a"}`}, {http.MethodPost, `{"query":"b"}`}} {
+		status, body, header := send(replayer, call.method, call.body)
+		replayed = append(replayed, http.StatusText(status)+" "+body)
+		if call.method == http.MethodGet {
+			link = header.Get("Link")
+		}
+	}
+	if strings.Join(replayed, "\n") != strings.Join(recorded, "\n") {
+		t.Fatalf("replayed:\n%s\nrecorded:\n%s", strings.Join(replayed, "\n"), strings.Join(recorded, "\n"))
+	}
+	if !strings.Contains(link, "page=2") {
+		t.Fatalf("Link = %q, want the recorded header", link)
+	}
+	if status, _, _ := send(replayer, http.MethodGet, ""); status != http.StatusOK {
+		t.Fatalf("status = %d, want the last recorded response repeated", status)
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, "https://api.github.com/orgs/other/members", nil)
+	if _, err := replayer.RoundTrip(request); err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Fatalf("error = %v, want missing recording error", err)
+	}
+}
+
+func TestNewRecorderRefusesNonEmptyDirectory(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "old.json"), []byte("{}"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if _, err := NewRecorder(dir, nil); err == nil || !strings.Contains(err.Error(), "non-empty") {
+		t.Fatalf("error = %v, want non-empty directory error", err)
+	}
+}
+
+func TestNewReplayerRequiresRecording(t *testing.T) {
+	if _, err := NewReplayer(t.TempDir()); err == nil || !strings.Contains(err.Error(), "no recorded exchanges") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestCoordinatorDoesNotWaitForReplayedRateLimits(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "recording")
+	calls := 0
+	recorder, err := NewRecorder(dir, roundTripFunc(func(*http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			return response(http.StatusForbidden, `{"message":"API rate limit exceeded"}`, map[string]string{
+				"Date":                  "Tue, 03 Mar 2026 10:00:00 GMT",
+				"X-RateLimit-Limit":     "5000",
+				"X-RateLimit-Remaining": "0",
+				"X-RateLimit-Reset":     "1772535600",
+			}), nil
+		}
+		return response(http.StatusOK, `[]`, map[string]string{"Date": "Tue, 03 Mar 2026 11:00:01 GMT"}), nil
+	}))
+	if err != nil {
+		t.Fatalf("NewRecorder returned error: %v", err)
+	}
+	for range 2 {
+		request, _ := http.NewRequest(http.MethodGet, "https://api.github.com/orgs/example/members", nil)
+		result, err := recorder.RoundTrip(request)
+		if err != nil {
+			t.Fatalf("RoundTrip returned error: %v", err)
+		}
+		_ = result.Body.Close()
+	}
+
+	replayer, err := NewReplayer(dir)
+	if err != nil {
+		t.Fatalf("NewReplayer returned error: %v", err)
+	}
+	if want := time.Date(2026, time.March, 3, 11, 0, 1, 0, time.UTC); !replayer.RecordedAt().Equal(want) {
+		t.Fatalf("RecordedAt = %v, want the latest response date %v", replayer.RecordedAt(), want)
+	}
+	var delays []time.Duration
+	coordinator, err := NewCoordinator(Config{
+		Transport:         replayer,
+		MaxConcurrency:    1,
+		RequestsPerSecond: 1,
+		Now:               func() time.Time { return time.Unix(1772532000, 0) },
+		Sleep: func(_ context.Context, delay time.Duration) error {
+			delays = append(delays, delay)
+			return nil
+		},
+		Jitter: noJitter,
+	})
+	if err != nil {
+		t.Fatalf("NewCoordinator returned error: %v", err)
+	}
+	for range 2 {
+		request, _ := http.NewRequest(http.MethodGet, "https://api.github.com/orgs/example/members", nil)
+		result, err := coordinator.RoundTrip(request)
+		if err != nil {
+			t.Fatalf("RoundTrip returned error: %v", err)
+		}
+		_ = result.Body.Close()
+	}
+	if len(delays) != 0 {
+		t.Fatalf("delays = %v, want no waits while replaying", delays)
+	}
+	if retries := coordinator.Stats().Retries; retries != 1 {
+		t.Fatalf("retries = %d, want the recorded retry replayed", retries)
+	}
+}
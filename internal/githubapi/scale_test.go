@@ -1,6 +1,7 @@
 package githubapi
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -35,7 +36,7 @@ func TestGetAllRequestsTenThousandRecordsInOneHundredCalls(t *testing.T) {
 		client.responses[url] = jsonResponse(string(body), next)
 	}
 
-	items, err := GetAll[item](client, "items?per_page=100&page=1")
+	items, err := GetAll[item](context.Background(), client, "items?per_page=100&page=1")
 	if err != nil {
 		t.Fatalf("GetAll returned error: %v", err)
 	}
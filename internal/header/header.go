@@ -2,18 +2,161 @@ package header
 
 import "strings"
 
+// Links holds the pagination relations of a Link header. A relation that is
+// absent is empty.
+type Links struct {
+	First string
+	Prev  string
+	Next  string
+	Last  string
+}
+
 func GetNextPageURL(linkHeader string) string {
-	links := strings.Split(linkHeader, ",")
-	for _, link := range links {
-		parts := strings.Split(strings.TrimSpace(link), ";")
-		if len(parts) < 2 {
+	return ParseLinks(linkHeader).Next
+}
+
+// ParseLinks reads the first, prev, next and last relations from a Link
+// header as described in RFC 8288. A link may carry several space-separated
+// relation types, parameters may be quoted, and malformed links are skipped.
+// When a relation appears more than once the first link wins.
+func ParseLinks(linkHeader string) Links {
+	var links Links
+	p := parser{input: linkHeader}
+	for !p.done() {
+		target, params, ok := p.link()
+		if !ok {
+			p.skipLink()
 			continue
 		}
-		urlPart := strings.Trim(parts[0], "<>")
-		relPart := strings.TrimSpace(parts[1])
-		if relPart == `rel="next"` {
-			return urlPart
+		for _, rel := range strings.Fields(params["rel"]) {
+			var slot *string
+			switch strings.ToLower(rel) {
+			case "first":
+				slot = &links.First
+			case "prev", "previous":
+				slot = &links.Prev
+			case "next":
+				slot = &links.Next
+			case "last":
+				slot = &links.Last
+			}
+			if slot != nil && *slot == "" {
+				*slot = target
+			}
+		}
+	}
+	return links
+}
+
+type parser struct {
+	input string
+	pos   int
+}
+
+func (p *parser) done() bool {
+	p.skip(" \t,")
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) skip(chars string) {
+	for p.pos < len(p.input) && strings.IndexByte(chars, p.input[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// link reads one "<target>; name=value; ..." entry. Only the first
+// occurrence of each parameter is kept.
+func (p *parser) link() (string, map[string]string, bool) {
+	if p.input[p.pos] != '<' {
+		return "", nil, false
+	}
+	end := strings.IndexByte(p.input[p.pos:], '>')
+	if end < 0 {
+		p.pos = len(p.input)
+		return "", nil, false
+	}
+	target := strings.TrimSpace(p.input[p.pos+1 : p.pos+end])
+	p.pos += end + 1
+
+	params := make(map[string]string)
+	for {
+		p.skip(" \t")
+		if p.pos >= len(p.input) || p.input[p.pos] != ';' {
+			break
+		}
+		p.pos++
+		p.skip(" \t")
+		name := strings.ToLower(p.token())
+		p.skip(" \t")
+		value := ""
+		if p.pos < len(p.input) && p.input[p.pos] == '=' {
+			p.pos++
+			p.skip(" \t")
+			if p.pos < len(p.input) && p.input[p.pos] == '"' {
+				value = p.quoted()
+			} else {
+				value = p.token()
+			}
+		}
+		if _, exists := params[name]; name != "" && !exists {
+			params[name] = value
+		}
+	}
+	p.skip(" \t")
+	if p.pos < len(p.input) && p.input[p.pos] != ',' {
+		return "", nil, false
+	}
+	return target, params, true
+}
+
+func (p *parser) token() string {
+	start := p.pos
+	for p.pos < len(p.input) && strings.IndexByte(" \t;,=\"", p.input[p.pos]) < 0 {
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+// quoted reads a quoted-string, unescaping backslash pairs
+func (p *parser) quoted() string {
+	var value strings.Builder
+	for p.pos++; p.pos < len(p.input); p.pos++ {
+		switch c := p.input[p.pos]; c {
+		case '\\':
+			if p.pos+1 < len(p.input) {
+				p.pos++
+				value.WriteByte(p.input[p.pos])
+			}
+		case '"':
+			p.pos++
+			return value.String()
+		default:
+			value.WriteByte(c)
+		}
+	}
+	return value.String()
+}
+
+// skipLink moves past the rest of a malformed link, ignoring commas inside
+// angle brackets or quotes.
+func (p *parser) skipLink() {
+	quoted, bracketed := false, false
+	for ; p.pos < len(p.input); p.pos++ {
+		switch c := p.input[p.pos]; {
+		case quoted:
+			if c == '\\' {
+				p.pos++
+			} else if c == '"' {
+				quoted = false
+			}
+		case c == '"':
+			quoted = true
+		case c == '<':
+			bracketed = true
+		case c == '>':
+			bracketed = false
+		case c == ',' && !bracketed:
+			return
 		}
 	}
-	return ""
 }
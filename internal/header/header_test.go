@@ -27,7 +27,12 @@ func TestGetNextPageURL(t *testing.T) {
 			linkHeader: `not-a-link, <https://api.github.com/items?page=4>; rel="next"`,
 			want:       "https://api.github.com/items?page=4",
 		},
-		{name: "relation is not exact", linkHeader: `<https://api.github.com/items?page=2>; rel="next last"`},
+		{
+			name:       "several relation types",
+			linkHeader: `<https://api.github.com/items?page=2>; rel="next last"`,
+			want:       "https://api.github.com/items?page=2",
+		},
+		{name: "relation is a prefix", linkHeader: `<https://api.github.com/items?page=2>; rel="nextpage"`},
 	}
 
 	for _, tt := range tests {
@@ -40,3 +45,66 @@ func TestGetNextPageURL(t *testing.T) {
 		})
 	}
 }
+
+func TestParseLinks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		linkHeader string
+		want       Links
+	}{
+		{
+			name:       "all relations",
+			linkHeader: `<https://api.github.com/items?page=1>; rel="first", <https://api.github.com/items?page=2>; rel="prev", <https://api.github.com/items?page=4>; rel="next", <https://api.github.com/items?page=9>; rel="last"`,
+			want: Links{
+				First: "https://api.github.com/items?page=1",
+				Prev:  "https://api.github.com/items?page=2",
+				Next:  "https://api.github.com/items?page=4",
+				Last:  "https://api.github.com/items?page=9",
+			},
+		},
+		{
+			name:       "unquoted relation and extra parameters",
+			linkHeader: `<https://api.github.com/items?page=2>;rel=next;title="a, b; c", <https://api.github.com/items?page=3> ; REL = "LAST"`,
+			want: Links{
+				Next: "https://api.github.com/items?page=2",
+				Last: "https://api.github.com/items?page=3",
+			},
+		},
+		{
+			name:       "escaped quote in parameter",
+			linkHeader: `<https://api.github.com/items?page=2>; title="say \"hi\", then"; rel="next"`,
+			want:       Links{Next: "https://api.github.com/items?page=2"},
+		},
+		{
+			name:       "first link wins",
+			linkHeader: `<https://api.github.com/items?page=2>; rel="next", <https://api.github.com/items?page=7>; rel="next"`,
+			want:       Links{Next: "https://api.github.com/items?page=2"},
+		},
+		{
+			name:       "only the first rel parameter counts",
+			linkHeader: `<https://api.github.com/items?page=2>; rel="next"; rel="last"`,
+			want:       Links{Next: "https://api.github.com/items?page=2"},
+		},
+		{
+			name:       "unterminated target",
+			linkHeader: `<https://api.github.com/items?page=2; rel="next"`,
+		},
+		{
+			name:       "malformed link with quoted comma",
+			linkHeader: `bad; title="x, y", <https://api.github.com/items?page=5>; rel="last"`,
+			want:       Links{Last: "https://api.github.com/items?page=5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ParseLinks(tt.linkHeader); got != tt.want {
+				t.Fatalf("ParseLinks(%q) = %#v, want %#v", tt.linkHeader, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package history
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ssulei7/gh-dormant-users/internal/report"
+)
+
+// runExtension is the file extension of stored runs. Runs are kept as NDJSON
+// reports, so they can be read by analyze and diff like any other report.
+const runExtension = ".ndjson"
+
+// DefaultDir is the history directory under the user cache directory. It is
+// kept next to the API cache rather than inside it, so clearing the cache
+// does not remove the history.
+func DefaultDir() (string, error) {
+	cacheRoot, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve user cache directory: %w", err)
+	}
+	return filepath.Join(cacheRoot, "gh-dormant-users-history"), nil
+}
+
+// Store keeps the report of every run in a directory, one file per run
+type Store struct {
+	dir string
+}
+
+// Run is one stored report run
+type Run struct {
+	ID     string
+	Scope  string
+	Report report.Report
+}
+
+// Open returns the store in dir, creating the directory when needed
+func Open(dir string) (*Store, error) {
+	if dir == "" {
+		return nil, errors.New("history directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create history directory: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Dir is the directory the store keeps runs in
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Scope identifies what a run covered: an enterprise, or a set of
+// organizations, on a GitHub host. Runs against github.com, and runs recorded
+// before the host was, have no host in their scope. Streaks only count runs of
+// the same scope.
+func Scope(metadata report.Metadata) string {
+	host := ""
+	if hostname := strings.ToLower(metadata.Hostname); hostname != "" && hostname != "github.com" {
+		host = hostname + "/"
+	}
+	if metadata.Enterprise != "" {
+		return host + "enterprise:" + strings.ToLower(metadata.Enterprise)
+	}
+	organizations := make([]string, 0, len(metadata.Organizations))
+	for _, organization := range metadata.Organizations {
+		organizations = append(organizations, strings.ToLower(organization))
+	}
+	slices.Sort(organizations)
+	return host + strings.Join(organizations, ",")
+}
+
+// Save stores a run and returns its ID. IDs start with the time the report
+// was generated, so they sort in the order the runs were made.
+func (s *Store) Save(run report.Report) (string, error) {
+	run.SchemaVersion = report.SchemaVersion
+	generatedAt := run.Metadata.GeneratedAt
+	if generatedAt.IsZero() {
+		generatedAt = time.Now()
+	}
+	base := generatedAt.UTC().Format("20060102T150405Z") + "-" + fileSafe(Scope(run.Metadata))
+	for attempt := 1; ; attempt++ {
+		id := base
+		if attempt > 1 {
+			id = fmt.Sprintf("%s-%d", base, attempt)
+		}
+		file, err := os.OpenFile(filepath.Join(s.dir, id+runExtension), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("save run: %w", err)
+		}
+		writer := bufio.NewWriter(file)
+		err = report.Encode(writer, "ndjson", run)
+		if err == nil {
+			err = writer.Flush()
+		}
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(file.Name())
+			return "", fmt.Errorf("save run: %w", err)
+		}
+		return id, nil
+	}
+}
+
+// fileSafe replaces the characters of a scope that cannot be in a file name
+func fileSafe(scope string) string {
+	if scope == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, scope)
+}
+
+// Prune deletes the runs generated before the cutoff and returns how many
+// were deleted. Runs hold members' logins and emails, so old ones should not
+// be kept longer than they are needed.
+func (s *Store) Prune(before time.Time) (int, error) {
+	runs, err := s.Runs()
+	if err != nil {
+		return 0, err
+	}
+	pruned := 0
+	for _, run := range runs {
+		if !run.Report.Metadata.GeneratedAt.Before(before) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, run.ID+runExtension)); err != nil {
+			return pruned, fmt.Errorf("prune run %s: %w", run.ID, err)
+		}
+		pruned++
+	}
+	return pruned, nil
+}
+
+// Runs returns every stored run, oldest first
+func (s *Store) Runs() ([]Run, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+	var runs []Run
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), runExtension) {
+			continue
+		}
+		run, err := s.Run(strings.TrimSuffix(entry.Name(), runExtension))
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+	slices.SortFunc(runs, func(a, b Run) int {
+		if order := a.Report.Metadata.GeneratedAt.Compare(b.Report.Metadata.GeneratedAt); order != 0 {
+			return order
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return runs, nil
+}
+
+// Run reads one stored run
+func (s *Store) Run(id string) (*Run, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return nil, fmt.Errorf("invalid run ID %q", id)
+	}
+	path := filepath.Join(s.dir, id+runExtension)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no run %q in %s", id, s.dir)
+	}
+	stored, err := report.Read(path)
+	if err != nil {
+		return nil, fmt.Errorf("read run %s: %w", id, err)
+	}
+	return &Run{ID: id, Scope: Scope(stored.Metadata), Report: *stored}, nil
+}
+
+// Dormant returns the number of dormant members in the run
+func (r Run) Dormant() int {
+	dormant := 0
+	for _, user := range r.Report.Users {
+		if !user.Active {
+			dormant++
+		}
+	}
+	return dormant
+}
+
+// Streaks returns, for each member of through keyed by lowercase login, the
+// number of consecutive runs of the same scope, ending with through, in which
+// the member was dormant. Only runs that checked the same activity types as
+// through are counted, and runs with the same cutoff date count once, the
+// latest of them deciding, so rerunning a report does not lengthen a streak.
+// Partial runs are skipped, as their verdicts are incomplete; a member
+// missing from a run ends their streak.
+func Streaks(runs []Run, through Run) map[string]int {
+	streaks := make(map[string]int)
+	counting := make(map[string]bool)
+	for _, user := range through.Report.Users {
+		if !user.Active {
+			counting[strings.ToLower(user.Login)] = true
+		}
+	}
+	counted := make(map[time.Time]bool)
+	for index := len(runs) - 1; index >= 0 && len(counting) > 0; index-- {
+		run := runs[index]
+		metadata := run.Report.Metadata
+		if run.Scope != through.Scope || metadata.Partial || metadata.GeneratedAt.After(through.Report.Metadata.GeneratedAt) ||
+			!sameTypes(metadata.ActivityTypes, through.Report.Metadata.ActivityTypes) {
+			continue
+		}
+		since := metadata.Since.UTC().Truncate(24 * time.Hour)
+		if counted[since] {
+			continue
+		}
+		counted[since] = true
+		dormant := make(map[string]bool)
+		for _, user := range run.Report.Users {
+			if !user.Active {
+				dormant[strings.ToLower(user.Login)] = true
+			}
+		}
+		for login := range counting {
+			if !dormant[login] {
+				delete(counting, login)
+				continue
+			}
+			streaks[login]++
+		}
+	}
+	return streaks
+}
+
+// sameTypes reports whether two runs checked the same activity types
+func sameTypes(a []string, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(slices.Compact(a), slices.Compact(b))
+}
+
+// Point is a member's verdict in one run. Complete is set when the run read
+// every source in full, so ActivityTypes are all the member's activity types
+// rather than the first found.
+type Point struct {
+	RunID         string
+	Scope         string
+	GeneratedAt   time.Time
+	Partial       bool
+	Complete      bool
+	Active        bool
+	ActivityTypes []string
+	LastActivity  *report.Evidence
+}
+
+// Trend returns the member's verdict in every run that included them,
+// oldest first. Logins are matched ignoring case.
+func Trend(runs []Run, login string) []Point {
+	var points []Point
+	for _, run := range runs {
+		for _, user := range run.Report.Users {
+			if !strings.EqualFold(user.Login, login) {
+				continue
+			}
+			points = append(points, Point{
+				RunID:         run.ID,
+				Scope:         run.Scope,
+				GeneratedAt:   run.Report.Metadata.GeneratedAt,
+				Partial:       run.Report.Metadata.Partial,
+				Complete:      run.Report.Metadata.EvidenceComplete,
+				Active:        user.Active,
+				ActivityTypes: user.ActivityTypes,
+				LastActivity:  user.LastActivity,
+			})
+			break
+		}
+	}
+	return points
+}
@@ -0,0 +1,173 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ssulei7/gh-dormant-users/internal/report"
+)
+
+func testRun(day int, partial bool, dormant ...string) report.Report {
+	run := report.Report{Metadata: report.Metadata{
+		Organizations: []string{"Example"},
+		Since:         time.Date(2024, 2, day, 0, 0, 0, 0, time.UTC),
+		ActivityTypes: []string{"commits", "issues"},
+		GeneratedAt:   time.Date(2024, 3, day, 12, 0, 0, 0, time.UTC),
+		Partial:       partial,
+	}}
+	run.Users = append(run.Users, report.User{Login: "octocat", Active: true, ActivityTypes: []string{"commits"}})
+	for _, login := range dormant {
+		run.Users = append(run.Users, report.User{Login: login, ActivityTypes: []string{}})
+	}
+	return run
+}
+
+func TestSaveAndReadRuns(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "history"))
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	later, err := store.Save(testRun(8, false, "hubot"))
+	if err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	earlier, err := store.Save(testRun(1, false, "hubot", "monalisa"))
+	if err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	again, err := store.Save(testRun(1, false))
+	if err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	if earlier != "20240301T120000Z-example" || again != earlier+"-2" || later != "20240308T120000Z-example" {
+		t.Fatalf("IDs = %q, %q, %q", earlier, again, later)
+	}
+
+	runs, err := store.Runs()
+	if err != nil {
+		t.Fatalf("Runs returned error: %v", err)
+	}
+	var ids []string
+	for _, run := range runs {
+		ids = append(ids, run.ID)
+	}
+	if strings.Join(ids, " ") != strings.Join([]string{earlier, again, later}, " ") {
+		t.Fatalf("runs = %v", ids)
+	}
+	if runs[0].Scope != "example" || runs[0].Dormant() != 2 || runs[0].Report.Users[0].Login != "octocat" {
+		t.Fatalf("first run = %+v", runs[0])
+	}
+	info, err := os.Stat(filepath.Join(store.Dir(), earlier+".ndjson"))
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("run file = %v, %v", info, err)
+	}
+}
+
+func TestRunRejectsUnknownAndInvalidIDs(t *testing.T) {
+	store, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	if _, err := store.Run("missing"); err == nil || !strings.Contains(err.Error(), "no run") {
+		t.Fatalf("error = %v", err)
+	}
+	if _, err := store.Run("../secrets"); err == nil || !strings.Contains(err.Error(), "invalid run ID") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestStreaksCountConsecutiveCompleteRunsOfTheSameScope(t *testing.T) {
+	other := testRun(4, false, "monalisa")
+	other.Metadata.Organizations = []string{"other"}
+	var runs []Run
+	for _, run := range []report.Report{
+		testRun(1, false, "hubot", "monalisa"),
+		testRun(2, false, "hubot"),
+		testRun(3, false, "hubot", "monalisa"),
+		other,
+		testRun(5, true),
+		testRun(6, false, "hubot", "monalisa", "newcomer"),
+	} {
+		runs = append(runs, Run{ID: fmt.Sprint(run.Metadata.GeneratedAt.Day()), Scope: Scope(run.Metadata), Report: run})
+	}
+
+	streaks := Streaks(runs, runs[len(runs)-1])
+	want := map[string]int{"hubot": 4, "monalisa": 2, "newcomer": 1}
+	if fmt.Sprint(streaks) != fmt.Sprint(want) {
+		t.Fatalf("streaks = %v, want %v", streaks, want)
+	}
+	if streaks := Streaks(runs, runs[1]); fmt.Sprint(streaks) != fmt.Sprint(map[string]int{"hubot": 2}) {
+		t.Fatalf("streaks through the second run = %v", streaks)
+	}
+}
+
+func TestStreaksCountEachCutoffOnceAndOnlyMatchingActivityTypes(t *testing.T) {
+	rerun := testRun(3, false, "hubot")
+	rerun.Metadata.Since = time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC)
+	narrower := testRun(4, false, "hubot")
+	narrower.Metadata.ActivityTypes = []string{"commits"}
+	var runs []Run
+	for _, run := range []report.Report{
+		testRun(1, false, "hubot"),
+		testRun(2, false, "hubot"),
+		rerun,
+		narrower,
+		testRun(5, false, "hubot"),
+	} {
+		runs = append(runs, Run{ID: fmt.Sprint(run.Metadata.GeneratedAt.Day()), Scope: Scope(run.Metadata), Report: run})
+	}
+
+	if streaks := Streaks(runs, runs[len(runs)-1]); streaks["hubot"] != 3 {
+		t.Fatalf("streaks = %v, want hubot dormant for three cutoffs", streaks)
+	}
+}
+
+func TestPruneDeletesOldRuns(t *testing.T) {
+	store, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	for day := 1; day <= 3; day++ {
+		if _, err := store.Save(testRun(day, false, "hubot")); err != nil {
+			t.Fatalf("Save returned error: %v", err)
+		}
+	}
+	pruned, err := store.Prune(time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC))
+	if err != nil || pruned != 2 {
+		t.Fatalf("Prune = %d, %v", pruned, err)
+	}
+	runs, err := store.Runs()
+	if err != nil || len(runs) != 1 || runs[0].ID != "20240303T120000Z-example" {
+		t.Fatalf("runs = %+v, err = %v", runs, err)
+	}
+}
+
+func TestTrendFollowsMemberAcrossRuns(t *testing.T) {
+	var runs []Run
+	for _, run := range []report.Report{testRun(1, false, "HUBOT"), testRun(2, false), testRun(3, true, "hubot")} {
+		runs = append(runs, Run{ID: fmt.Sprint(run.Metadata.GeneratedAt.Day()), Scope: Scope(run.Metadata), Report: run})
+	}
+	points := Trend(runs, "hubot")
+	if len(points) != 2 || points[0].RunID != "1" || points[0].Active || points[1].RunID != "3" || !points[1].Partial {
+		t.Fatalf("points = %+v", points)
+	}
+}
+
+func TestScope(t *testing.T) {
+	if got := Scope(report.Metadata{Organizations: []string{"Two", "one"}}); got != "one,two" {
+		t.Fatalf("Scope = %q", got)
+	}
+	if got := Scope(report.Metadata{Organizations: []string{"one"}, Enterprise: "Acme"}); got != "enterprise:acme" {
+		t.Fatalf("Scope = %q", got)
+	}
+	if got := Scope(report.Metadata{Organizations: []string{"one"}, Hostname: "github.com"}); got != "one" {
+		t.Fatalf("Scope = %q", got)
+	}
+	if got := Scope(report.Metadata{Organizations: []string{"one"}, Hostname: "GHE.example.com"}); got != "ghe.example.com/one" {
+		t.Fatalf("Scope = %q", got)
+	}
+}
@@ -1,7 +1,9 @@
 package issues
 
 import (
+	"context"
 	"fmt"
+	"iter"
 	"strings"
 
 	"github.com/cli/go-gh/pkg/api"
@@ -9,9 +11,10 @@ import (
 )
 
 type Issue struct {
-	ID    int    `json:"id"`
-	Title string `json:"title"`
-	User  struct {
+	ID      int    `json:"id"`
+	Title   string `json:"title"`
+	HTMLURL string `json:"html_url"`
+	User    struct {
 		Login string `json:"login"`
 	} `json:"user"`
 	CreatedAt string `json:"created_at"`
@@ -19,6 +22,7 @@ type Issue struct {
 
 type IssueComment struct {
 	ID        int    `json:"id"`
+	HTMLURL   string `json:"html_url"`
 	CreatedAt string `json:"created_at"`
 	UpdatedAt string `json:"updated_at"`
 	User      struct {
@@ -29,26 +33,38 @@ type IssueComment struct {
 type IssueComments []IssueComment
 type Issues []Issue
 
-func GetIssuesSinceDate(organization string, repo string, date string, client api.RESTClient) (Issues, error) {
+// IssuePagesSinceDate streams the issues updated since the date one page at a time
+func IssuePagesSinceDate(ctx context.Context, organization string, repo string, date string, client api.RESTClient, prefetch bool) iter.Seq2[[]Issue, error] {
 	url := fmt.Sprintf("repos/%s/%s/issues?per_page=100&since=%s", organization, repo, date)
-	issueList, err := githubapi.GetAll[Issue](client, url)
-	if err != nil {
-		if strings.Contains(err.Error(), "Git Repository is empty.") {
-			return nil, nil
+	return func(yield func([]Issue, error) bool) {
+		for page, err := range githubapi.Pages[Issue](ctx, client, url, prefetch) {
+			if err != nil {
+				if !strings.Contains(err.Error(), "Git Repository is empty.") {
+					yield(nil, fmt.Errorf("fetch issues for %s/%s: %w", organization, repo, err))
+				}
+				return
+			}
+			if !yield(page, nil) {
+				return
+			}
 		}
-		return nil, fmt.Errorf("fetch issues for %s/%s: %w", organization, repo, err)
 	}
-	return Issues(issueList), nil
 }
 
-func GetIssueCommentsSinceDate(organization string, repo string, date string, client api.RESTClient) (IssueComments, error) {
+// IssueCommentPagesSinceDate streams the issue comments updated since the date one page at a time
+func IssueCommentPagesSinceDate(ctx context.Context, organization string, repo string, date string, client api.RESTClient, prefetch bool) iter.Seq2[[]IssueComment, error] {
 	url := fmt.Sprintf("repos/%s/%s/issues/comments?per_page=100&since=%s", organization, repo, date)
-	comments, err := githubapi.GetAll[IssueComment](client, url)
-	if err != nil {
-		if strings.Contains(err.Error(), "Git Repository is empty.") {
-			return nil, nil
+	return func(yield func([]IssueComment, error) bool) {
+		for page, err := range githubapi.Pages[IssueComment](ctx, client, url, prefetch) {
+			if err != nil {
+				if !strings.Contains(err.Error(), "Git Repository is empty.") {
+					yield(nil, fmt.Errorf("fetch issue comments for %s/%s: %w", organization, repo, err))
+				}
+				return
+			}
+			if !yield(page, nil) {
+				return
+			}
 		}
-		return nil, fmt.Errorf("fetch issue comments for %s/%s: %w", organization, repo, err)
 	}
-	return IssueComments(comments), nil
 }
@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+
+	"github.com/ssulei7/gh-dormant-users/internal/githubapi"
 )
 
 type mockRESTClient struct {
@@ -68,13 +70,13 @@ func (m *mockRESTClient) Put(path string, body io.Reader, result interface{}) er
 
 func (m *mockRESTClient) RESTPrefix() string { return "" }
 
-func TestGetIssuesSinceDate(t *testing.T) {
+func TestIssuePagesSinceDate(t *testing.T) {
 	t.Parallel()
 
 	client := &mockRESTClient{body: `[{"id":1,"title":"Bug","user":{"login":"octocat"}}]`}
-	issues, err := GetIssuesSinceDate("example", "widgets", "2026-07-01T00:00:00Z", client)
+	issues, err := githubapi.Collect(IssuePagesSinceDate(context.Background(), "example", "widgets", "2026-07-01T00:00:00Z", client, false))
 	if err != nil {
-		t.Fatalf("GetIssuesSinceDate returned error: %v", err)
+		t.Fatalf("IssuePagesSinceDate returned error: %v", err)
 	}
 	if client.path != "repos/example/widgets/issues?per_page=100&since=2026-07-01T00:00:00Z" {
 		t.Fatalf("request path = %q", client.path)
@@ -84,13 +86,13 @@ func TestGetIssuesSinceDate(t *testing.T) {
 	}
 }
 
-func TestGetIssueCommentsSinceDate(t *testing.T) {
+func TestIssueCommentPagesSinceDate(t *testing.T) {
 	t.Parallel()
 
 	client := &mockRESTClient{body: `[{"id":2,"user":{"login":"hubot"}}]`}
-	comments, err := GetIssueCommentsSinceDate("example", "widgets", "2026-07-01T00:00:00Z", client)
+	comments, err := githubapi.Collect(IssueCommentPagesSinceDate(context.Background(), "example", "widgets", "2026-07-01T00:00:00Z", client, false))
 	if err != nil {
-		t.Fatalf("GetIssueCommentsSinceDate returned error: %v", err)
+		t.Fatalf("IssueCommentPagesSinceDate returned error: %v", err)
 	}
 	if client.path != "repos/example/widgets/issues/comments?per_page=100&since=2026-07-01T00:00:00Z" {
 		t.Fatalf("request path = %q", client.path)
@@ -104,11 +106,11 @@ func TestIssueEndpointsHandleEmptyRepository(t *testing.T) {
 	t.Parallel()
 
 	client := &mockRESTClient{err: errors.New("Git Repository is empty.")}
-	issues, err := GetIssuesSinceDate("example", "empty", "2026-07-01T00:00:00Z", client)
+	issues, err := githubapi.Collect(IssuePagesSinceDate(context.Background(), "example", "empty", "2026-07-01T00:00:00Z", client, false))
 	if err != nil || issues != nil {
 		t.Fatalf("issues = %#v, error = %v", issues, err)
 	}
-	comments, err := GetIssueCommentsSinceDate("example", "empty", "2026-07-01T00:00:00Z", client)
+	comments, err := githubapi.Collect(IssueCommentPagesSinceDate(context.Background(), "example", "empty", "2026-07-01T00:00:00Z", client, false))
 	if err != nil || comments != nil {
 		t.Fatalf("comments = %#v, error = %v", comments, err)
 	}
@@ -118,11 +120,11 @@ func TestIssueEndpointsWrapErrors(t *testing.T) {
 	t.Parallel()
 
 	client := &mockRESTClient{err: errors.New("boom")}
-	_, issueErr := GetIssuesSinceDate("example", "widgets", "2026-07-01T00:00:00Z", client)
+	_, issueErr := githubapi.Collect(IssuePagesSinceDate(context.Background(), "example", "widgets", "2026-07-01T00:00:00Z", client, false))
 	if issueErr == nil || !strings.Contains(issueErr.Error(), "fetch issues for example/widgets") {
 		t.Fatalf("issue error = %v", issueErr)
 	}
-	_, commentErr := GetIssueCommentsSinceDate("example", "widgets", "2026-07-01T00:00:00Z", client)
+	_, commentErr := githubapi.Collect(IssueCommentPagesSinceDate(context.Background(), "example", "widgets", "2026-07-01T00:00:00Z", client, false))
 	if commentErr == nil || !strings.Contains(commentErr.Error(), "fetch issue comments for example/widgets") {
 		t.Fatalf("comment error = %v", commentErr)
 	}
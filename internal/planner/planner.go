@@ -0,0 +1,238 @@
+package planner
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ssulei7/gh-dormant-users/internal/contributions"
+	"github.com/ssulei7/gh-dormant-users/internal/discussions"
+	"github.com/ssulei7/gh-dormant-users/internal/githubapi"
+	"github.com/ssulei7/gh-dormant-users/internal/repository"
+	"github.com/ssulei7/gh-dormant-users/internal/search"
+)
+
+const (
+	// defaultHourlyLimit is assumed for resources the coordinator has not
+	// seen a response for yet.
+	defaultHourlyLimit = 5000
+	// defaultSearchLimit is the authenticated search limit, which resets
+	// every minute rather than every hour.
+	defaultSearchLimit = 30
+)
+
+// perRepositoryTypes each cost at least one request per repository.
+var perRepositoryTypes = []string{"issues", "issue-comments", "pr-comments"}
+
+// Input describes the organization being scanned
+type Input struct {
+	Members       int
+	Repositories  repository.Repositories
+	Since         time.Time
+	ActivityTypes []string
+	RateLimits    map[string]githubapi.RateLimit
+}
+
+// Estimate is the expected request cost of one scan strategy. Request counts
+// are lower bounds: every list endpoint is assumed to fit in a single page.
+type Estimate struct {
+	Strategy    string
+	Description string
+	Requests    map[string]int
+	Unsupported []string
+	// BudgetShare is the largest fraction of a resource's capacity over the
+	// next hour that the strategy would consume.
+	BudgetShare float64
+}
+
+// Covers reports whether the strategy checks every requested activity type
+func (e Estimate) Covers() bool {
+	return len(e.Unsupported) == 0
+}
+
+// Plan is the set of estimates and the strategy chosen from them
+type Plan struct {
+	Estimates []Estimate
+	Strategy  string
+	Reason    string
+}
+
+// Build estimates every strategy and chooses the cheapest one that covers all
+// requested activity types. The repository scan covers every type, so it is
+// always a candidate.
+func Build(input Input) Plan {
+	estimates := []Estimate{
+		estimateRepositoryScan(input),
+		estimateUserScan(input),
+		estimateSearchScan(input),
+	}
+
+	chosen := estimates[0]
+	for _, estimate := range estimates[1:] {
+		if estimate.Covers() && cheaper(estimate, chosen) {
+			chosen = estimate
+		}
+	}
+
+	reason := fmt.Sprintf("lowest estimated cost (%.1f%% of the rate limit available over the next hour)", chosen.BudgetShare*100)
+	return Plan{Estimates: estimates, Strategy: chosen.Strategy, Reason: reason}
+}
+
+// Override records a strategy chosen explicitly instead of by cost
+func (p *Plan) Override(strategy string) {
+	p.Strategy = strategy
+	p.Reason = "requested with --scan-strategy"
+}
+
+// Summary renders the plan for display
+func (p Plan) Summary() string {
+	var lines []string
+	for _, estimate := range p.Estimates {
+		line := fmt.Sprintf("%-6s ~%s (%s)", estimate.Strategy, formatRequests(estimate.Requests), estimate.Description)
+		if !estimate.Covers() {
+			line += fmt.Sprintf("; does not check %s", strings.Join(estimate.Unsupported, ", "))
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "", fmt.Sprintf("Selected: %s, %s", p.Strategy, p.Reason))
+	return strings.Join(lines, "\n")
+}
+
+func estimateRepositoryScan(input Input) Estimate {
+	types := typeSet(input.ActivityTypes)
+	requests := map[string]int{}
+	discussionRepositories := 0
+	for _, repo := range input.Repositories {
+		pushedSince := repo.PushedAt == nil || !repo.PushedAt.Before(input.Since)
+		if types["commits"] && repo.Size > 0 && pushedSince {
+			requests["core"]++
+		}
+		for _, activityType := range perRepositoryTypes {
+			if types[activityType] {
+				requests["core"]++
+			}
+		}
+		if types["pull-requests"] || types["pr-reviews"] {
+			requests["core"]++
+		}
+		// Reviews cost one request per recently updated pull request; assume
+		// one for every repository with recent pushes.
+		if types["pr-reviews"] && repo.Size > 0 && pushedSince {
+			requests["core"]++
+		}
+		if repo.HasDiscussions {
+			discussionRepositories++
+		}
+	}
+	if types["discussions"] && discussionRepositories > 0 {
+		requests["graphql"] += ceilDiv(discussionRepositories, discussions.RepositoryBatchSize)
+	}
+	return newEstimate(input, "repos", "walk every repository for each activity type", requests, nil)
+}
+
+func estimateUserScan(input Input) Estimate {
+	requests := map[string]int{}
+	if input.Members > 0 {
+		// One organization ID lookup plus one query per batch of members.
+		requests["graphql"] = 1 + ceilDiv(input.Members, contributions.UserBatchSize)
+	}
+	unsupported := contributions.UnsupportedActivityTypes(input.ActivityTypes)
+	return newEstimate(input, "users", "query each member's contributions in batches", requests, unsupported)
+}
+
+func estimateSearchScan(input Input) Estimate {
+	types := typeSet(input.ActivityTypes)
+	requests := map[string]int{}
+	perUser := 0
+	if slices.ContainsFunc(input.ActivityTypes, func(t string) bool { return slices.Contains(search.CommitActivityTypes, t) }) {
+		perUser++
+	}
+	if types["issues"] || types["pull-requests"] {
+		perUser++
+	}
+	if types["issue-comments"] || types["pr-comments"] {
+		perUser++
+		// A comment found by search is confirmed by listing the comments of
+		// the issue; assume one listing for every member.
+		if input.Members > 0 {
+			requests["core"] = input.Members
+		}
+	}
+	if perUser > 0 && input.Members > 0 {
+		requests["search"] = input.Members * perUser
+	}
+	unsupported := search.UnsupportedActivityTypes(input.ActivityTypes)
+	return newEstimate(input, "search", "search commits, issues and comments for each member", requests, unsupported)
+}
+
+func newEstimate(input Input, strategy string, description string, requests map[string]int, unsupported []string) Estimate {
+	estimate := Estimate{Strategy: strategy, Description: description, Requests: requests, Unsupported: unsupported}
+	for resource, count := range requests {
+		available := hourlyCapacity(resource, input.RateLimits)
+		estimate.BudgetShare = math.Max(estimate.BudgetShare, float64(count)/float64(available))
+	}
+	return estimate
+}
+
+// hourlyCapacity is the number of requests a resource allows over the next
+// hour: what remains of an hourly limit, or sixty windows of the search limit.
+func hourlyCapacity(resource string, limits map[string]githubapi.RateLimit) int {
+	rate, known := limits[resource]
+	known = known && rate.Limit > 0
+	if resource == "search" {
+		if known {
+			return rate.Limit * 60
+		}
+		return defaultSearchLimit * 60
+	}
+	if known {
+		return max(rate.Remaining, 1)
+	}
+	return defaultHourlyLimit
+}
+
+func cheaper(candidate Estimate, current Estimate) bool {
+	if candidate.BudgetShare != current.BudgetShare {
+		return candidate.BudgetShare < current.BudgetShare
+	}
+	return totalRequests(candidate) < totalRequests(current)
+}
+
+func totalRequests(estimate Estimate) int {
+	total := 0
+	for _, count := range estimate.Requests {
+		total += count
+	}
+	return total
+}
+
+func formatRequests(requests map[string]int) string {
+	if len(requests) == 0 {
+		return "0 requests"
+	}
+	resources := make([]string, 0, len(requests))
+	for resource := range requests {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+	parts := make([]string, 0, len(resources))
+	for _, resource := range resources {
+		parts = append(parts, fmt.Sprintf("%d %s", requests[resource], resource))
+	}
+	return strings.Join(parts, " + ") + " requests"
+}
+
+func typeSet(types []string) map[string]bool {
+	set := make(map[string]bool, len(types))
+	for _, activityType := range types {
+		set[activityType] = true
+	}
+	return set
+}
+
+func ceilDiv(value int, divisor int) int {
+	return (value + divisor - 1) / divisor
+}
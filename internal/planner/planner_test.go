@@ -0,0 +1,127 @@
+package planner
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ssulei7/gh-dormant-users/internal/githubapi"
+	"github.com/ssulei7/gh-dormant-users/internal/repository"
+)
+
+var since = time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+
+func repositories(count int) repository.Repositories {
+	recent := since.Add(24 * time.Hour)
+	repos := make(repository.Repositories, 0, count)
+	for index := range count {
+		repos = append(repos, repository.Repository{Name: fmt.Sprintf("repo%d", index), Size: 10, PushedAt: &recent})
+	}
+	return repos
+}
+
+func TestBuildPrefersUserScanForManyRepositories(t *testing.T) {
+	t.Parallel()
+
+	plan := Build(Input{
+		Members:       100,
+		Repositories:  repositories(2000),
+		Since:         since,
+		ActivityTypes: []string{"commits", "issues"},
+	})
+	if plan.Strategy != "users" {
+		t.Fatalf("strategy = %q\n%s", plan.Strategy, plan.Summary())
+	}
+	if got := plan.Estimates[0].Requests["core"]; got != 4000 {
+		t.Fatalf("repository scan core requests = %d, want 4000", got)
+	}
+	if got := plan.Estimates[1].Requests["graphql"]; got != 5 {
+		t.Fatalf("user scan graphql requests = %d, want 5", got)
+	}
+}
+
+func TestBuildRequiresCoverageOfSelectedTypes(t *testing.T) {
+	t.Parallel()
+
+	plan := Build(Input{
+		Members:       100,
+		Repositories:  repositories(2000),
+		Since:         since,
+		ActivityTypes: []string{"commits", "discussions"},
+	})
+	if plan.Strategy != "repos" {
+		t.Fatalf("strategy = %q", plan.Strategy)
+	}
+	if summary := plan.Summary(); !strings.Contains(summary, "does not check discussions") {
+		t.Fatalf("summary = %s", summary)
+	}
+}
+
+func TestBuildPrefersSearchForCommentActivity(t *testing.T) {
+	t.Parallel()
+
+	plan := Build(Input{
+		Members:       100,
+		Repositories:  repositories(2000),
+		Since:         since,
+		ActivityTypes: []string{"commits", "issue-comments"},
+	})
+	if plan.Strategy != "search" {
+		t.Fatalf("strategy = %q\n%s", plan.Strategy, plan.Summary())
+	}
+	if got := plan.Estimates[2].Requests["search"]; got != 200 {
+		t.Fatalf("search requests = %d, want 200", got)
+	}
+	if got := plan.Estimates[2].Requests["core"]; got != 100 {
+		t.Fatalf("comment listing requests = %d, want 100", got)
+	}
+}
+
+func TestBuildSkipsCommitsForStaleRepositories(t *testing.T) {
+	t.Parallel()
+
+	stale := since.Add(-24 * time.Hour)
+	plan := Build(Input{
+		Repositories: repository.Repositories{
+			{Name: "stale", Size: 10, PushedAt: &stale},
+			{Name: "empty"},
+			{Name: "active", Size: 10},
+		},
+		Since:         since,
+		ActivityTypes: []string{"commits"},
+	})
+	if got := plan.Estimates[0].Requests["core"]; got != 1 {
+		t.Fatalf("core requests = %d, want 1", got)
+	}
+}
+
+func TestBuildUsesRemainingRateLimit(t *testing.T) {
+	t.Parallel()
+
+	// With the GraphQL budget nearly spent, the repository scan is cheaper
+	// relative to what remains.
+	plan := Build(Input{
+		Members:       1000,
+		Repositories:  repositories(50),
+		Since:         since,
+		ActivityTypes: []string{"commits"},
+		RateLimits: map[string]githubapi.RateLimit{
+			"core":    {Limit: 5000, Remaining: 5000},
+			"graphql": {Limit: 5000, Remaining: 100},
+		},
+	})
+	if plan.Strategy != "repos" {
+		t.Fatalf("strategy = %q\n%s", plan.Strategy, plan.Summary())
+	}
+}
+
+func TestPlanOverride(t *testing.T) {
+	t.Parallel()
+
+	plan := Build(Input{Repositories: repositories(1), Since: since, ActivityTypes: []string{"commits"}})
+	plan.Override("users")
+	if summary := plan.Summary(); !strings.Contains(summary, "Selected: users, requested with --scan-strategy") {
+		t.Fatalf("summary = %s", summary)
+	}
+}
@@ -0,0 +1,122 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cli/go-gh/pkg/api"
+	"github.com/ssulei7/gh-dormant-users/internal/githubapi"
+)
+
+// Result identifies the issue a report was published to
+type Result struct {
+	Number  int
+	URL     string
+	Created bool
+}
+
+type issue struct {
+	Number      int       `json:"number"`
+	Body        string    `json:"body"`
+	HTMLURL     string    `json:"html_url"`
+	PullRequest *struct{} `json:"pull_request"`
+}
+
+// ParseRepository validates a repository given as owner/repo
+func ParseRepository(repository string) (string, error) {
+	owner, name, ok := strings.Cut(strings.TrimSpace(repository), "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return "", fmt.Errorf("invalid repository %q; expected owner/repo", repository)
+	}
+	return owner + "/" + name, nil
+}
+
+// Marker is the hidden comment that ties an issue to the reports for key, so
+// later runs update the issue instead of opening another one. The key is
+// hashed, so the marker has the same length however many organizations it
+// covers.
+func Marker(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return fmt.Sprintf("<!-- gh-dormant-users:%s -->", hex.EncodeToString(sum[:16]))
+}
+
+// Issue publishes body to the open issue in repository that carries the
+// marker for key, or opens a new issue with title when there is none. Task
+// list items already ticked in the issue stay ticked when they are still
+// listed, so progress on a review is not lost when the report is updated.
+func Issue(ctx context.Context, client api.RESTClient, repository string, key string, title string, body string) (*Result, error) {
+	marker := Marker(key)
+	existing, err := findIssue(ctx, client, repository, marker)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil {
+		body = keepTicked(existing.Body, body)
+	}
+	payload, err := json.Marshal(map[string]string{"title": title, "body": body + "\n\n" + marker + "\n"})
+	if err != nil {
+		return nil, err
+	}
+	method, path := http.MethodPost, fmt.Sprintf("repos/%s/issues", repository)
+	if existing != nil {
+		method, path = http.MethodPatch, fmt.Sprintf("repos/%s/issues/%d", repository, existing.Number)
+	}
+	var published issue
+	if err := client.DoWithContext(ctx, method, path, bytes.NewReader(payload), &published); err != nil {
+		if existing != nil {
+			return nil, fmt.Errorf("update issue #%d in %s: %w", existing.Number, repository, err)
+		}
+		return nil, fmt.Errorf("create issue in %s: %w", repository, err)
+	}
+	return &Result{Number: published.Number, URL: published.HTMLURL, Created: existing == nil}, nil
+}
+
+// keepTicked ticks the task list items of body that are ticked in previous
+func keepTicked(previous string, body string) string {
+	ticked := make(map[string]bool)
+	for _, line := range strings.Split(previous, "\n") {
+		line = strings.TrimRight(line, "\r")
+		for _, prefix := range []string{"- [x] ", "- [X] "} {
+			if item, ok := strings.CutPrefix(line, prefix); ok {
+				ticked[item] = true
+			}
+		}
+	}
+	if len(ticked) == 0 {
+		return body
+	}
+	lines := strings.Split(body, "\n")
+	for index, line := range lines {
+		if item, ok := strings.CutPrefix(line, "- [ ] "); ok && ticked[item] {
+			lines[index] = "- [x] " + item
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// findIssue returns the open issue whose body contains marker. Pull requests,
+// which the issues endpoint also lists, are skipped.
+func findIssue(ctx context.Context, client api.RESTClient, repository string, marker string) (*issue, error) {
+	var found *issue
+	url := fmt.Sprintf("repos/%s/issues?state=open&per_page=100", repository)
+	_, err := githubapi.GetAllWhile(ctx, client, url, func(page []issue) bool {
+		for index := range page {
+			if page[index].PullRequest == nil && strings.Contains(page[index].Body, marker) {
+				found = &page[index]
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list issues in %s: %w", repository, err)
+	}
+	return found, nil
+}
@@ -0,0 +1,170 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cli/go-gh/pkg/api"
+)
+
+type mockRESTClient struct {
+	routes   map[string]string
+	requests []string
+	bodies   map[string]string
+}
+
+func (m *mockRESTClient) Request(method string, path string, body io.Reader) (*http.Response, error) {
+	key := method + " " + path
+	m.requests = append(m.requests, key)
+	if body != nil {
+		content, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		m.bodies[key] = string(content)
+	}
+	response, ok := m.routes[key]
+	if !ok {
+		return nil, fmt.Errorf("unexpected request: %s", key)
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(bytes.NewBufferString(response)),
+	}, nil
+}
+
+func (m *mockRESTClient) RequestWithContext(_ context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	return m.Request(method, path, body)
+}
+
+func (m *mockRESTClient) Do(method, path string, body io.Reader, result interface{}) error {
+	response, err := m.Request(method, path, body)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(response.Body).Decode(result)
+}
+
+func (m *mockRESTClient) DoWithContext(_ context.Context, method, path string, body io.Reader, result interface{}) error {
+	return m.Do(method, path, body, result)
+}
+
+func (m *mockRESTClient) Delete(path string, result interface{}) error {
+	return m.Do(http.MethodDelete, path, nil, result)
+}
+
+func (m *mockRESTClient) Get(path string, result interface{}) error {
+	return m.Do(http.MethodGet, path, nil, result)
+}
+
+func (m *mockRESTClient) Patch(path string, body io.Reader, result interface{}) error {
+	return m.Do(http.MethodPatch, path, body, result)
+}
+
+func (m *mockRESTClient) Post(path string, body io.Reader, result interface{}) error {
+	return m.Do(http.MethodPost, path, body, result)
+}
+
+func (m *mockRESTClient) Put(path string, body io.Reader, result interface{}) error {
+	return m.Do(http.MethodPut, path, body, result)
+}
+
+var _ api.RESTClient = (*mockRESTClient)(nil)
+
+const listIssues = "GET repos/example/audits/issues?state=open&per_page=100"
+
+func TestIssueCreatesIssueWithoutMarker(t *testing.T) {
+	client := &mockRESTClient{
+		bodies: map[string]string{},
+		routes: map[string]string{
+			listIssues:                         `[{"number": 3, "body": "unrelated"}]`,
+			"POST repos/example/audits/issues": `{"number": 7, "html_url": "https://github.com/example/audits/issues/7"}`,
+		},
+	}
+	result, err := Issue(context.Background(), client, "example/audits", "example", "Dormant users: example", "report")
+	if err != nil {
+		t.Fatalf("Issue returned error: %v", err)
+	}
+	if !result.Created || result.Number != 7 || result.URL != "https://github.com/example/audits/issues/7" {
+		t.Fatalf("result = %+v", result)
+	}
+	var payload map[string]string
+	if err := json.Unmarshal([]byte(client.bodies["POST repos/example/audits/issues"]), &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if payload["title"] != "Dormant users: example" || !strings.HasPrefix(payload["body"], "report") || !strings.Contains(payload["body"], Marker("example")) {
+		t.Fatalf("payload = %v", payload)
+	}
+}
+
+func TestIssueUpdatesIssueWithMarker(t *testing.T) {
+	client := &mockRESTClient{
+		bodies: map[string]string{},
+		routes: map[string]string{
+			// A pull request carrying the marker is not the report issue.
+			listIssues:                            fmt.Sprintf(`[{"number": 2, "body": %q, "pull_request": {}}, {"number": 5, "body": %q}]`, Marker("example"), "old report\n"+Marker("example")),
+			"PATCH repos/example/audits/issues/5": `{"number": 5, "html_url": "https://github.com/example/audits/issues/5"}`,
+		},
+	}
+	result, err := Issue(context.Background(), client, "example/audits", "example", "Dormant users: example", "new report")
+	if err != nil {
+		t.Fatalf("Issue returned error: %v", err)
+	}
+	if result.Created || result.Number != 5 {
+		t.Fatalf("result = %+v", result)
+	}
+	if !strings.Contains(client.bodies["PATCH repos/example/audits/issues/5"], "new report") {
+		t.Fatalf("requests = %v, bodies = %v", client.requests, client.bodies)
+	}
+}
+
+func TestIssueKeepsTickedItems(t *testing.T) {
+	previous := "## Dormant members\n\n- [x] hubot\r\n- [X] departed\n- [ ] monalisa\n\n" + Marker("example")
+	client := &mockRESTClient{
+		bodies: map[string]string{},
+		routes: map[string]string{
+			listIssues:                            fmt.Sprintf(`[{"number": 5, "body": %q}]`, previous),
+			"PATCH repos/example/audits/issues/5": `{"number": 5}`,
+		},
+	}
+	body := "## Dormant members\n\n- [ ] hubot\n- [ ] monalisa\n- [ ] newcomer\n"
+	if _, err := Issue(context.Background(), client, "example/audits", "example", "Dormant users: example", body); err != nil {
+		t.Fatalf("Issue returned error: %v", err)
+	}
+	var payload map[string]string
+	if err := json.Unmarshal([]byte(client.bodies["PATCH repos/example/audits/issues/5"]), &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if !strings.HasPrefix(payload["body"], "## Dormant members\n\n- [x] hubot\n- [ ] monalisa\n- [ ] newcomer\n") {
+		t.Fatalf("body = %q", payload["body"])
+	}
+}
+
+func TestMarkerHasFixedLength(t *testing.T) {
+	short, long := Marker("one"), Marker(strings.Repeat("organization,", 500))
+	if len(short) != len(long) || short == Marker("two") {
+		t.Fatalf("markers = %q, %q", short, long)
+	}
+}
+
+func TestParseRepository(t *testing.T) {
+	if got, err := ParseRepository("example/audits"); err != nil || got != "example/audits" {
+		t.Fatalf("ParseRepository = %q, %v", got, err)
+	}
+	for _, repository := range []string{"", "example", "/audits", "example/audits/extra"} {
+		if _, err := ParseRepository(repository); err == nil {
+			t.Errorf("ParseRepository(%q) returned no error", repository)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package pullrequests
 
 import (
+	"context"
 	"fmt"
+	"iter"
 	"strings"
+	"time"
 
 	"github.com/cli/go-gh/pkg/api"
 	"github.com/ssulei7/gh-dormant-users/internal/githubapi"
@@ -10,6 +13,7 @@ import (
 
 type PullRequestComment struct {
 	ID        int    `json:"id"`
+	HTMLURL   string `json:"html_url"`
 	CreatedAt string `json:"created_at"`
 	UpdatedAt string `json:"updated_at"`
 	User      struct {
@@ -17,16 +21,86 @@ type PullRequestComment struct {
 	} `json:"user"`
 }
 
+type PullRequest struct {
+	Number    int    `json:"number"`
+	HTMLURL   string `json:"html_url"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+	User      struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+type PullRequestReview struct {
+	ID          int    `json:"id"`
+	HTMLURL     string `json:"html_url"`
+	State       string `json:"state"`
+	SubmittedAt string `json:"submitted_at"`
+	User        struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
 type PullRequestComments []PullRequestComment
+type PullRequests []PullRequest
+type PullRequestReviews []PullRequestReview
 
-func GetPullRequestCommentsSinceDate(organization string, repo string, date string, client api.RESTClient) (PullRequestComments, error) {
+// PullRequestCommentPagesSinceDate streams the review comments updated since the date one page at a time
+func PullRequestCommentPagesSinceDate(ctx context.Context, organization string, repo string, date string, client api.RESTClient, prefetch bool) iter.Seq2[[]PullRequestComment, error] {
 	url := fmt.Sprintf("repos/%s/%s/pulls/comments?per_page=100&since=%s", organization, repo, date)
-	comments, err := githubapi.GetAll[PullRequestComment](client, url)
+	return func(yield func([]PullRequestComment, error) bool) {
+		for page, err := range githubapi.Pages[PullRequestComment](ctx, client, url, prefetch) {
+			if err != nil {
+				if !strings.Contains(err.Error(), "Git Repository is empty.") {
+					yield(nil, fmt.Errorf("fetch pull request comments for %s/%s: %w", organization, repo, err))
+				}
+				return
+			}
+			if !yield(page, nil) {
+				return
+			}
+		}
+	}
+}
+
+// GetPullRequestsUpdatedSinceDate returns pull requests updated on or after
+// date. The pulls endpoint has no since filter, so pages are read newest
+// first and pagination stops at the first pull request older than date.
+func GetPullRequestsUpdatedSinceDate(ctx context.Context, organization string, repo string, date string, client api.RESTClient) (PullRequests, error) {
+	since, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return nil, fmt.Errorf("parse date %q: %w", date, err)
+	}
+	updatedSince := func(pullRequest PullRequest) bool {
+		updatedAt, err := time.Parse(time.RFC3339, pullRequest.UpdatedAt)
+		return err != nil || !updatedAt.Before(since)
+	}
+
+	url := fmt.Sprintf("repos/%s/%s/pulls?state=all&sort=updated&direction=desc&per_page=100", organization, repo)
+	pullRequests, err := githubapi.GetAllWhile(ctx, client, url, func(page []PullRequest) bool {
+		return len(page) > 0 && updatedSince(page[len(page)-1])
+	})
 	if err != nil {
 		if strings.Contains(err.Error(), "Git Repository is empty.") {
 			return nil, nil
 		}
-		return nil, fmt.Errorf("fetch pull request comments for %s/%s: %w", organization, repo, err)
+		return nil, fmt.Errorf("fetch pull requests for %s/%s: %w", organization, repo, err)
+	}
+
+	recent := make(PullRequests, 0, len(pullRequests))
+	for _, pullRequest := range pullRequests {
+		if updatedSince(pullRequest) {
+			recent = append(recent, pullRequest)
+		}
+	}
+	return recent, nil
+}
+
+func GetPullRequestReviews(ctx context.Context, organization string, repo string, number int, client api.RESTClient) (PullRequestReviews, error) {
+	url := fmt.Sprintf("repos/%s/%s/pulls/%d/reviews?per_page=100", organization, repo, number)
+	reviews, err := githubapi.GetAll[PullRequestReview](ctx, client, url)
+	if err != nil {
+		return nil, fmt.Errorf("fetch reviews for %s/%s#%d: %w", organization, repo, number, err)
 	}
-	return PullRequestComments(comments), nil
+	return PullRequestReviews(reviews), nil
 }
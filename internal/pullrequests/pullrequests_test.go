@@ -9,22 +9,31 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+
+	"github.com/ssulei7/gh-dormant-users/internal/githubapi"
 )
 
 type mockRESTClient struct {
-	path string
-	body string
-	err  error
+	path     string
+	body     string
+	link     string
+	err      error
+	requests int
 }
 
 func (m *mockRESTClient) Request(_ string, path string, _ io.Reader) (*http.Response, error) {
 	m.path = path
+	m.requests++
 	if m.err != nil {
 		return nil, m.err
 	}
+	header := make(http.Header)
+	if m.link != "" {
+		header.Set("Link", m.link)
+	}
 	return &http.Response{
 		StatusCode: http.StatusOK,
-		Header:     make(http.Header),
+		Header:     header,
 		Body:       io.NopCloser(bytes.NewBufferString(m.body)),
 	}, nil
 }
@@ -68,13 +77,13 @@ func (m *mockRESTClient) Put(path string, body io.Reader, result interface{}) er
 
 func (m *mockRESTClient) RESTPrefix() string { return "" }
 
-func TestGetPullRequestCommentsSinceDate(t *testing.T) {
+func TestPullRequestCommentPagesSinceDate(t *testing.T) {
 	t.Parallel()
 
 	client := &mockRESTClient{body: `[{"id":3,"user":{"login":"octocat"}}]`}
-	comments, err := GetPullRequestCommentsSinceDate("example", "widgets", "2026-07-01T00:00:00Z", client)
+	comments, err := githubapi.Collect(PullRequestCommentPagesSinceDate(context.Background(), "example", "widgets", "2026-07-01T00:00:00Z", client, false))
 	if err != nil {
-		t.Fatalf("GetPullRequestCommentsSinceDate returned error: %v", err)
+		t.Fatalf("PullRequestCommentPagesSinceDate returned error: %v", err)
 	}
 	if client.path != "repos/example/widgets/pulls/comments?per_page=100&since=2026-07-01T00:00:00Z" {
 		t.Fatalf("request path = %q", client.path)
@@ -84,25 +93,84 @@ func TestGetPullRequestCommentsSinceDate(t *testing.T) {
 	}
 }
 
-func TestGetPullRequestCommentsSinceDateEmptyRepository(t *testing.T) {
+func TestPullRequestCommentPagesSinceDateEmptyRepository(t *testing.T) {
 	t.Parallel()
 
 	client := &mockRESTClient{err: errors.New("Git Repository is empty.")}
-	comments, err := GetPullRequestCommentsSinceDate("example", "empty", "2026-07-01T00:00:00Z", client)
+	comments, err := githubapi.Collect(PullRequestCommentPagesSinceDate(context.Background(), "example", "empty", "2026-07-01T00:00:00Z", client, false))
 	if err != nil {
-		t.Fatalf("GetPullRequestCommentsSinceDate returned error: %v", err)
+		t.Fatalf("PullRequestCommentPagesSinceDate returned error: %v", err)
 	}
 	if comments != nil {
 		t.Fatalf("comments = %#v, want nil", comments)
 	}
 }
 
-func TestGetPullRequestCommentsSinceDateWrapsError(t *testing.T) {
+func TestPullRequestCommentPagesSinceDateWrapsError(t *testing.T) {
 	t.Parallel()
 
 	client := &mockRESTClient{err: errors.New("boom")}
-	_, err := GetPullRequestCommentsSinceDate("example", "widgets", "2026-07-01T00:00:00Z", client)
+	_, err := githubapi.Collect(PullRequestCommentPagesSinceDate(context.Background(), "example", "widgets", "2026-07-01T00:00:00Z", client, false))
 	if err == nil || !strings.Contains(err.Error(), "fetch pull request comments for example/widgets") || !strings.Contains(err.Error(), "boom") {
 		t.Fatalf("error = %v", err)
 	}
 }
+
+func TestGetPullRequestsUpdatedSinceDateStopsAtOlderPullRequests(t *testing.T) {
+	t.Parallel()
+
+	client := &mockRESTClient{
+		body: `[
+			{"number":3,"updated_at":"2026-07-09T00:00:00Z","created_at":"2026-07-08T00:00:00Z","user":{"login":"octocat"}},
+			{"number":2,"updated_at":"2026-06-30T00:00:00Z","created_at":"2026-06-01T00:00:00Z","user":{"login":"hubot"}}
+		]`,
+		link: `<https://api.github.com/repos/example/widgets/pulls?page=2>; rel="next"`,
+	}
+	pullRequests, err := GetPullRequestsUpdatedSinceDate(context.Background(), "example", "widgets", "2026-07-01T00:00:00Z", client)
+	if err != nil {
+		t.Fatalf("GetPullRequestsUpdatedSinceDate returned error: %v", err)
+	}
+	if client.path != "repos/example/widgets/pulls?state=all&sort=updated&direction=desc&per_page=100" {
+		t.Fatalf("request path = %q", client.path)
+	}
+	if client.requests != 1 {
+		t.Fatalf("requests = %d, want pagination to stop after the first page", client.requests)
+	}
+	if len(pullRequests) != 1 || pullRequests[0].Number != 3 {
+		t.Fatalf("pull requests = %#v", pullRequests)
+	}
+}
+
+func TestGetPullRequestsUpdatedSinceDateErrors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := GetPullRequestsUpdatedSinceDate(context.Background(), "example", "widgets", "not-a-date", &mockRESTClient{}); err == nil {
+		t.Fatal("expected invalid date error")
+	}
+	client := &mockRESTClient{err: errors.New("boom")}
+	_, err := GetPullRequestsUpdatedSinceDate(context.Background(), "example", "widgets", "2026-07-01T00:00:00Z", client)
+	if err == nil || !strings.Contains(err.Error(), "fetch pull requests for example/widgets") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestGetPullRequestReviews(t *testing.T) {
+	t.Parallel()
+
+	client := &mockRESTClient{body: `[{"id":7,"state":"APPROVED","submitted_at":"2026-07-02T00:00:00Z","user":{"login":"reviewer"}}]`}
+	reviews, err := GetPullRequestReviews(context.Background(), "example", "widgets", 12, client)
+	if err != nil {
+		t.Fatalf("GetPullRequestReviews returned error: %v", err)
+	}
+	if client.path != "repos/example/widgets/pulls/12/reviews?per_page=100" {
+		t.Fatalf("request path = %q", client.path)
+	}
+	if len(reviews) != 1 || reviews[0].User.Login != "reviewer" || reviews[0].State != "APPROVED" {
+		t.Fatalf("reviews = %#v", reviews)
+	}
+
+	_, err = GetPullRequestReviews(context.Background(), "example", "widgets", 12, &mockRESTClient{err: errors.New("boom")})
+	if err == nil || !strings.Contains(err.Error(), "fetch reviews for example/widgets#12") {
+		t.Fatalf("error = %v", err)
+	}
+}
@@ -0,0 +1,285 @@
+package remediation
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/pkg/api"
+	"github.com/ssulei7/gh-dormant-users/internal/githubapi"
+)
+
+// Action identifies the change applied to each dormant user
+type Action string
+
+const (
+	ActionRemove          Action = "remove"
+	ActionConvert         Action = "convert"
+	ActionRemoveFromTeams Action = "remove-from-teams"
+)
+
+// Result statuses recorded in the remediation log
+const (
+	StatusSucceeded = "succeeded"
+	StatusSkipped   = "skipped"
+	StatusFailed    = "failed"
+)
+
+// ParseAction validates an action name supplied on the command line
+func ParseAction(name string) (Action, error) {
+	switch action := Action(strings.ToLower(strings.TrimSpace(name))); action {
+	case ActionRemove, ActionConvert, ActionRemoveFromTeams:
+		return action, nil
+	default:
+		return "", fmt.Errorf("invalid action %q; expected remove, convert or remove-from-teams", name)
+	}
+}
+
+// Describe returns a human readable description of the action
+func (a Action) Describe() string {
+	switch a {
+	case ActionRemove:
+		return "remove from organization"
+	case ActionConvert:
+		return "convert to outside collaborator"
+	case ActionRemoveFromTeams:
+		return "remove from team"
+	default:
+		return string(a)
+	}
+}
+
+// Step is a single API change planned for one user
+type Step struct {
+	Username string
+	Action   Action
+	Target   string
+}
+
+// Plan holds every change a remediation run would make
+type Plan struct {
+	Organization string
+	Action       Action
+	Steps        []Step
+}
+
+// Users returns the number of distinct users affected by the plan
+func (p *Plan) Users() int {
+	seen := make(map[string]bool, len(p.Steps))
+	for _, step := range p.Steps {
+		seen[step.Username] = true
+	}
+	return len(seen)
+}
+
+// Result records the outcome of a single step
+type Result struct {
+	Step
+	Status      string
+	Error       string
+	CompletedAt time.Time
+}
+
+type team struct {
+	Slug string `json:"slug"`
+}
+
+type teamMember struct {
+	Login string `json:"login"`
+}
+
+// ReadDormantUsers returns the logins marked inactive in a report CSV. A
+// partial report from an interrupted run lists members that were never
+// checked as inactive, so it is refused unless allowPartial is set.
+func ReadDormantUsers(csvPath string, allowPartial bool) ([]string, error) {
+	file, err := os.Open(csvPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV file: %w", err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV file: %w", err)
+	}
+	if len(records) < 1 {
+		return nil, fmt.Errorf("CSV file is empty")
+	}
+
+	colIndex := make(map[string]int)
+	for i, col := range records[0] {
+		colIndex[strings.ToLower(strings.TrimSpace(col))] = i
+	}
+	for _, col := range []string{"username", "active"} {
+		if _, ok := colIndex[col]; !ok {
+			return nil, fmt.Errorf("missing required column: %s", col)
+		}
+	}
+
+	if partial, ok := colIndex["partial"]; ok && !allowPartial {
+		for _, row := range records[1:] {
+			if len(row) > partial && strings.EqualFold(strings.TrimSpace(row[partial]), "true") {
+				return nil, fmt.Errorf("%s is a partial report from an interrupted run, so members that were not checked are listed as inactive", csvPath)
+			}
+		}
+	}
+
+	var logins []string
+	for _, row := range records[1:] {
+		if len(row) <= colIndex["username"] || len(row) <= colIndex["active"] {
+			continue
+		}
+		login := strings.TrimSpace(row[colIndex["username"]])
+		if login == "" || strings.ToLower(strings.TrimSpace(row[colIndex["active"]])) != "false" {
+			continue
+		}
+		logins = append(logins, login)
+	}
+	return logins, nil
+}
+
+// BuildPlan resolves the changes needed to apply an action to the given users.
+// Team removal looks up current team memberships so the plan only contains
+// memberships that exist; the other actions need no lookups.
+func BuildPlan(ctx context.Context, organization string, action Action, logins []string, teams []string, client api.RESTClient) (*Plan, error) {
+	plan := &Plan{Organization: organization, Action: action}
+	if action != ActionRemoveFromTeams {
+		for _, login := range logins {
+			plan.Steps = append(plan.Steps, Step{Username: login, Action: action, Target: organization})
+		}
+		return plan, nil
+	}
+
+	if len(teams) == 0 {
+		teamList, err := githubapi.GetAll[team](ctx, client, fmt.Sprintf("orgs/%s/teams?per_page=100", organization))
+		if err != nil {
+			return nil, fmt.Errorf("fetch organization teams: %w", err)
+		}
+		for _, item := range teamList {
+			teams = append(teams, item.Slug)
+		}
+	}
+
+	dormant := make(map[string]bool, len(logins))
+	for _, login := range logins {
+		dormant[strings.ToLower(login)] = true
+	}
+	for _, slug := range teams {
+		url := fmt.Sprintf("orgs/%s/teams/%s/members?per_page=100", organization, slug)
+		members, err := githubapi.GetAll[teamMember](ctx, client, url)
+		if err != nil {
+			return nil, fmt.Errorf("fetch members of team %s: %w", slug, err)
+		}
+		for _, member := range members {
+			if dormant[strings.ToLower(member.Login)] {
+				plan.Steps = append(plan.Steps, Step{Username: member.Login, Action: action, Target: slug})
+			}
+		}
+	}
+	return plan, nil
+}
+
+// Execute applies the steps of the plan in order. A failed step is recorded
+// and does not stop the remaining steps; report is called after each step,
+// and an error from it stops the run. When ctx is cancelled, the step in
+// flight is allowed to finish and no further steps are started; the results
+// so far are returned with the context's error.
+func (p *Plan) Execute(ctx context.Context, client api.RESTClient, now func() time.Time, report func(Result) error) ([]Result, error) {
+	results := make([]Result, 0, len(p.Steps))
+	for _, step := range p.Steps {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		result := Result{Step: step, Status: StatusSucceeded}
+		if err := applyStep(p.Organization, step, client); err != nil {
+			result.Status = StatusFailed
+			result.Error = err.Error()
+			if isNotFound(err) {
+				result.Status = StatusSkipped
+				result.Error = "membership no longer exists"
+			}
+		}
+		result.CompletedAt = now().UTC()
+		results = append(results, result)
+		if report != nil {
+			if err := report(result); err != nil {
+				return results, err
+			}
+		}
+	}
+	return results, nil
+}
+
+func applyStep(organization string, step Step, client api.RESTClient) error {
+	switch step.Action {
+	case ActionRemove:
+		return client.Delete(fmt.Sprintf("orgs/%s/members/%s", organization, step.Username), nil)
+	case ActionConvert:
+		return client.Put(fmt.Sprintf("orgs/%s/outside_collaborators/%s", organization, step.Username), nil, nil)
+	case ActionRemoveFromTeams:
+		return client.Delete(fmt.Sprintf("orgs/%s/teams/%s/memberships/%s", organization, step.Target, step.Username), nil)
+	default:
+		return fmt.Errorf("unsupported action %q", step.Action)
+	}
+}
+
+func isNotFound(err error) bool {
+	var httpError api.HTTPError
+	return errors.As(err, &httpError) && httpError.StatusCode == http.StatusNotFound
+}
+
+// ResultLog is the CSV log of a remediation run. Each result is written and
+// flushed as soon as its step completes, so the log shows which changes were
+// made even if the run is interrupted.
+type ResultLog struct {
+	file   *os.File
+	writer *csv.Writer
+}
+
+// CreateResultLog starts a result log at filePath with its header row
+func CreateResultLog(filePath string) (*ResultLog, error) {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return nil, err
+	}
+	log := &ResultLog{file: file, writer: csv.NewWriter(file)}
+	header := []string{"Username", "Action", "Target", "Status", "Error", "CompletedAt"}
+	if err := log.write(header); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return log, nil
+}
+
+// Write appends one result and flushes it to disk
+func (l *ResultLog) Write(result Result) error {
+	return l.write([]string{
+		result.Username,
+		string(result.Action),
+		result.Target,
+		result.Status,
+		result.Error,
+		result.CompletedAt.Format(time.RFC3339),
+	})
+}
+
+func (l *ResultLog) write(record []string) error {
+	if err := l.writer.Write(record); err != nil {
+		return err
+	}
+	l.writer.Flush()
+	if err := l.writer.Error(); err != nil {
+		return err
+	}
+	return l.file.Sync()
+}
+
+// Close closes the log file
+func (l *ResultLog) Close() error {
+	return l.file.Close()
+}
@@ -0,0 +1,322 @@
+package remediation
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cli/go-gh/pkg/api"
+)
+
+type mockRESTClient struct {
+	routes   map[string]string
+	errs     map[string]error
+	requests []string
+}
+
+func (m *mockRESTClient) Request(method string, path string, _ io.Reader) (*http.Response, error) {
+	key := method + " " + path
+	m.requests = append(m.requests, key)
+	if err := m.errs[key]; err != nil {
+		return nil, err
+	}
+	body, ok := m.routes[key]
+	if !ok && method == http.MethodGet {
+		return nil, fmt.Errorf("unexpected request: %s", key)
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(bytes.NewBufferString(body)),
+	}, nil
+}
+
+func (m *mockRESTClient) RequestWithContext(_ context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	return m.Request(method, path, body)
+}
+
+func (m *mockRESTClient) Do(method, path string, body io.Reader, result interface{}) error {
+	response, err := m.Request(method, path, body)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(response.Body).Decode(result)
+}
+
+func (m *mockRESTClient) DoWithContext(_ context.Context, method, path string, body io.Reader, result interface{}) error {
+	return m.Do(method, path, body, result)
+}
+
+func (m *mockRESTClient) Delete(path string, result interface{}) error {
+	return m.Do(http.MethodDelete, path, nil, result)
+}
+
+func (m *mockRESTClient) Get(path string, result interface{}) error {
+	return m.Do(http.MethodGet, path, nil, result)
+}
+
+func (m *mockRESTClient) Patch(path string, body io.Reader, result interface{}) error {
+	return m.Do(http.MethodPatch, path, body, result)
+}
+
+func (m *mockRESTClient) Post(path string, body io.Reader, result interface{}) error {
+	return m.Do(http.MethodPost, path, body, result)
+}
+
+func (m *mockRESTClient) Put(path string, body io.Reader, result interface{}) error {
+	return m.Do(http.MethodPut, path, body, result)
+}
+
+func (m *mockRESTClient) RESTPrefix() string { return "" }
+
+func fixedNow() time.Time {
+	return time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+}
+
+func writeReport(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "report.csv")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write report: %v", err)
+	}
+	return path
+}
+
+func TestParseAction(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"remove", "CONVERT", " remove-from-teams "} {
+		if _, err := ParseAction(name); err != nil {
+			t.Fatalf("ParseAction(%q) returned error: %v", name, err)
+		}
+	}
+	if _, err := ParseAction("archive"); err == nil || !strings.Contains(err.Error(), "invalid action") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestReadDormantUsers(t *testing.T) {
+	t.Parallel()
+
+	path := writeReport(t, "Username,Email,Active,ActivityTypes\nactive,,true,commits\ndormant,,false,none\n,,false,none\nother,,FALSE,none\n")
+	logins, err := ReadDormantUsers(path, false)
+	if err != nil {
+		t.Fatalf("ReadDormantUsers returned error: %v", err)
+	}
+	if fmt.Sprint(logins) != fmt.Sprint([]string{"dormant", "other"}) {
+		t.Fatalf("logins = %v", logins)
+	}
+}
+
+func TestReadDormantUsersRequiresColumns(t *testing.T) {
+	t.Parallel()
+
+	path := writeReport(t, "Username,Email\noctocat,\n")
+	_, err := ReadDormantUsers(path, false)
+	if err == nil || !strings.Contains(err.Error(), "missing required column: active") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestReadDormantUsersRefusesPartialReport(t *testing.T) {
+	t.Parallel()
+
+	path := writeReport(t, "Username,Email,Active,ActivityTypes,EvidenceComplete,Partial\nactive,,true,commits,false,true\nunchecked,,false,none,false,true\n")
+	if _, err := ReadDormantUsers(path, false); err == nil || !strings.Contains(err.Error(), "partial report") {
+		t.Fatalf("error = %v, want partial report refused", err)
+	}
+	logins, err := ReadDormantUsers(path, true)
+	if err != nil {
+		t.Fatalf("ReadDormantUsers returned error: %v", err)
+	}
+	if fmt.Sprint(logins) != fmt.Sprint([]string{"unchecked"}) {
+		t.Fatalf("logins = %v", logins)
+	}
+}
+
+func TestBuildPlanForOrganizationActions(t *testing.T) {
+	t.Parallel()
+
+	client := &mockRESTClient{}
+	plan, err := BuildPlan(context.Background(), "example", ActionConvert, []string{"one", "two"}, nil, client)
+	if err != nil {
+		t.Fatalf("BuildPlan returned error: %v", err)
+	}
+	if len(client.requests) != 0 {
+		t.Fatalf("requests = %v, want none", client.requests)
+	}
+	if len(plan.Steps) != 2 || plan.Steps[1] != (Step{Username: "two", Action: ActionConvert, Target: "example"}) {
+		t.Fatalf("steps = %#v", plan.Steps)
+	}
+	if plan.Users() != 2 {
+		t.Fatalf("users = %d, want 2", plan.Users())
+	}
+}
+
+func TestBuildPlanForTeamsOnlyIncludesExistingMemberships(t *testing.T) {
+	t.Parallel()
+
+	client := &mockRESTClient{routes: map[string]string{
+		"GET orgs/example/teams?per_page=100":              `[{"slug":"core"},{"slug":"docs"}]`,
+		"GET orgs/example/teams/core/members?per_page=100": `[{"login":"Dormant"},{"login":"active"}]`,
+		"GET orgs/example/teams/docs/members?per_page=100": `[{"login":"active"}]`,
+	}}
+	plan, err := BuildPlan(context.Background(), "example", ActionRemoveFromTeams, []string{"dormant"}, nil, client)
+	if err != nil {
+		t.Fatalf("BuildPlan returned error: %v", err)
+	}
+	if len(plan.Steps) != 1 || plan.Steps[0] != (Step{Username: "Dormant", Action: ActionRemoveFromTeams, Target: "core"}) {
+		t.Fatalf("steps = %#v", plan.Steps)
+	}
+}
+
+func TestBuildPlanForSelectedTeams(t *testing.T) {
+	t.Parallel()
+
+	client := &mockRESTClient{routes: map[string]string{
+		"GET orgs/example/teams/docs/members?per_page=100": `[{"login":"dormant"}]`,
+	}}
+	plan, err := BuildPlan(context.Background(), "example", ActionRemoveFromTeams, []string{"dormant"}, []string{"docs"}, client)
+	if err != nil {
+		t.Fatalf("BuildPlan returned error: %v", err)
+	}
+	if fmt.Sprint(client.requests) != fmt.Sprint([]string{"GET orgs/example/teams/docs/members?per_page=100"}) {
+		t.Fatalf("requests = %v", client.requests)
+	}
+	if len(plan.Steps) != 1 {
+		t.Fatalf("steps = %#v", plan.Steps)
+	}
+}
+
+func TestExecuteAppliesEachStepAndRecordsResults(t *testing.T) {
+	t.Parallel()
+
+	client := &mockRESTClient{errs: map[string]error{
+		"DELETE orgs/example/members/gone":   api.HTTPError{StatusCode: http.StatusNotFound},
+		"DELETE orgs/example/members/locked": api.HTTPError{StatusCode: http.StatusForbidden, Message: "owner"},
+	}}
+	plan := &Plan{Organization: "example", Action: ActionRemove, Steps: []Step{
+		{Username: "dormant", Action: ActionRemove, Target: "example"},
+		{Username: "gone", Action: ActionRemove, Target: "example"},
+		{Username: "locked", Action: ActionRemove, Target: "example"},
+	}}
+
+	var reported []string
+	results, err := plan.Execute(context.Background(), client, fixedNow, func(result Result) error {
+		reported = append(reported, result.Username+"="+result.Status)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	if fmt.Sprint(reported) != fmt.Sprint([]string{"dormant=succeeded", "gone=skipped", "locked=failed"}) {
+		t.Fatalf("reported = %v", reported)
+	}
+	if !strings.Contains(results[2].Error, "owner") || !results[0].CompletedAt.Equal(fixedNow()) {
+		t.Fatalf("results = %#v", results)
+	}
+	want := []string{
+		"DELETE orgs/example/members/dormant",
+		"DELETE orgs/example/members/gone",
+		"DELETE orgs/example/members/locked",
+	}
+	if fmt.Sprint(client.requests) != fmt.Sprint(want) {
+		t.Fatalf("requests = %v", client.requests)
+	}
+}
+
+func TestExecuteUsesActionEndpoints(t *testing.T) {
+	t.Parallel()
+
+	client := &mockRESTClient{}
+	plan := &Plan{Organization: "example", Steps: []Step{
+		{Username: "one", Action: ActionConvert, Target: "example"},
+		{Username: "two", Action: ActionRemoveFromTeams, Target: "core"},
+	}}
+	if _, err := plan.Execute(context.Background(), client, fixedNow, nil); err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	want := []string{
+		"PUT orgs/example/outside_collaborators/one",
+		"DELETE orgs/example/teams/core/memberships/two",
+	}
+	if fmt.Sprint(client.requests) != fmt.Sprint(want) {
+		t.Fatalf("requests = %v", client.requests)
+	}
+}
+
+func TestExecuteStopsBetweenStepsWhenCancelled(t *testing.T) {
+	t.Parallel()
+
+	client := &mockRESTClient{}
+	plan := &Plan{Organization: "example", Steps: []Step{
+		{Username: "one", Action: ActionRemove, Target: "example"},
+		{Username: "two", Action: ActionRemove, Target: "example"},
+	}}
+	ctx, cancel := context.WithCancel(context.Background())
+	results, err := plan.Execute(ctx, client, fixedNow, func(Result) error {
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || len(results) != 1 {
+		t.Fatalf("results = %v, error = %v", results, err)
+	}
+	if fmt.Sprint(client.requests) != fmt.Sprint([]string{"DELETE orgs/example/members/one"}) {
+		t.Fatalf("requests = %v", client.requests)
+	}
+
+	// A result that cannot be logged stops the run
+	client = &mockRESTClient{}
+	results, err = plan.Execute(context.Background(), client, fixedNow, func(Result) error {
+		return errors.New("disk full")
+	})
+	if err == nil || len(results) != 1 || len(client.requests) != 1 {
+		t.Fatalf("results = %v, error = %v, requests = %v", results, err, client.requests)
+	}
+}
+
+func TestResultLogWritesEachResult(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "log.csv")
+	log, err := CreateResultLog(path)
+	if err != nil {
+		t.Fatalf("CreateResultLog returned error: %v", err)
+	}
+	defer log.Close()
+	if err := log.Write(Result{
+		Step:        Step{Username: "dormant", Action: ActionRemove, Target: "example"},
+		Status:      StatusFailed,
+		Error:       "boom",
+		CompletedAt: fixedNow(),
+	}); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	// The row is on disk before the log is closed
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open log: %v", err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("read log: %v", err)
+	}
+	if len(records) != 2 || strings.Join(records[1], ",") != "dormant,remove,example,failed,boom,2026-10-01T12:00:00Z" {
+		t.Fatalf("records = %v", records)
+	}
+}
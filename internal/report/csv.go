@@ -0,0 +1,122 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ssulei7/gh-dormant-users/internal/enterprise"
+)
+
+// Load reads a report in any format the report command writes as data: a
+// JSON or NDJSON report, or a CSV report for one or several organizations.
+// CSV reports carry no run metadata beyond the organizations they cover.
+func Load(filePath string) (*Report, error) {
+	structured, err := IsStructured(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("open report: %w", err)
+	}
+	if structured {
+		return Read(filePath)
+	}
+	return readCSV(filePath)
+}
+
+func readCSV(filePath string) (*Report, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("open report: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parse CSV report: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV report is empty")
+	}
+	columns := make(map[string]int)
+	for index, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = index
+	}
+	if _, ok := columns["username"]; !ok {
+		return nil, fmt.Errorf("CSV report has no Username column")
+	}
+	field := func(row []string, name string) string {
+		if index, ok := columns[name]; ok && index < len(row) {
+			return strings.TrimSpace(row[index])
+		}
+		return ""
+	}
+	list := func(value string) []string {
+		if value == "" || value == "none" {
+			return []string{}
+		}
+		items := strings.Split(value, ",")
+		for index := range items {
+			items[index] = strings.TrimSpace(items[index])
+		}
+		return items
+	}
+
+	report := &Report{SchemaVersion: SchemaVersion}
+	// Reports written before the EvidenceComplete column may have stopped
+	// early, so only the column can mark a report complete.
+	_, hasCompleteness := columns["evidencecomplete"]
+	report.Metadata.EvidenceComplete = hasCompleteness
+	for _, row := range records[1:] {
+		if !strings.EqualFold(field(row, "evidencecomplete"), "true") {
+			report.Metadata.EvidenceComplete = false
+		}
+		if strings.EqualFold(field(row, "partial"), "true") {
+			report.Metadata.Partial = true
+		}
+		user := User{
+			Login:         field(row, "username"),
+			Email:         field(row, "email"),
+			Active:        strings.EqualFold(field(row, "active"), "true"),
+			ActivityTypes: list(field(row, "activitytypes")),
+		}
+		if at, err := time.Parse(time.RFC3339, field(row, "lastactiveat")); err == nil {
+			user.LastActivity = &Evidence{
+				At:           at,
+				Organization: field(row, "lastactiveorganization"),
+				Repository:   field(row, "lastactiverepo"),
+				URL:          field(row, "evidenceurl"),
+			}
+		}
+		// Reports across organizations list memberships, with a column of
+		// activity types for each organization. Older reports named those
+		// columns after the organization alone.
+		activeIn := list(field(row, "activeorganizations"))
+		for _, name := range list(field(row, "organizations")) {
+			column := strings.ToLower(enterprise.OrganizationColumnPrefix + name)
+			if _, ok := columns[column]; !ok {
+				column = strings.ToLower(name)
+			}
+			activityTypes := list(field(row, column))
+			user.Organizations = append(user.Organizations, Organization{
+				Name:          name,
+				Active:        slices.Contains(activeIn, name),
+				ActivityTypes: activityTypes,
+			})
+			for _, activityType := range activityTypes {
+				if !slices.Contains(user.ActivityTypes, activityType) {
+					user.ActivityTypes = append(user.ActivityTypes, activityType)
+				}
+			}
+			if !slices.Contains(report.Metadata.Organizations, name) {
+				report.Metadata.Organizations = append(report.Metadata.Organizations, name)
+			}
+		}
+		slices.Sort(user.ActivityTypes)
+		report.Users = append(report.Users, user)
+	}
+	return report, nil
+}
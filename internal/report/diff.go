@@ -0,0 +1,221 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Kinds of change between two reports
+const (
+	ChangeNewlyDormant    = "newly-dormant"
+	ChangeReactivated     = "reactivated"
+	ChangeJoined          = "joined"
+	ChangeLeft            = "left"
+	ChangeActivityShifted = "activity-shifted"
+)
+
+// ChangeKinds lists the kinds of change in the order they are presented
+var ChangeKinds = []string{ChangeNewlyDormant, ChangeReactivated, ChangeJoined, ChangeLeft, ChangeActivityShifted}
+
+// Diff is what changed for each member between an earlier and a later
+// report. Caveats describe differences between the two runs that make the
+// comparison less reliable than it looks.
+type Diff struct {
+	SchemaVersion int      `json:"schema_version"`
+	Before        Metadata `json:"before"`
+	After         Metadata `json:"after"`
+	Caveats       []string `json:"caveats,omitempty"`
+	Changes       []Change `json:"changes"`
+}
+
+// Change is one member's change. Before is nil for members who joined and
+// After is nil for members who left.
+type Change struct {
+	Login           string     `json:"login"`
+	Kind            string     `json:"change"`
+	Before          *UserState `json:"before,omitempty"`
+	After           *UserState `json:"after,omitempty"`
+	ActivityAdded   []string   `json:"activity_added,omitempty"`
+	ActivityRemoved []string   `json:"activity_removed,omitempty"`
+}
+
+// UserState is a member's verdict in one report
+type UserState struct {
+	Active        bool     `json:"active"`
+	ActivityTypes []string `json:"activity_types"`
+}
+
+// Compare lists the members who joined, left, changed verdict or were seen
+// with different activity types between two reports. Logins are matched
+// ignoring case, as GitHub does. Members whose verdict and activity types
+// are unchanged are left out. Activity types are only compared when both
+// reports read every source in full, as otherwise they are just the first
+// evidence found.
+func Compare(before Report, after Report) Diff {
+	diff := Diff{
+		SchemaVersion: SchemaVersion,
+		Before:        before.Metadata,
+		After:         after.Metadata,
+		Caveats:       caveats(before.Metadata, after.Metadata),
+		Changes:       []Change{},
+	}
+	earlier := make(map[string]User, len(before.Users))
+	for _, user := range before.Users {
+		earlier[strings.ToLower(user.Login)] = user
+	}
+	seen := make(map[string]bool, len(after.Users))
+	compareTypes := before.Metadata.EvidenceComplete && after.Metadata.EvidenceComplete
+
+	for _, user := range after.Users {
+		key := strings.ToLower(user.Login)
+		seen[key] = true
+		change := Change{Login: user.Login, After: userState(user)}
+		previous, ok := earlier[key]
+		if !ok {
+			change.Kind = ChangeJoined
+			diff.Changes = append(diff.Changes, change)
+			continue
+		}
+		change.Before = userState(previous)
+		if compareTypes {
+			change.ActivityAdded = missingFrom(previous.ActivityTypes, user.ActivityTypes)
+			change.ActivityRemoved = missingFrom(user.ActivityTypes, previous.ActivityTypes)
+		}
+		switch {
+		case previous.Active && !user.Active:
+			change.Kind = ChangeNewlyDormant
+		case !previous.Active && user.Active:
+			change.Kind = ChangeReactivated
+		case len(change.ActivityAdded) > 0 || len(change.ActivityRemoved) > 0:
+			change.Kind = ChangeActivityShifted
+		default:
+			continue
+		}
+		diff.Changes = append(diff.Changes, change)
+	}
+	for _, user := range before.Users {
+		if !seen[strings.ToLower(user.Login)] {
+			diff.Changes = append(diff.Changes, Change{Login: user.Login, Kind: ChangeLeft, Before: userState(user)})
+		}
+	}
+
+	slices.SortFunc(diff.Changes, func(a, b Change) int {
+		if order := slices.Index(ChangeKinds, a.Kind) - slices.Index(ChangeKinds, b.Kind); order != 0 {
+			return order
+		}
+		return strings.Compare(strings.ToLower(a.Login), strings.ToLower(b.Login))
+	})
+	return diff
+}
+
+func userState(user User) *UserState {
+	activityTypes := user.ActivityTypes
+	if activityTypes == nil {
+		activityTypes = []string{}
+	}
+	return &UserState{Active: user.Active, ActivityTypes: activityTypes}
+}
+
+// missingFrom returns the entries of values that are not in reference
+func missingFrom(reference []string, values []string) []string {
+	var missing []string
+	for _, value := range values {
+		if !slices.Contains(reference, value) {
+			missing = append(missing, value)
+		}
+	}
+	return missing
+}
+
+// Count returns the number of changes of each kind
+func (d Diff) Count() map[string]int {
+	counts := make(map[string]int, len(ChangeKinds))
+	for _, change := range d.Changes {
+		counts[change.Kind]++
+	}
+	return counts
+}
+
+func caveats(before Metadata, after Metadata) []string {
+	var notes []string
+	if before.Partial || after.Partial {
+		notes = append(notes, "a report is partial, so members without recorded activity may not be dormant")
+	}
+	if !sameSet(before.Organizations, after.Organizations) {
+		notes = append(notes, fmt.Sprintf("the reports cover different organizations (%s and %s), so members of only one appear to join or leave",
+			listOrNone(before.Organizations), listOrNone(after.Organizations)))
+	}
+	if !before.EvidenceComplete || !after.EvidenceComplete {
+		notes = append(notes, "a run stopped reading activity once the verdicts could no longer change, so changes in activity types are not listed; use --activity-breakdown to compare them")
+	}
+	if len(before.ActivityTypes) > 0 && len(after.ActivityTypes) > 0 && !sameSet(before.ActivityTypes, after.ActivityTypes) {
+		notes = append(notes, fmt.Sprintf("the runs checked different activity types (%s and %s)",
+			strings.Join(before.ActivityTypes, ", "), strings.Join(after.ActivityTypes, ", ")))
+	}
+	return notes
+}
+
+func sameSet(a []string, b []string) bool {
+	return len(missingFrom(a, b)) == 0 && len(missingFrom(b, a)) == 0
+}
+
+func listOrNone(values []string) string {
+	if len(values) == 0 {
+		return "none recorded"
+	}
+	return strings.Join(values, ", ")
+}
+
+// EncodeDiff writes a diff as indented JSON
+func EncodeDiff(w io.Writer, diff Diff) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(diff)
+}
+
+// EncodeDiffCSV writes one row per change
+func EncodeDiffCSV(w io.Writer, diff Diff) error {
+	writer := csv.NewWriter(w)
+	header := []string{"Username", "Change", "ActiveBefore", "ActiveAfter", "ActivityTypesBefore", "ActivityTypesAfter", "ActivityAdded", "ActivityRemoved"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	state := func(state *UserState) (string, string) {
+		if state == nil {
+			return "", ""
+		}
+		return strconv.FormatBool(state.Active), activityList(state.ActivityTypes)
+	}
+	for _, change := range diff.Changes {
+		activeBefore, typesBefore := state(change.Before)
+		activeAfter, typesAfter := state(change.After)
+		record := []string{
+			change.Login,
+			change.Kind,
+			activeBefore,
+			activeAfter,
+			typesBefore,
+			typesAfter,
+			strings.Join(change.ActivityAdded, ","),
+			strings.Join(change.ActivityRemoved, ","),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// activityList writes activity types as the CSV reports do
+func activityList(activityTypes []string) string {
+	if len(activityTypes) == 0 {
+		return "none"
+	}
+	return strings.Join(activityTypes, ",")
+}
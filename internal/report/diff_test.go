@@ -0,0 +1,95 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCompareClassifiesChanges(t *testing.T) {
+	before := Report{
+		Metadata: Metadata{Organizations: []string{"example"}, ActivityTypes: []string{"commits", "issues"}, EvidenceComplete: true},
+		Users: []User{
+			{Login: "octocat", Active: true, ActivityTypes: []string{"commits"}},
+			{Login: "hubot", ActivityTypes: []string{}},
+			{Login: "Monalisa", Active: true, ActivityTypes: []string{"commits", "issues"}},
+			{Login: "departed", Active: true, ActivityTypes: []string{"issues"}},
+			{Login: "steady", ActivityTypes: []string{}},
+		},
+	}
+	after := Report{
+		Metadata: Metadata{Organizations: []string{"example"}, ActivityTypes: []string{"commits", "issues"}, EvidenceComplete: true},
+		Users: []User{
+			{Login: "octocat", ActivityTypes: []string{}},
+			{Login: "hubot", Active: true, ActivityTypes: []string{"issues"}},
+			{Login: "monalisa", Active: true, ActivityTypes: []string{"issues"}},
+			{Login: "newcomer", ActivityTypes: []string{}},
+			{Login: "steady", ActivityTypes: []string{}},
+		},
+	}
+	diff := Compare(before, after)
+
+	var got []string
+	for _, change := range diff.Changes {
+		got = append(got, change.Kind+" "+change.Login+" +"+strings.Join(change.ActivityAdded, ",")+" -"+strings.Join(change.ActivityRemoved, ","))
+	}
+	want := []string{
+		"newly-dormant octocat + -commits",
+		"reactivated hubot +issues -",
+		"joined newcomer + -",
+		"left departed + -",
+		"activity-shifted monalisa + -commits",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("changes =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if caveats := diff.Caveats; len(caveats) != 0 {
+		t.Fatalf("caveats = %v", caveats)
+	}
+}
+
+func TestCompareIgnoresActivityTypesOfRunsThatStoppedEarly(t *testing.T) {
+	diff := Compare(
+		Report{
+			Metadata: Metadata{EvidenceComplete: true},
+			Users:    []User{{Login: "octocat", Active: true, ActivityTypes: []string{"commits"}}, {Login: "hubot", Active: true, ActivityTypes: []string{"issues"}}},
+		},
+		Report{Users: []User{{Login: "octocat", Active: true, ActivityTypes: []string{"issues"}}, {Login: "hubot", ActivityTypes: []string{}}}},
+	)
+	if len(diff.Changes) != 1 || diff.Changes[0].Kind != ChangeNewlyDormant || diff.Changes[0].ActivityRemoved != nil {
+		t.Fatalf("changes = %+v", diff.Changes)
+	}
+	if len(diff.Caveats) != 1 || !strings.Contains(diff.Caveats[0], "changes in activity types are not listed") {
+		t.Fatalf("caveats = %v", diff.Caveats)
+	}
+}
+
+func TestDiffCaveats(t *testing.T) {
+	diff := Compare(
+		Report{Metadata: Metadata{Organizations: []string{"one"}, ActivityTypes: []string{"commits"}}},
+		Report{Metadata: Metadata{Organizations: []string{"two"}, ActivityTypes: []string{"issues"}, Partial: true}},
+	)
+	caveats := strings.Join(diff.Caveats, "\n")
+	for _, want := range []string{"partial", "different organizations (one and two)", "different activity types"} {
+		if !strings.Contains(caveats, want) {
+			t.Errorf("caveats do not mention %q:\n%s", want, caveats)
+		}
+	}
+}
+
+func TestEncodeDiffCSV(t *testing.T) {
+	diff := Compare(
+		Report{Metadata: Metadata{EvidenceComplete: true}, Users: []User{{Login: "octocat", Active: true, ActivityTypes: []string{"commits", "issues"}}}},
+		Report{Metadata: Metadata{EvidenceComplete: true}, Users: []User{{Login: "octocat", ActivityTypes: []string{}}, {Login: "hubot", ActivityTypes: []string{}}}},
+	)
+	var buffer bytes.Buffer
+	if err := EncodeDiffCSV(&buffer, diff); err != nil {
+		t.Fatalf("EncodeDiffCSV returned error: %v", err)
+	}
+	want := "Username,Change,ActiveBefore,ActiveAfter,ActivityTypesBefore,ActivityTypesAfter,ActivityAdded,ActivityRemoved\n" +
+		"octocat,newly-dormant,true,false,\"commits,issues\",none,,\"commits,issues\"\n" +
+		"hubot,joined,,false,,none,,\n"
+	if buffer.String() != want {
+		t.Fatalf("CSV =\n%s\nwant\n%s", buffer.String(), want)
+	}
+}
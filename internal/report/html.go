@@ -0,0 +1,184 @@
+package report
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ssulei7/gh-dormant-users/internal/ui"
+)
+
+//go:embed html.tmpl
+var htmlTemplateSource string
+
+// htmlTemplate renders a single file report. Styles and scripts are inline
+// so the file works offline and can be sent as an attachment.
+var htmlTemplate = template.Must(template.New("report").Parse(htmlTemplateSource))
+
+type reportView struct {
+	Report         Report
+	Title          string
+	Since          string
+	GeneratedAt    string
+	ActivityTypes  string
+	NotChecked     string
+	Total          int
+	Active         int
+	Dormant        int
+	DormantPercent float64
+	Split          []chartBar
+	Activity       []chartBar
+	Consolidated   bool
+	Users          []userRow
+	Analysis       string
+}
+
+// chartBar is one bar of a chart; Width is a percentage of the widest bar
+type chartBar struct {
+	Label string
+	Value int
+	Width float64
+	Class string
+}
+
+type userRow struct {
+	Login          string
+	Email          string
+	Status         string
+	ActivityTypes  string
+	LastActiveAt   string
+	LastActiveSort string
+	Repository     string
+	Organizations  string
+	URL            string
+}
+
+// WriteHTML saves a report as one self-contained HTML file. analysis, the
+// output of the analyze command, is added as a section when it is not empty.
+func WriteHTML(filePath string, report Report, analysis string) error {
+	ui.Info("Generating html report: %s", filePath)
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	if err := EncodeHTML(writer, report, analysis); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	ui.Success("Report saved to %s", filePath)
+	return nil
+}
+
+// EncodeHTML renders a report as HTML
+func EncodeHTML(w io.Writer, report Report, analysis string) error {
+	if err := htmlTemplate.Execute(w, newReportView(report, analysis)); err != nil {
+		return fmt.Errorf("render html report: %w", err)
+	}
+	return nil
+}
+
+func newReportView(report Report, analysis string) reportView {
+	metadata := report.Metadata
+	view := reportView{
+		Report:        report,
+		Title:         "Dormant users",
+		Since:         "an unknown date",
+		GeneratedAt:   "at an unknown time",
+		ActivityTypes: "all collected activity types",
+		NotChecked:    strings.Join(metadata.NotChecked, ", "),
+		Total:         len(report.Users),
+		Analysis:      strings.TrimSpace(analysis),
+	}
+	if metadata.Enterprise != "" {
+		view.Title += ": " + metadata.Enterprise + " enterprise"
+	} else if len(metadata.Organizations) > 0 {
+		view.Title += ": " + strings.Join(metadata.Organizations, ", ")
+	}
+	if !metadata.Since.IsZero() {
+		view.Since = metadata.Since.UTC().Format("January 2, 2006")
+	}
+	if !metadata.GeneratedAt.IsZero() {
+		view.GeneratedAt = metadata.GeneratedAt.UTC().Format("January 2, 2006 15:04 MST")
+	}
+	if len(metadata.ActivityTypes) > 0 {
+		view.ActivityTypes = strings.Join(metadata.ActivityTypes, ", ")
+	}
+
+	activityCounts := make(map[string]int)
+	for _, user := range report.Users {
+		row := userRow{
+			Login:         user.Login,
+			Email:         user.Email,
+			Status:        "dormant",
+			ActivityTypes: strings.Join(user.ActivityTypes, ", "),
+		}
+		if user.Active {
+			view.Active++
+			row.Status = "active"
+			for _, activityType := range user.ActivityTypes {
+				activityCounts[activityType]++
+			}
+		}
+		if evidence := user.LastActivity; evidence != nil {
+			row.LastActiveAt = evidence.At.UTC().Format("2006-01-02")
+			row.LastActiveSort = evidence.At.UTC().Format(time.RFC3339)
+			row.Repository = evidence.Repository
+			row.URL = evidence.URL
+		}
+		var organizations []string
+		for _, organization := range user.Organizations {
+			organizations = append(organizations, organization.Name)
+		}
+		if len(organizations) > 0 {
+			view.Consolidated = true
+			row.Organizations = strings.Join(organizations, ", ")
+		}
+		view.Users = append(view.Users, row)
+	}
+	view.Dormant = view.Total - view.Active
+	if view.Total > 0 {
+		view.DormantPercent = float64(view.Dormant) / float64(view.Total) * 100
+	}
+
+	view.Split = scaleBars([]chartBar{
+		{Label: "Active", Value: view.Active, Class: "active"},
+		{Label: "Dormant", Value: view.Dormant, Class: "dormant"},
+	})
+	for activityType, count := range activityCounts {
+		view.Activity = append(view.Activity, chartBar{Label: activityType, Value: count})
+	}
+	sort.Slice(view.Activity, func(i, j int) bool {
+		if view.Activity[i].Value != view.Activity[j].Value {
+			return view.Activity[i].Value > view.Activity[j].Value
+		}
+		return view.Activity[i].Label < view.Activity[j].Label
+	})
+	view.Activity = scaleBars(view.Activity)
+	return view
+}
+
+// scaleBars sets each bar's width relative to the largest value, as
+// ui.BarChart does in the terminal.
+func scaleBars(bars []chartBar) []chartBar {
+	largest := 0
+	for _, bar := range bars {
+		largest = max(largest, bar.Value)
+	}
+	for index := range bars {
+		if largest > 0 {
+			bars[index].Width = float64(bars[index].Value) / float64(largest) * 100
+		}
+	}
+	return bars
+}
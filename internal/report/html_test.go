@@ -0,0 +1,100 @@
+package report
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncodeHTMLIsSelfContained(t *testing.T) {
+	report := Report{
+		Metadata: Metadata{
+			Organizations: []string{"example"},
+			Since:         time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			ActivityTypes: []string{"commits", "issues"},
+			Partial:       true,
+			NotChecked:    []string{"copilot"},
+		},
+		Users: []User{
+			{Login: "octocat", Active: true, ActivityTypes: []string{"commits", "issues"}, LastActivity: &Evidence{At: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), Repository: "app", URL: "https://github.com/example/app/issues/1"}},
+			{Login: "monalisa", Active: true, ActivityTypes: []string{"commits"}},
+			{Login: "<script>alert(1)</script>", ActivityTypes: []string{}},
+		},
+	}
+	var buffer bytes.Buffer
+	if err := EncodeHTML(&buffer, report, "Keep octocat"); err != nil {
+		t.Fatalf("EncodeHTML returned error: %v", err)
+	}
+	page := buffer.String()
+
+	for _, want := range []string{
+		"Dormant users: example",
+		"Activity since March 1, 2024",
+		"Not checked: copilot.",
+		`<div class="bar active" style="width: 100%">`,
+		`<div class="bar dormant" style="width: 50%">`,
+		`<span>commits</span>`,
+		"Keep octocat",
+		`<a href="https://github.com/example/app/issues/1">link</a>`,
+		`data-sort="2024-03-02T00:00:00Z"`,
+		"&lt;script&gt;alert(1)&lt;/script&gt;",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("HTML report does not contain %q", want)
+		}
+	}
+	if external := regexp.MustCompile(`(?i)<(script|link|img)[^>]+(src|href)=`).FindString(page); external != "" {
+		t.Errorf("HTML report loads an external asset: %s", external)
+	}
+}
+
+func TestLoadReadsCSVReports(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "acme-enterprise-dormant-users.csv")
+	content := "Username,Email,Active,Organizations,ActiveOrganizations,LastActiveAt,LastActiveOrganization,LastActiveRepo,EvidenceURL,one,two\n" +
+		"octocat,,true,\"one,two\",two,2024-03-05T00:00:00Z,two,app,https://example.com,none,\"commits,issues\"\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write report: %v", err)
+	}
+	report, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if strings.Join(report.Metadata.Organizations, ",") != "one,two" || len(report.Users) != 1 {
+		t.Fatalf("report = %+v", report)
+	}
+	user := report.Users[0]
+	if !user.Active || strings.Join(user.ActivityTypes, ",") != "commits,issues" || user.LastActivity.Organization != "two" {
+		t.Fatalf("user = %+v", user)
+	}
+	if user.Organizations[0].Active || !user.Organizations[1].Active {
+		t.Fatalf("organizations = %+v", user.Organizations)
+	}
+}
+
+func TestLoadReadsPrefixedOrganizationColumns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.csv")
+	// An organization named email must not be read from the Email column.
+	content := "Username,Email,Active,Organizations,ActiveOrganizations,Org:email,Org:two\n" +
+		"octocat,octocat@example.com,true,\"email,two\",two,none,commits\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write report: %v", err)
+	}
+	report, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	user := report.Users[0]
+	if user.Email != "octocat@example.com" || len(user.Organizations) != 2 {
+		t.Fatalf("user = %+v", user)
+	}
+	if got := user.Organizations[0]; got.Name != "email" || got.Active || len(got.ActivityTypes) != 0 {
+		t.Fatalf("email organization = %+v", got)
+	}
+	if strings.Join(user.ActivityTypes, ",") != "commits" {
+		t.Fatalf("activity types = %v", user.ActivityTypes)
+	}
+}
@@ -0,0 +1,149 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"strings"
+
+	"github.com/ssulei7/gh-dormant-users/internal/ui"
+)
+
+// WriteMarkdown saves a report as Markdown
+func WriteMarkdown(filePath string, report Report) error {
+	ui.Info("Generating markdown report: %s", filePath)
+	if err := os.WriteFile(filePath, []byte(Markdown(report, 0)), 0o644); err != nil {
+		return err
+	}
+	ui.Success("Report saved to %s", filePath)
+	return nil
+}
+
+// Markdown renders a report for an issue or pull request: a summary table, a
+// checklist of dormant members and a collapsible section for each member.
+// Logins are not written as @mentions, so posting the report notifies
+// nobody. A positive limit caps the length, as issue bodies are limited;
+// member sections, and then checklist entries, that do not fit are left out
+// with a note.
+func Markdown(report Report, limit int) string {
+	view := newReportView(report, "")
+	var head strings.Builder
+	fmt.Fprintf(&head, "# %s\n\n", view.Title)
+	fmt.Fprintf(&head, "Activity since %s · %s · generated %s", view.Since, view.ActivityTypes, view.GeneratedAt)
+	if version := report.Metadata.ToolVersion; version != "" {
+		fmt.Fprintf(&head, " by gh-dormant-users %s", version)
+	}
+	head.WriteString("\n\n")
+	if report.Metadata.Partial {
+		head.WriteString("> [!WARNING]\n> The run was interrupted; members without recorded activity may not be dormant.")
+		if view.NotChecked != "" {
+			fmt.Fprintf(&head, " Not checked: %s.", view.NotChecked)
+		}
+		head.WriteString("\n\n")
+	}
+
+	head.WriteString("| | Members |\n|---|---:|\n")
+	fmt.Fprintf(&head, "| Total | %d |\n", view.Total)
+	fmt.Fprintf(&head, "| Active | %d (%.1f%%) |\n", view.Active, 100-view.DormantPercent)
+	fmt.Fprintf(&head, "| Dormant | %d (%.1f%%) |\n", view.Dormant, view.DormantPercent)
+	if organizations := len(report.Metadata.Organizations); organizations > 1 {
+		fmt.Fprintf(&head, "| Organizations | %d |\n", organizations)
+	}
+	if len(view.Activity) > 0 {
+		head.WriteString("\n| Activity type | Active members |\n|---|---:|\n")
+		for _, bar := range view.Activity {
+			fmt.Fprintf(&head, "| %s | %d |\n", markdownText(bar.Label), bar.Value)
+		}
+	}
+
+	var checklist []string
+	var details []string
+	for _, user := range report.Users {
+		if !user.Active {
+			checklist = append(checklist, fmt.Sprintf("- [ ] %s\n", markdownText(user.Login)))
+		}
+		details = append(details, markdownDetails(user))
+	}
+
+	var body strings.Builder
+	body.WriteString(head.String())
+	fits := func(text string, reserve int) bool {
+		return limit <= 0 || body.Len()+len(text)+reserve <= limit
+	}
+	// Room for the note about anything left out
+	const reserve = 200
+
+	if len(checklist) > 0 {
+		body.WriteString("\n## Dormant members\n\n")
+		for index, item := range checklist {
+			if !fits(item, reserve) {
+				fmt.Fprintf(&body, "\n%d more dormant members are listed in the full report.\n", len(checklist)-index)
+				return body.String()
+			}
+			body.WriteString(item)
+		}
+	}
+	if len(details) > 0 {
+		body.WriteString("\n## Members\n\n")
+		for index, section := range details {
+			if !fits(section, reserve) {
+				fmt.Fprintf(&body, "%d more members are listed in the full report.\n", len(details)-index)
+				break
+			}
+			body.WriteString(section)
+		}
+	}
+	return body.String()
+}
+
+func markdownDetails(user User) string {
+	status := "dormant"
+	if user.Active {
+		status = "active"
+	}
+	var section strings.Builder
+	fmt.Fprintf(&section, "<details>\n<summary>%s · %s</summary>\n\n", html.EscapeString(user.Login), status)
+	if user.Email != "" {
+		fmt.Fprintf(&section, "- Email: %s\n", markdownText(user.Email))
+	}
+	if len(user.ActivityTypes) > 0 {
+		fmt.Fprintf(&section, "- Activity types: %s\n", markdownText(strings.Join(user.ActivityTypes, ", ")))
+	} else {
+		section.WriteString("- Activity types: none\n")
+	}
+	if evidence := user.LastActivity; evidence != nil {
+		fmt.Fprintf(&section, "- Last active: %s", evidence.At.UTC().Format("2006-01-02"))
+		if evidence.Repository != "" {
+			fmt.Fprintf(&section, " in %s", markdownText(evidence.Repository))
+		}
+		if evidence.URL != "" {
+			fmt.Fprintf(&section, " ([evidence](%s))", evidence.URL)
+		}
+		section.WriteString("\n")
+	}
+	if seat := user.Copilot; seat != nil {
+		fmt.Fprintf(&section, "- Copilot seat: %t\n", seat.Assigned)
+	}
+	if len(user.Organizations) > 0 {
+		memberships := make([]string, 0, len(user.Organizations))
+		for _, organization := range user.Organizations {
+			verdict := "dormant"
+			if organization.Active {
+				verdict = "active"
+			}
+			memberships = append(memberships, fmt.Sprintf("%s (%s)", markdownText(organization.Name), verdict))
+		}
+		fmt.Fprintf(&section, "- Organizations: %s\n", strings.Join(memberships, ", "))
+	}
+	section.WriteString("\n</details>\n")
+	return section.String()
+}
+
+// markdownText escapes characters that Markdown or HTML would interpret
+func markdownText(text string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+		"<", "&lt;", ">", "&gt;", "|", `\|`,
+	)
+	return replacer.Replace(text)
+}
@@ -0,0 +1,64 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarkdownSummarizesReport(t *testing.T) {
+	report := Report{
+		Metadata: Metadata{
+			Organizations: []string{"example"},
+			Since:         time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			ActivityTypes: []string{"commits", "issues"},
+		},
+		Users: []User{
+			{Login: "octocat", Active: true, ActivityTypes: []string{"commits"}, LastActivity: &Evidence{At: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), Repository: "app", URL: "https://github.com/example/app/pull/1"}},
+			{Login: "hubot", Email: "hubot@example.com", ActivityTypes: []string{}},
+			{Login: "mona_lisa", ActivityTypes: []string{}},
+		},
+	}
+	body := Markdown(report, 0)
+
+	for _, want := range []string{
+		"# Dormant users: example",
+		"Activity since March 1, 2024",
+		"| Total | 3 |",
+		"| Dormant | 2 (66.7%) |",
+		"| commits | 1 |",
+		"- [ ] hubot\n",
+		"- [ ] mona\\_lisa\n",
+		"<summary>octocat · active</summary>",
+		"- Last active: 2024-03-02 in app ([evidence](https://github.com/example/app/pull/1))",
+		"- Email: hubot@example.com",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Markdown report does not contain %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "- [ ] octocat") {
+		t.Error("active member is listed as dormant")
+	}
+	if strings.Contains(body, "@hubot") || strings.Contains(body, "@octocat") {
+		t.Error("Markdown report mentions a member")
+	}
+}
+
+func TestMarkdownRespectsLimit(t *testing.T) {
+	report := Report{Metadata: Metadata{Organizations: []string{"example"}}}
+	for index := range 500 {
+		report.Users = append(report.Users, User{Login: strings.Repeat("x", 20) + string(rune('a'+index%26)), ActivityTypes: []string{}})
+	}
+	body := Markdown(report, 4000)
+
+	if len(body) > 4000 {
+		t.Fatalf("length = %d, want at most 4000", len(body))
+	}
+	if !strings.Contains(body, "more dormant members are listed in the full report") {
+		t.Fatalf("truncated report has no note:\n%s", body)
+	}
+	if full := Markdown(report, 0); !strings.Contains(full, "## Members") || strings.Contains(full, "full report") {
+		t.Fatal("unlimited report was truncated")
+	}
+}
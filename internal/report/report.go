@@ -0,0 +1,299 @@
+package report
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/ssulei7/gh-dormant-users/internal/enterprise"
+	"github.com/ssulei7/gh-dormant-users/internal/githubapi"
+	"github.com/ssulei7/gh-dormant-users/internal/ui"
+	"github.com/ssulei7/gh-dormant-users/internal/users"
+)
+
+// SchemaVersion is the version of the JSON and NDJSON report layout. It is
+// raised whenever a field changes meaning or is removed; new optional fields
+// keep the version.
+const SchemaVersion = 1
+
+// Formats are the report formats, CSV first as the default
+var Formats = []string{"csv", "json", "ndjson", "html", "markdown"}
+
+// Report is a structured dormant users report. As JSON it is one document;
+// as NDJSON the first line holds the schema version and metadata and each
+// following line is one user.
+type Report struct {
+	SchemaVersion int      `json:"schema_version"`
+	Metadata      Metadata `json:"metadata"`
+	Users         []User   `json:"users"`
+}
+
+// header is the first line of an NDJSON report
+type header struct {
+	SchemaVersion int      `json:"schema_version"`
+	Metadata      Metadata `json:"metadata"`
+}
+
+// Metadata describes the run that produced a report
+type Metadata struct {
+	Organizations []string  `json:"organizations"`
+	Enterprise    string    `json:"enterprise,omitempty"`
+	Hostname      string    `json:"hostname,omitempty"`
+	Since         time.Time `json:"since"`
+	ActivityTypes []string  `json:"activity_types"`
+	ToolVersion   string    `json:"tool_version"`
+	GeneratedAt   time.Time `json:"generated_at"`
+	// Partial is set when the run was interrupted; users without activity
+	// may not be dormant.
+	Partial    bool     `json:"partial,omitempty"`
+	NotChecked []string `json:"not_checked,omitempty"`
+	// EvidenceComplete is set when every source was read in full. Otherwise
+	// the scan stopped once the verdicts could no longer change, so users'
+	// activity types and last activity are the first evidence found.
+	EvidenceComplete bool      `json:"evidence_complete,omitempty"`
+	API              *APIStats `json:"api,omitempty"`
+}
+
+// APIStats summarizes the API requests of the run
+type APIStats struct {
+	Requests       int            `json:"requests"`
+	CacheHits      int            `json:"cache_hits"`
+	CacheErrors    int            `json:"cache_errors"`
+	Retries        int            `json:"retries"`
+	WaitSeconds    float64        `json:"wait_seconds"`
+	RateLimit      int            `json:"rate_limit,omitempty"`
+	RateRemaining  int            `json:"rate_remaining,omitempty"`
+	EndpointCounts map[string]int `json:"endpoint_counts,omitempty"`
+}
+
+// User is one member's verdict. Organizations is only set for reports across
+// several organizations.
+type User struct {
+	Login         string         `json:"login"`
+	Email         string         `json:"email,omitempty"`
+	Active        bool           `json:"active"`
+	ActivityTypes []string       `json:"activity_types"`
+	LastActivity  *Evidence      `json:"last_activity,omitempty"`
+	Copilot       *CopilotSeat   `json:"copilot,omitempty"`
+	Organizations []Organization `json:"organizations,omitempty"`
+}
+
+// Evidence is the newest activity found for a user
+type Evidence struct {
+	At           time.Time `json:"at"`
+	Organization string    `json:"organization,omitempty"`
+	Repository   string    `json:"repository,omitempty"`
+	URL          string    `json:"url,omitempty"`
+}
+
+// CopilotSeat is set when Copilot seats were checked
+type CopilotSeat struct {
+	Assigned           bool       `json:"assigned"`
+	LastActivityAt     *time.Time `json:"last_activity_at,omitempty"`
+	LastActivityEditor string     `json:"last_activity_editor,omitempty"`
+}
+
+// Organization is a user's membership in one scanned organization
+type Organization struct {
+	Name          string   `json:"name"`
+	Active        bool     `json:"active"`
+	ActivityTypes []string `json:"activity_types"`
+}
+
+// NewAPIStats converts the Coordinator's statistics for a report
+func NewAPIStats(stats githubapi.Stats) *APIStats {
+	return &APIStats{
+		Requests:       stats.Requests,
+		CacheHits:      stats.CacheHits,
+		CacheErrors:    stats.CacheErrors,
+		Retries:        stats.Retries,
+		WaitSeconds:    stats.WaitDuration.Seconds(),
+		RateLimit:      stats.RateLimit,
+		RateRemaining:  stats.RateRemaining,
+		EndpointCounts: stats.EndpointCounts,
+	}
+}
+
+// FromUsers converts the members of one organization
+func FromUsers(userList users.Users) []User {
+	records := make([]User, 0, len(userList))
+	for index := range userList {
+		user := &userList[index]
+		record := User{
+			Login:         user.Login,
+			Email:         user.Email,
+			Active:        user.IsActive(),
+			ActivityTypes: sortedActivityTypes(user),
+		}
+		if evidence := user.GetLastActivity(); evidence != (users.Evidence{}) {
+			record.LastActivity = &Evidence{At: evidence.At.UTC(), Repository: evidence.Repository, URL: evidence.URL}
+		}
+		record.Copilot = fromCopilotSeat(user.GetCopilotSeat())
+		records = append(records, record)
+	}
+	return records
+}
+
+// FromMembers converts members consolidated across organizations. A
+// member's activity types are those found in any organization.
+func FromMembers(members []enterprise.Member) []User {
+	records := make([]User, 0, len(members))
+	for _, member := range members {
+		record := User{Login: member.Login, Email: member.Email, Active: member.Active(), ActivityTypes: []string{}, Copilot: fromCopilotSeat(member.CopilotSeat())}
+		seen := make(map[string]bool)
+		for _, membership := range member.Memberships {
+			activityTypes := sortedActivityTypes(membership.User)
+			record.Organizations = append(record.Organizations, Organization{
+				Name:          membership.Organization,
+				Active:        membership.User.IsActive(),
+				ActivityTypes: activityTypes,
+			})
+			for _, activityType := range activityTypes {
+				if !seen[activityType] {
+					seen[activityType] = true
+					record.ActivityTypes = append(record.ActivityTypes, activityType)
+				}
+			}
+		}
+		sort.Strings(record.ActivityTypes)
+		if organization, evidence := member.LastActivity(); organization != "" {
+			record.LastActivity = &Evidence{At: evidence.At.UTC(), Organization: organization, Repository: evidence.Repository, URL: evidence.URL}
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// fromCopilotSeat is nil when seats were not checked
+func fromCopilotSeat(seat users.CopilotSeat) *CopilotSeat {
+	if !seat.Checked {
+		return nil
+	}
+	record := &CopilotSeat{Assigned: seat.Assigned, LastActivityEditor: seat.LastActivityEditor}
+	if !seat.LastActivityAt.IsZero() {
+		at := seat.LastActivityAt.UTC()
+		record.LastActivityAt = &at
+	}
+	return record
+}
+
+func sortedActivityTypes(user *users.User) []string {
+	activityTypes := user.GetActivityTypes()
+	if activityTypes == nil {
+		return []string{}
+	}
+	sort.Strings(activityTypes)
+	return activityTypes
+}
+
+// Write saves a report as json, ndjson, html or markdown, setting its schema
+// version
+func Write(filePath string, format string, report Report) error {
+	switch format {
+	case "html":
+		return WriteHTML(filePath, report, "")
+	case "markdown":
+		return WriteMarkdown(filePath, report)
+	}
+	ui.Info("Generating %s report: %s", format, filePath)
+	report.SchemaVersion = SchemaVersion
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	if err := Encode(writer, format, report); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	ui.Success("Report saved to %s", filePath)
+	return nil
+}
+
+// Encode writes a report as json or ndjson
+func Encode(w io.Writer, format string, report Report) error {
+	switch format {
+	case "json":
+		if report.Users == nil {
+			report.Users = []User{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case "ndjson":
+		encoder := json.NewEncoder(w)
+		if err := encoder.Encode(header{SchemaVersion: report.SchemaVersion, Metadata: report.Metadata}); err != nil {
+			return err
+		}
+		for _, user := range report.Users {
+			if err := encoder.Encode(user); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported report format %q", format)
+	}
+}
+
+// IsStructured reports whether a file holds a JSON or NDJSON report rather
+// than CSV.
+func IsStructured(filePath string) (bool, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	for {
+		b, err := reader.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+		default:
+			return b == '{', nil
+		}
+	}
+}
+
+// Read loads a JSON or NDJSON report. Reports with a newer schema version
+// are rejected rather than misread.
+func Read(filePath string) (*Report, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("open report: %w", err)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	var report Report
+	if err := decoder.Decode(&report); err != nil {
+		return nil, fmt.Errorf("decode report: %w", err)
+	}
+	if report.SchemaVersion < 1 || report.SchemaVersion > SchemaVersion {
+		return nil, fmt.Errorf("unsupported report schema version %d; this version reads up to %d", report.SchemaVersion, SchemaVersion)
+	}
+	// NDJSON reports continue with one user per line
+	for decoder.More() {
+		var user User
+		if err := decoder.Decode(&user); err != nil {
+			return nil, fmt.Errorf("decode report user %d: %w", len(report.Users)+1, err)
+		}
+		report.Users = append(report.Users, user)
+	}
+	return &report, nil
+}
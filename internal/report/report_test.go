@@ -0,0 +1,155 @@
+package report
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ssulei7/gh-dormant-users/internal/enterprise"
+	"github.com/ssulei7/gh-dormant-users/internal/githubapi"
+	"github.com/ssulei7/gh-dormant-users/internal/users"
+)
+
+func testUsers() users.Users {
+	userList := users.Users{{Login: "octocat", Email: "octocat@example.com"}, {Login: "hubot"}}
+	userList[0].RecordActivity("issues", users.Evidence{At: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), Repository: "example/app", URL: "https://github.com/example/app/issues/1"})
+	userList[0].RecordActivity("commits", users.Evidence{})
+	userList[1].SetCopilotSeat(users.CopilotSeat{Checked: true, Assigned: true})
+	return userList
+}
+
+func TestWriteAndReadBackEachFormat(t *testing.T) {
+	metadata := Metadata{
+		Organizations: []string{"example"},
+		Since:         time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		ActivityTypes: []string{"commits", "issues"},
+		ToolVersion:   "v1.2.3",
+		API:           NewAPIStats(githubapi.Stats{Requests: 12, WaitDuration: 1500 * time.Millisecond}),
+	}
+	for _, format := range []string{"json", "ndjson"} {
+		path := filepath.Join(t.TempDir(), "report."+format)
+		if err := Write(path, format, Report{Metadata: metadata, Users: FromUsers(testUsers())}); err != nil {
+			t.Fatalf("Write(%s) returned error: %v", format, err)
+		}
+		if structured, err := IsStructured(path); err != nil || !structured {
+			t.Fatalf("IsStructured(%s) = %v, %v", format, structured, err)
+		}
+
+		read, err := Read(path)
+		if err != nil {
+			t.Fatalf("Read(%s) returned error: %v", format, err)
+		}
+		if read.SchemaVersion != SchemaVersion || read.Metadata.ToolVersion != "v1.2.3" || read.Metadata.API.Requests != 12 || read.Metadata.API.WaitSeconds != 1.5 {
+			t.Fatalf("%s metadata = %+v", format, read.Metadata)
+		}
+		if len(read.Users) != 2 {
+			t.Fatalf("%s users = %+v", format, read.Users)
+		}
+		octocat, hubot := read.Users[0], read.Users[1]
+		if !octocat.Active || strings.Join(octocat.ActivityTypes, ",") != "commits,issues" || octocat.LastActivity.Repository != "example/app" || octocat.Copilot != nil {
+			t.Fatalf("%s octocat = %+v", format, octocat)
+		}
+		if hubot.Active || len(hubot.ActivityTypes) != 0 || hubot.LastActivity != nil || hubot.Copilot == nil || !hubot.Copilot.Assigned {
+			t.Fatalf("%s hubot = %+v", format, hubot)
+		}
+	}
+}
+
+func TestEncodeNDJSONWritesOneUserPerLine(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := Encode(&buffer, "ndjson", Report{SchemaVersion: SchemaVersion, Users: FromUsers(testUsers())}); err != nil {
+		t.Fatalf("Encode returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], `{"schema_version":1,"metadata":`) || !strings.HasPrefix(lines[1], `{"login":"octocat"`) {
+		t.Fatalf("ndjson =\n%s", buffer.String())
+	}
+}
+
+func TestFromMembersCombinesOrganizations(t *testing.T) {
+	one := users.Users{{Login: "octocat"}}
+	two := users.Users{{Login: "octocat"}}
+	one[0].RecordActivity("commits", users.Evidence{At: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), Repository: "one/app"})
+	two[0].RecordActivity("issues", users.Evidence{At: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), Repository: "two/app"})
+	two[0].SetCopilotSeat(users.CopilotSeat{Checked: true, Assigned: true, LastActivityEditor: "vscode/1.99.0"})
+	members := enterprise.Consolidate([]enterprise.OrganizationUsers{{Organization: "one", Users: one}, {Organization: "two", Users: two}})
+
+	records := FromMembers(members)
+	if len(records) != 1 {
+		t.Fatalf("records = %+v", records)
+	}
+	record := records[0]
+	if strings.Join(record.ActivityTypes, ",") != "commits,issues" || len(record.Organizations) != 2 || record.Organizations[1].Name != "two" {
+		t.Fatalf("record = %+v", record)
+	}
+	if record.LastActivity.Organization != "two" || record.LastActivity.Repository != "two/app" {
+		t.Fatalf("last activity = %+v", record.LastActivity)
+	}
+	if record.Copilot == nil || !record.Copilot.Assigned || record.Copilot.LastActivityEditor != "vscode/1.99.0" {
+		t.Fatalf("copilot = %+v", record.Copilot)
+	}
+}
+
+func TestReadRejectsNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := os.WriteFile(path, []byte(`{"schema_version": 99, "metadata": {}, "users": []}`), 0o600); err != nil {
+		t.Fatalf("write report: %v", err)
+	}
+	if _, err := Read(path); err == nil || !strings.Contains(err.Error(), "unsupported report schema version 99") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestIsStructuredRecognizesCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.csv")
+	if err := os.WriteFile(path, []byte("Username,Email,Active\n"), 0o600); err != nil {
+		t.Fatalf("write report: %v", err)
+	}
+	if structured, err := IsStructured(path); err != nil || structured {
+		t.Fatalf("IsStructured = %v, %v", structured, err)
+	}
+}
+
+func TestLoadCSVReadsEvidenceCompleteness(t *testing.T) {
+	directory := t.TempDir()
+	for name, content := range map[string]string{
+		"complete.csv":   "Username,Active,ActivityTypes,EvidenceComplete\noctocat,true,commits,true\n",
+		"stopped.csv":    "Username,Active,ActivityTypes,EvidenceComplete\noctocat,true,commits,false\n",
+		"unrecorded.csv": "Username,Active,ActivityTypes\noctocat,true,commits\n",
+	} {
+		path := filepath.Join(directory, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write report: %v", err)
+		}
+		loaded, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load(%s) returned error: %v", name, err)
+		}
+		if want := name == "complete.csv"; loaded.Metadata.EvidenceComplete != want {
+			t.Errorf("%s evidence complete = %v, want %v", name, loaded.Metadata.EvidenceComplete, want)
+		}
+	}
+}
+
+func TestLoadCSVReadsPartialMarker(t *testing.T) {
+	directory := t.TempDir()
+	for name, content := range map[string]string{
+		"partial.csv":  "Username,Active,ActivityTypes,Partial\noctocat,false,none,true\n",
+		"complete.csv": "Username,Active,ActivityTypes,Partial\noctocat,false,none,false\n",
+	} {
+		path := filepath.Join(directory, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write report: %v", err)
+		}
+		loaded, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load(%s) returned error: %v", name, err)
+		}
+		if want := name == "partial.csv"; loaded.Metadata.Partial != want {
+			t.Errorf("%s partial = %v, want %v", name, loaded.Metadata.Partial, want)
+		}
+	}
+}
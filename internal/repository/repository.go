@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -10,19 +11,20 @@ import (
 )
 
 type Repository struct {
-	Name     string     `json:"name"`
-	Size     int        `json:"size"`
-	PushedAt *time.Time `json:"pushed_at"`
+	Name           string     `json:"name"`
+	Size           int        `json:"size"`
+	PushedAt       *time.Time `json:"pushed_at"`
+	HasDiscussions bool       `json:"has_discussions"`
 }
 
 type Repositories []Repository
 
-func GetOrgRepositories(organization string, client api.RESTClient) (Repositories, error) {
+func GetOrgRepositories(ctx context.Context, organization string, client api.RESTClient) (Repositories, error) {
 	spinner := ui.NewSimpleSpinner("Fetching repositories...")
 	spinner.Start()
 
 	url := fmt.Sprintf("orgs/%s/repos?per_page=100", organization)
-	repositories, err := githubapi.GetAll[Repository](client, url)
+	repositories, err := githubapi.GetAll[Repository](ctx, client, url)
 	if err != nil {
 		spinner.StopFail("Failed to fetch repositories")
 		return nil, fmt.Errorf("fetch organization repositories: %w", err)
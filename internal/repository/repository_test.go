@@ -105,7 +105,7 @@ func TestGetOrgRepositories_SinglePage(t *testing.T) {
 		},
 	}
 
-	repos, err := GetOrgRepositories(org, mockClient)
+	repos, err := GetOrgRepositories(context.Background(), org, mockClient)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -139,7 +139,7 @@ func TestGetOrgRepositories_MultiplePages(t *testing.T) {
 		},
 	}
 
-	repos, err := GetOrgRepositories(org, mockClient)
+	repos, err := GetOrgRepositories(context.Background(), org, mockClient)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
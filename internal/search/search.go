@@ -0,0 +1,236 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/pkg/api"
+	"github.com/ssulei7/gh-dormant-users/internal/githubapi"
+)
+
+// CommitActivityTypes are answered by the commit search
+var CommitActivityTypes = []string{"commits"}
+
+// IssueActivityTypes are answered by the issue search: issues and pull
+// requests the user opened, and those the user commented on.
+var IssueActivityTypes = []string{"issues", "issue-comments", "pull-requests", "pr-comments"}
+
+// commentCandidates is how many of the most recently updated issues the user
+// commented on are checked for a comment of theirs since the date. An issue
+// can have been updated by others long after the user's last comment.
+const commentCandidates = 5
+
+// Match is the most recent search result proving a user's activity
+type Match struct {
+	ActivityType string
+	Repository   string
+	URL          string
+	At           time.Time
+}
+
+type commitSearchResult struct {
+	Items []struct {
+		HTMLURL    string `json:"html_url"`
+		Repository struct {
+			Name string `json:"name"`
+		} `json:"repository"`
+		Commit struct {
+			Committer struct {
+				Date time.Time `json:"date"`
+			} `json:"committer"`
+		} `json:"commit"`
+	} `json:"items"`
+}
+
+type issueSearchResult struct {
+	Items []issueItem `json:"items"`
+}
+
+type issueItem struct {
+	Number        int       `json:"number"`
+	HTMLURL       string    `json:"html_url"`
+	RepositoryURL string    `json:"repository_url"`
+	CreatedAt     time.Time `json:"created_at"`
+	PullRequest   *struct{} `json:"pull_request"`
+}
+
+type comment struct {
+	HTMLURL   string    `json:"html_url"`
+	CreatedAt time.Time `json:"created_at"`
+	User      struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+// UnsupportedActivityTypes returns the requested activity types that search
+// cannot answer.
+func UnsupportedActivityTypes(activityTypes []string) []string {
+	var unsupported []string
+	for _, activityType := range activityTypes {
+		if !slices.Contains(CommitActivityTypes, activityType) && !slices.Contains(IssueActivityTypes, activityType) {
+			unsupported = append(unsupported, activityType)
+		}
+	}
+	return unsupported
+}
+
+// FindCommitActivity returns the newest commit the user authored in the
+// organization since the date, or nil when there is none.
+func FindCommitActivity(ctx context.Context, organization string, login string, since time.Time, client api.RESTClient) (*Match, error) {
+	query := fmt.Sprintf("author:%s org:%s committer-date:>=%s", login, organization, since.UTC().Format(time.RFC3339))
+	var result commitSearchResult
+	if err := client.DoWithContext(ctx, http.MethodGet, searchPath("commits", query, "committer-date", 1), nil, &result); err != nil {
+		if isUnsearchableUser(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("search commits by %s: %w", login, err)
+	}
+	if len(result.Items) == 0 {
+		return nil, nil
+	}
+	item := result.Items[0]
+	return &Match{
+		ActivityType: "commits",
+		Repository:   item.Repository.Name,
+		URL:          item.HTMLURL,
+		At:           item.Commit.Committer.Date.UTC(),
+	}, nil
+}
+
+// FindIssueActivity returns the user's newest issue or pull request opened
+// in the organization since the date or, when there is none, a comment of
+// theirs made since the date, or nil when there is neither. Only the
+// requested activity types are searched. Assignments, mentions and review
+// requests, which are not the user's own activity, never match.
+func FindIssueActivity(ctx context.Context, organization string, login string, since time.Time, activityTypes []string, client api.RESTClient) (*Match, error) {
+	if qualifier, ok := kindQualifier(activityTypes, "issues", "pull-requests"); ok {
+		query := fmt.Sprintf("author:%s org:%s created:>=%s%s", login, organization, since.UTC().Format(time.RFC3339), qualifier)
+		items, err := searchIssues(ctx, searchPath("issues", query, "created", 1), client)
+		if err != nil {
+			return nil, fmt.Errorf("search issues opened by %s: %w", login, err)
+		}
+		if len(items) > 0 {
+			item := items[0]
+			activityType := "issues"
+			if item.PullRequest != nil {
+				activityType = "pull-requests"
+			}
+			return &Match{
+				ActivityType: activityType,
+				Repository:   path.Base(item.RepositoryURL),
+				URL:          item.HTMLURL,
+				At:           item.CreatedAt.UTC(),
+			}, nil
+		}
+	}
+
+	qualifier, ok := kindQualifier(activityTypes, "issue-comments", "pr-comments")
+	if !ok {
+		return nil, nil
+	}
+	query := fmt.Sprintf("commenter:%s org:%s updated:>=%s%s", login, organization, since.UTC().Format(time.RFC3339), qualifier)
+	items, err := searchIssues(ctx, searchPath("issues", query, "updated", commentCandidates), client)
+	if err != nil {
+		return nil, fmt.Errorf("search issues commented on by %s: %w", login, err)
+	}
+	for _, item := range items {
+		match, err := findComment(ctx, item, login, since, activityTypes, client)
+		if err != nil || match != nil {
+			return match, err
+		}
+	}
+	return nil, nil
+}
+
+// kindQualifier limits an issue search to issues or to pull requests when
+// only one of the two activity types is requested. ok is false when neither
+// is.
+func kindQualifier(activityTypes []string, issueType string, pullRequestType string) (qualifier string, ok bool) {
+	issues, pullRequests := slices.Contains(activityTypes, issueType), slices.Contains(activityTypes, pullRequestType)
+	switch {
+	case issues && pullRequests:
+		return "", true
+	case issues:
+		return " is:issue", true
+	case pullRequests:
+		return " is:pr", true
+	default:
+		return "", false
+	}
+}
+
+func searchIssues(ctx context.Context, searchURL string, client api.RESTClient) ([]issueItem, error) {
+	var result issueSearchResult
+	if err := client.DoWithContext(ctx, http.MethodGet, searchURL, nil, &result); err != nil {
+		if isUnsearchableUser(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return result.Items, nil
+}
+
+// findComment returns the user's newest comment on the issue or pull request
+// made since the date. Pull requests are checked for review comments as well
+// as conversation comments.
+func findComment(ctx context.Context, item issueItem, login string, since time.Time, activityTypes []string, client api.RESTClient) (*Match, error) {
+	_, repository, found := strings.Cut(item.RepositoryURL, "/repos/")
+	if !found {
+		return nil, fmt.Errorf("unexpected repository URL %q", item.RepositoryURL)
+	}
+	activityType := "issue-comments"
+	listings := []string{fmt.Sprintf("repos/%s/issues/%d/comments", repository, item.Number)}
+	if item.PullRequest != nil {
+		activityType = "pr-comments"
+		listings = append(listings, fmt.Sprintf("repos/%s/pulls/%d/comments", repository, item.Number))
+	}
+	if !slices.Contains(activityTypes, activityType) {
+		return nil, nil
+	}
+
+	var newest *comment
+	for _, listing := range listings {
+		comments, err := githubapi.GetAll[comment](ctx, client, fmt.Sprintf("%s?since=%s&per_page=100", listing, url.QueryEscape(since.UTC().Format(time.RFC3339))))
+		if err != nil {
+			return nil, fmt.Errorf("list comments on %s: %w", item.HTMLURL, err)
+		}
+		for index := range comments {
+			candidate := &comments[index]
+			if !strings.EqualFold(candidate.User.Login, login) || candidate.CreatedAt.Before(since) {
+				continue
+			}
+			if newest == nil || candidate.CreatedAt.After(newest.CreatedAt) {
+				newest = candidate
+			}
+		}
+	}
+	if newest == nil {
+		return nil, nil
+	}
+	return &Match{
+		ActivityType: activityType,
+		Repository:   path.Base(repository),
+		URL:          newest.HTMLURL,
+		At:           newest.CreatedAt.UTC(),
+	}, nil
+}
+
+// searchPath asks for the newest perPage results. Most searches only need
+// the newest one, as only its existence matters.
+func searchPath(searchType string, query string, sort string, perPage int) string {
+	return fmt.Sprintf("search/%s?q=%s&sort=%s&order=desc&per_page=%d", searchType, url.QueryEscape(query), sort, perPage)
+}
+
+// isUnsearchableUser reports the validation error returned when a login
+// cannot be searched, for example because the account no longer exists.
+func isUnsearchableUser(err error) bool {
+	var httpErr api.HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusUnprocessableEntity
+}
@@ -0,0 +1,234 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cli/go-gh/pkg/api"
+)
+
+// mockRESTClient answers requests for the paths in routes with their body,
+// and every other request with body.
+type mockRESTClient struct {
+	path   string
+	paths  []string
+	body   string
+	routes map[string]string
+	err    error
+}
+
+func (m *mockRESTClient) Request(_ string, path string, _ io.Reader) (*http.Response, error) {
+	m.path = path
+	m.paths = append(m.paths, path)
+	if m.err != nil {
+		return nil, m.err
+	}
+	body, ok := m.routes[path]
+	if !ok {
+		body = m.body
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(bytes.NewBufferString(body)),
+	}, nil
+}
+
+func (m *mockRESTClient) RequestWithContext(_ context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	return m.Request(method, path, body)
+}
+
+func (m *mockRESTClient) Do(method, path string, body io.Reader, result interface{}) error {
+	response, err := m.Request(method, path, body)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	return json.NewDecoder(response.Body).Decode(result)
+}
+
+func (m *mockRESTClient) DoWithContext(_ context.Context, method, path string, body io.Reader, result interface{}) error {
+	return m.Do(method, path, body, result)
+}
+
+func (m *mockRESTClient) Delete(path string, result interface{}) error {
+	return m.Do(http.MethodDelete, path, nil, result)
+}
+
+func (m *mockRESTClient) Get(path string, result interface{}) error {
+	return m.Do(http.MethodGet, path, nil, result)
+}
+
+func (m *mockRESTClient) Patch(path string, body io.Reader, result interface{}) error {
+	return m.Do(http.MethodPatch, path, body, result)
+}
+
+func (m *mockRESTClient) Post(path string, body io.Reader, result interface{}) error {
+	return m.Do(http.MethodPost, path, body, result)
+}
+
+func (m *mockRESTClient) Put(path string, body io.Reader, result interface{}) error {
+	return m.Do(http.MethodPut, path, body, result)
+}
+
+var since = time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFindCommitActivity(t *testing.T) {
+	t.Parallel()
+
+	client := &mockRESTClient{body: `{"total_count":3,"items":[{"html_url":"https://github.com/example/widgets/commit/abc","repository":{"name":"widgets"},"commit":{"committer":{"date":"2026-07-02T10:00:00+02:00"}}}]}`}
+	match, err := FindCommitActivity(context.Background(), "example", "octocat", since, client)
+	if err != nil {
+		t.Fatalf("FindCommitActivity returned error: %v", err)
+	}
+	want := "search/commits?q=author%3Aoctocat+org%3Aexample+committer-date%3A%3E%3D2026-07-01T00%3A00%3A00Z&sort=committer-date&order=desc&per_page=1"
+	if client.path != want {
+		t.Fatalf("request path = %q", client.path)
+	}
+	if match == nil || match.Repository != "widgets" || !match.At.Equal(time.Date(2026, 7, 2, 8, 0, 0, 0, time.UTC)) {
+		t.Fatalf("match = %#v", match)
+	}
+}
+
+var issueTypes = []string{"issues", "issue-comments", "pull-requests", "pr-comments"}
+
+func TestFindIssueActivityClassifiesPullRequests(t *testing.T) {
+	t.Parallel()
+
+	client := &mockRESTClient{body: `{"items":[{"number":4,"html_url":"https://github.com/example/widgets/issues/4","repository_url":"https://api.github.com/repos/example/widgets","created_at":"2026-07-03T00:00:00Z"}]}`}
+	match, err := FindIssueActivity(context.Background(), "example", "octocat", since, issueTypes, client)
+	if err != nil {
+		t.Fatalf("FindIssueActivity returned error: %v", err)
+	}
+	want := "search/issues?q=author%3Aoctocat+org%3Aexample+created%3A%3E%3D2026-07-01T00%3A00%3A00Z&sort=created&order=desc&per_page=1"
+	if client.path != want {
+		t.Fatalf("request path = %q", client.path)
+	}
+	if match == nil || match.ActivityType != "issues" || match.Repository != "widgets" || !match.At.Equal(time.Date(2026, 7, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("match = %#v", match)
+	}
+
+	client = &mockRESTClient{body: `{"items":[{"number":5,"html_url":"https://github.com/example/widgets/pull/5","repository_url":"https://api.github.com/repos/example/widgets","created_at":"2026-07-03T00:00:00Z","pull_request":{"url":"x"}}]}`}
+	match, err = FindIssueActivity(context.Background(), "example", "octocat", since, issueTypes, client)
+	if err != nil || match == nil || match.ActivityType != "pull-requests" {
+		t.Fatalf("match = %#v, err = %v", match, err)
+	}
+}
+
+func TestFindIssueActivityLimitsSearchToRequestedTypes(t *testing.T) {
+	t.Parallel()
+
+	client := &mockRESTClient{body: `{"items":[]}`}
+	match, err := FindIssueActivity(context.Background(), "example", "octocat", since, []string{"pull-requests"}, client)
+	if err != nil || match != nil {
+		t.Fatalf("match = %#v, err = %v", match, err)
+	}
+	if len(client.paths) != 1 || !strings.HasPrefix(client.paths[0], "search/issues?q=author%3Aoctocat+org%3Aexample+created%3A%3E%3D2026-07-01T00%3A00%3A00Z+is%3Apr&") {
+		t.Fatalf("requests = %v", client.paths)
+	}
+
+	client = &mockRESTClient{body: `{"items":[]}`}
+	if _, err := FindIssueActivity(context.Background(), "example", "octocat", since, []string{"issue-comments"}, client); err != nil {
+		t.Fatalf("FindIssueActivity returned error: %v", err)
+	}
+	if len(client.paths) != 1 || !strings.HasPrefix(client.paths[0], "search/issues?q=commenter%3Aoctocat+org%3Aexample+updated%3A%3E%3D2026-07-01T00%3A00%3A00Z+is%3Aissue&sort=updated&order=desc&per_page=5") {
+		t.Fatalf("requests = %v", client.paths)
+	}
+}
+
+func TestFindIssueActivityCountsOnlyTheUsersOwnComments(t *testing.T) {
+	t.Parallel()
+
+	const (
+		authored  = "search/issues?q=author%3Aoctocat+org%3Aexample+created%3A%3E%3D2026-07-01T00%3A00%3A00Z&sort=created&order=desc&per_page=1"
+		commented = "search/issues?q=commenter%3Aoctocat+org%3Aexample+updated%3A%3E%3D2026-07-01T00%3A00%3A00Z&sort=updated&order=desc&per_page=5"
+		first     = "repos/example/widgets/issues/7/comments?since=2026-07-01T00%3A00%3A00Z&per_page=100"
+		second    = "repos/example/gadgets/issues/3/comments?since=2026-07-01T00%3A00%3A00Z&per_page=100"
+	)
+	client := &mockRESTClient{routes: map[string]string{
+		authored: `{"items":[]}`,
+		// Issue 7 was updated since the date, but octocat's comment on it is
+		// older and was only edited since.
+		commented: `{"items":[
+			{"number":7,"html_url":"https://github.com/example/widgets/issues/7","repository_url":"https://api.github.com/repos/example/widgets"},
+			{"number":3,"html_url":"https://github.com/example/gadgets/issues/3","repository_url":"https://api.github.com/repos/example/gadgets"}
+		]}`,
+		first: `[
+			{"html_url":"https://github.com/example/widgets/issues/7#issuecomment-1","created_at":"2026-06-01T00:00:00Z","user":{"login":"octocat"}},
+			{"html_url":"https://github.com/example/widgets/issues/7#issuecomment-2","created_at":"2026-07-05T00:00:00Z","user":{"login":"hubot"}}
+		]`,
+		second: `[
+			{"html_url":"https://github.com/example/gadgets/issues/3#issuecomment-8","created_at":"2026-07-02T00:00:00Z","user":{"login":"Octocat"}},
+			{"html_url":"https://github.com/example/gadgets/issues/3#issuecomment-9","created_at":"2026-07-04T00:00:00Z","user":{"login":"octocat"}}
+		]`,
+	}}
+
+	match, err := FindIssueActivity(context.Background(), "example", "octocat", since, issueTypes, client)
+	if err != nil {
+		t.Fatalf("FindIssueActivity returned error: %v", err)
+	}
+	if match == nil || match.ActivityType != "issue-comments" || match.Repository != "gadgets" ||
+		match.URL != "https://github.com/example/gadgets/issues/3#issuecomment-9" || !match.At.Equal(time.Date(2026, 7, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("match = %#v", match)
+	}
+}
+
+func TestFindIssueActivityChecksReviewCommentsOnPullRequests(t *testing.T) {
+	t.Parallel()
+
+	const (
+		commented = "search/issues?q=commenter%3Aoctocat+org%3Aexample+updated%3A%3E%3D2026-07-01T00%3A00%3A00Z+is%3Apr&sort=updated&order=desc&per_page=5"
+		review    = "repos/example/widgets/pulls/5/comments?since=2026-07-01T00%3A00%3A00Z&per_page=100"
+	)
+	client := &mockRESTClient{body: `[]`, routes: map[string]string{
+		commented: `{"items":[{"number":5,"html_url":"https://github.com/example/widgets/pull/5","repository_url":"https://api.github.com/repos/example/widgets","pull_request":{}}]}`,
+		review:    `[{"html_url":"https://github.com/example/widgets/pull/5#discussion_r1","created_at":"2026-07-06T00:00:00Z","user":{"login":"octocat"}}]`,
+	}}
+
+	match, err := FindIssueActivity(context.Background(), "example", "octocat", since, []string{"pr-comments"}, client)
+	if err != nil {
+		t.Fatalf("FindIssueActivity returned error: %v", err)
+	}
+	if match == nil || match.ActivityType != "pr-comments" || match.URL != "https://github.com/example/widgets/pull/5#discussion_r1" {
+		t.Fatalf("match = %#v, requests = %v", match, client.paths)
+	}
+}
+
+func TestFindActivityWithoutResults(t *testing.T) {
+	t.Parallel()
+
+	match, err := FindCommitActivity(context.Background(), "example", "octocat", since, &mockRESTClient{body: `{"items":[]}`})
+	if err != nil || match != nil {
+		t.Fatalf("match = %#v, err = %v", match, err)
+	}
+	unsearchable := &mockRESTClient{err: api.HTTPError{StatusCode: http.StatusUnprocessableEntity}}
+	match, err = FindIssueActivity(context.Background(), "example", "ghost", since, issueTypes, unsearchable)
+	if err != nil || match != nil {
+		t.Fatalf("unsearchable user: match = %#v, err = %v", match, err)
+	}
+}
+
+func TestFindActivityWrapsErrors(t *testing.T) {
+	t.Parallel()
+
+	client := &mockRESTClient{err: api.HTTPError{StatusCode: http.StatusForbidden}}
+	_, err := FindCommitActivity(context.Background(), "example", "octocat", since, client)
+	if err == nil || !strings.Contains(err.Error(), "search commits by octocat") {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func TestUnsupportedActivityTypes(t *testing.T) {
+	t.Parallel()
+
+	got := UnsupportedActivityTypes([]string{"commits", "pr-reviews", "issue-comments", "discussions"})
+	if strings.Join(got, ",") != "pr-reviews,discussions" {
+		t.Fatalf("unsupported = %v", got)
+	}
+}
@@ -1,10 +1,12 @@
 package users
 
 import (
-	"errors"
+	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/cli/go-gh/pkg/api"
 	"github.com/ssulei7/gh-dormant-users/internal/githubapi"
@@ -19,18 +21,50 @@ type User struct {
 	Email         string `json:"email"`
 	Active        bool
 	ActivityTypes map[string]bool
+	LastActivity  Evidence
+	Copilot       CopilotSeat
 	mu            sync.Mutex
 }
 
+// Evidence identifies the API object that proved a user was active
+type Evidence struct {
+	At         time.Time
+	Repository string
+	URL        string
+}
+
+// CopilotSeat describes a user's Copilot seat. Checked is false when seats
+// were not part of the run.
+type CopilotSeat struct {
+	Checked            bool
+	Assigned           bool
+	LastActivityAt     time.Time
+	LastActivityEditor string
+}
+
+// Columns are the seat's CSV columns: whether it is assigned, when it was
+// last used and from which editor. Every column is empty when seats were not
+// checked.
+func (s CopilotSeat) Columns() []string {
+	if !s.Checked {
+		return []string{"", "", ""}
+	}
+	lastActivityAt := ""
+	if !s.LastActivityAt.IsZero() {
+		lastActivityAt = s.LastActivityAt.UTC().Format(time.RFC3339)
+	}
+	return []string{strconv.FormatBool(s.Assigned), lastActivityAt, s.LastActivityEditor}
+}
+
 type Users []User
 
-func GetOrganizationUsers(organization string, email bool, restClient api.RESTClient, gqlClient api.GQLClient) (Users, error) {
+func GetOrganizationUsers(ctx context.Context, organization string, email bool, restClient api.RESTClient, gqlClient api.GQLClient) (Users, error) {
 	ui.Info("Starting to fetch users for organization: %s", organization)
 	spinner := ui.NewSimpleSpinner("Fetching users...")
 	spinner.Start()
 
 	url := fmt.Sprintf("orgs/%s/members?per_page=100", organization)
-	userList, err := githubapi.GetAll[User](restClient, url)
+	userList, err := githubapi.GetAll[User](ctx, restClient, url)
 	if err != nil {
 		spinner.StopFail("Failed to fetch users")
 		return nil, fmt.Errorf("fetch organization users: %w", err)
@@ -43,7 +77,7 @@ func GetOrganizationUsers(organization string, email bool, restClient api.RESTCl
 			spinner.StopFail("Failed to get user emails")
 			return nil, fmt.Errorf("GraphQL client is required to fetch user emails")
 		}
-		if err := getUserEmails(users, gqlClient); err != nil {
+		if err := getUserEmails(ctx, users, gqlClient); err != nil {
 			spinner.StopFail("Failed to get user emails")
 			return nil, err
 		}
@@ -90,6 +124,39 @@ func (u *User) MarkActiveWithType(t string) {
 	u.Active = true
 }
 
+// RecordActivity marks the user active with the given activity type and keeps
+// the evidence if it is newer than anything recorded so far.
+func (u *User) RecordActivity(t string, evidence Evidence) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.ActivityTypes == nil {
+		u.ActivityTypes = make(map[string]bool)
+	}
+	u.ActivityTypes[t] = true
+	u.Active = true
+	if evidence.At.After(u.LastActivity.At) || u.LastActivity == (Evidence{}) {
+		u.LastActivity = evidence
+	}
+}
+
+func (u *User) GetLastActivity() Evidence {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.LastActivity
+}
+
+func (u *User) SetCopilotSeat(seat CopilotSeat) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.Copilot = seat
+}
+
+func (u *User) GetCopilotSeat() CopilotSeat {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.Copilot
+}
+
 func (u *User) GetActivityTypes() []string {
 	u.mu.Lock()
 	defer u.mu.Unlock()
@@ -103,17 +170,17 @@ func (u *User) GetActivityTypes() []string {
 	return activityTypes
 }
 
-func getUserEmails(users Users, client api.GQLClient) error {
+func getUserEmails(ctx context.Context, users Users, client api.GQLClient) error {
 	for start := 0; start < len(users); start += emailBatchSize {
 		end := min(start+emailBatchSize, len(users))
-		if err := getUserEmailBatch(users[start:end], client); err != nil {
+		if err := getUserEmailBatch(ctx, users[start:end], client); err != nil {
 			return fmt.Errorf("fetch user email batch starting at %d: %w", start, err)
 		}
 	}
 	return nil
 }
 
-func getUserEmailBatch(users Users, client api.GQLClient) error {
+func getUserEmailBatch(ctx context.Context, users Users, client api.GQLClient) error {
 	declarations := make([]string, 0, len(users))
 	fields := make([]string, 0, len(users))
 	variables := make(map[string]interface{}, len(users))
@@ -134,7 +201,7 @@ func getUserEmailBatch(users Users, client api.GQLClient) error {
 		Email string `json:"email"`
 	}
 	result := make(map[string]*profile, len(users))
-	if err := client.Do(query, variables, &result); err != nil && !isPartialUserLookupError(err, aliases) {
+	if err := client.DoWithContext(ctx, query, variables, &result); err != nil && !isPartialUserLookupError(err, aliases) {
 		return err
 	}
 
@@ -151,21 +218,5 @@ func getUserEmailBatch(users Users, client api.GQLClient) error {
 }
 
 func isPartialUserLookupError(err error, aliases map[string]struct{}) bool {
-	var gqlError api.GQLError
-	if !errors.As(err, &gqlError) || len(gqlError.Errors) == 0 {
-		return false
-	}
-	for _, item := range gqlError.Errors {
-		if len(item.Path) == 0 {
-			return false
-		}
-		alias, ok := item.Path[0].(string)
-		if !ok {
-			return false
-		}
-		if _, ok := aliases[alias]; !ok {
-			return false
-		}
-	}
-	return true
+	return githubapi.IsPartialAliasError(err, aliases)
 }
@@ -12,6 +12,7 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/cli/go-gh/pkg/api"
 )
@@ -118,7 +119,7 @@ func TestGetUserEmailsBatchesGraphQLRequests(t *testing.T) {
 	}
 	client := &mockGQLClient{}
 
-	if err := getUserEmails(userList, client); err != nil {
+	if err := getUserEmails(context.Background(), userList, client); err != nil {
 		t.Fatalf("getUserEmails returned error: %v", err)
 	}
 	if client.calls != 60 {
@@ -137,7 +138,7 @@ func TestGetUserEmailsKeepsPartialGraphQLData(t *testing.T) {
 	userList := Users{{Login: "available"}, {Login: "removed"}}
 	client := &partialErrorGQLClient{}
 
-	if err := getUserEmails(userList, client); err != nil {
+	if err := getUserEmails(context.Background(), userList, client); err != nil {
 		t.Fatalf("getUserEmails returned error: %v", err)
 	}
 	if userList[0].Email != "available@example.com" {
@@ -151,7 +152,7 @@ func TestGetUserEmailsKeepsPartialGraphQLData(t *testing.T) {
 func TestEmailQueryUsesVariables(t *testing.T) {
 	client := &capturingGQLClient{}
 	userList := Users{{Login: `quote"login`}}
-	if err := getUserEmails(userList, client); err != nil {
+	if err := getUserEmails(context.Background(), userList, client); err != nil {
 		t.Fatalf("getUserEmails returned error: %v", err)
 	}
 	if regexp.MustCompile(`quote`).MatchString(client.query) {
@@ -187,11 +188,32 @@ func TestUserActivityState(t *testing.T) {
 	}
 }
 
+func TestRecordActivityKeepsNewestEvidence(t *testing.T) {
+	t.Parallel()
+
+	user := User{Login: "octocat"}
+	newer := Evidence{At: time.Date(2026, 7, 5, 0, 0, 0, 0, time.UTC), Repository: "new", URL: "https://example.test/new"}
+	older := Evidence{At: time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC), Repository: "old", URL: "https://example.test/old"}
+
+	user.RecordActivity("commits", Evidence{Repository: "undated"})
+	if got := user.GetLastActivity(); got.Repository != "undated" {
+		t.Fatalf("first evidence = %#v", got)
+	}
+	user.RecordActivity("issues", newer)
+	user.RecordActivity("pr-comments", older)
+	if got := user.GetLastActivity(); got != newer {
+		t.Fatalf("last activity = %#v, want %#v", got, newer)
+	}
+	if !user.IsActive() || len(user.GetActivityTypes()) != 3 {
+		t.Fatalf("user = active %v types %v", user.IsActive(), user.GetActivityTypes())
+	}
+}
+
 func TestGetOrganizationUsersWithoutEmail(t *testing.T) {
 	t.Parallel()
 
 	restClient := &mockRESTClient{body: `[{"login":"octocat","id":1}]`}
-	userList, err := GetOrganizationUsers("example", false, restClient, nil)
+	userList, err := GetOrganizationUsers(context.Background(), "example", false, restClient, nil)
 	if err != nil {
 		t.Fatalf("GetOrganizationUsers returned error: %v", err)
 	}
@@ -208,7 +230,7 @@ func TestGetOrganizationUsersWithEmail(t *testing.T) {
 
 	restClient := &mockRESTClient{body: `[{"login":"octocat","id":1}]`}
 	gqlClient := &mockGQLClient{}
-	userList, err := GetOrganizationUsers("example", true, restClient, gqlClient)
+	userList, err := GetOrganizationUsers(context.Background(), "example", true, restClient, gqlClient)
 	if err != nil {
 		t.Fatalf("GetOrganizationUsers returned error: %v", err)
 	}
@@ -221,7 +243,7 @@ func TestGetOrganizationUsersRequiresGraphQLForEmail(t *testing.T) {
 	t.Parallel()
 
 	restClient := &mockRESTClient{body: `[{"login":"octocat","id":1}]`}
-	_, err := GetOrganizationUsers("example", true, restClient, nil)
+	_, err := GetOrganizationUsers(context.Background(), "example", true, restClient, nil)
 	if err == nil || !strings.Contains(err.Error(), "GraphQL client is required") {
 		t.Fatalf("error = %v", err)
 	}
@@ -231,7 +253,7 @@ func TestGetOrganizationUsersWrapsRESTError(t *testing.T) {
 	t.Parallel()
 
 	restClient := &mockRESTClient{err: errors.New("boom")}
-	_, err := GetOrganizationUsers("example", false, restClient, nil)
+	_, err := GetOrganizationUsers(context.Background(), "example", false, restClient, nil)
 	if err == nil || !strings.Contains(err.Error(), "fetch organization users") || !strings.Contains(err.Error(), "boom") {
 		t.Fatalf("error = %v", err)
 	}
@@ -241,7 +263,7 @@ func TestGetUserEmailsWrapsBatchError(t *testing.T) {
 	t.Parallel()
 
 	userList := Users{{Login: "octocat"}}
-	err := getUserEmails(userList, &errorGQLClient{err: errors.New("boom")})
+	err := getUserEmails(context.Background(), userList, &errorGQLClient{err: errors.New("boom")})
 	if err == nil || !strings.Contains(err.Error(), "fetch user email batch starting at 0") {
 		t.Fatalf("error = %v", err)
 	}
@@ -284,6 +306,10 @@ func (c *capturingGQLClient) Do(query string, variables map[string]interface{},
 	return c.mockGQLClient.Do(query, variables, response)
 }
 
+func (c *capturingGQLClient) DoWithContext(_ context.Context, query string, variables map[string]interface{}, response interface{}) error {
+	return c.Do(query, variables, response)
+}
+
 type partialErrorGQLClient struct {
 	mockGQLClient
 }
@@ -300,6 +326,10 @@ func (c *partialErrorGQLClient) Do(query string, variables map[string]interface{
 	}}}
 }
 
+func (c *partialErrorGQLClient) DoWithContext(_ context.Context, query string, variables map[string]interface{}, response interface{}) error {
+	return c.Do(query, variables, response)
+}
+
 type errorGQLClient struct {
 	mockGQLClient
 	err error
@@ -308,3 +338,7 @@ type errorGQLClient struct {
 func (c *errorGQLClient) Do(_ string, _ map[string]interface{}, _ interface{}) error {
 	return c.err
 }
+
+func (c *errorGQLClient) DoWithContext(_ context.Context, _ string, _ map[string]interface{}, _ interface{}) error {
+	return c.err
+}